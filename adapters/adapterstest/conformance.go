@@ -0,0 +1,203 @@
+package adapterstest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// ConformanceRule names one of the rules which every Bidder must follow.
+type ConformanceRule string
+
+const (
+	// RuleNoNilElements: the requests, bids and errors returned by the Bidder don't contain nil elements.
+	RuleNoNilElements ConformanceRule = "no-nil-elements"
+	// RuleTypedErrors: the errors returned by the Bidder are errortypes, such as a BadInput for bad requests or a
+	// BadServerResponse for bad responses, so that PBS can tell them apart.
+	RuleTypedErrors ConformanceRule = "typed-errors"
+	// RuleValidRequests: the HTTP requests made by the Bidder have a method and an absolute http(s) URI.
+	RuleValidRequests ConformanceRule = "valid-requests"
+	// RuleImpIDsPreserved: the bids returned by the Bidder are for the imps of the request it was given.
+	RuleImpIDsPreserved ConformanceRule = "imp-ids-preserved"
+	// RuleValidBidTypes: the bids returned by the Bidder have one of the media types known to PBS.
+	RuleValidBidTypes ConformanceRule = "valid-bid-types"
+	// RuleCurrencySet: the BidderResponses returned by the Bidder have a currency.
+	RuleCurrencySet ConformanceRule = "currency-set"
+	// RuleNoContentNoBids: the Bidder returns neither bids nor errors for 204 responses, and no bids for
+	// responses with an error status.
+	RuleNoContentNoBids ConformanceRule = "no-content-no-bids"
+)
+
+// ConformanceRules are all the rules checked by RunConformanceTests.
+var ConformanceRules = []ConformanceRule{
+	RuleNoNilElements,
+	RuleTypedErrors,
+	RuleValidRequests,
+	RuleImpIDsPreserved,
+	RuleValidBidTypes,
+	RuleCurrencySet,
+	RuleNoContentNoBids,
+}
+
+var validBidTypes = map[openrtb_ext.BidType]bool{
+	openrtb_ext.BidTypeBanner: true,
+	openrtb_ext.BidTypeVideo:  true,
+	openrtb_ext.BidTypeAudio:  true,
+	openrtb_ext.BidTypeNative: true,
+}
+
+// RunConformanceTests checks that a Bidder follows the rules which every Bidder must follow, whatever the
+// bidder-specific behavior which its JSON test files expect.
+//
+// The requests and mock responses are taken from the JSON test files in rootDir, which has the same layout as the
+// one given to RunJSONBidderTest. The Bidder is also given responses with a 204 and a 500 status for each of
+// its requests.
+//
+// The rules in skip aren't checked. They're meant for the Bidders which broke a rule before it was enforced.
+func RunConformanceTests(t *testing.T, rootDir string, bidder adapters.Bidder, skip ...ConformanceRule) {
+	t.Helper()
+
+	checker := &conformanceChecker{
+		t:     t,
+		rules: make(map[ConformanceRule]bool, len(ConformanceRules)),
+	}
+	for _, rule := range ConformanceRules {
+		checker.rules[rule] = true
+	}
+	for _, rule := range skip {
+		delete(checker.rules, rule)
+	}
+
+	for _, subDir := range []string{"exemplary", "supplemental", "amp", "video"} {
+		specFiles, err := ioutil.ReadDir(filepath.Join(rootDir, subDir))
+		if err != nil {
+			continue
+		}
+		for _, specFile := range specFiles {
+			fileName := filepath.Join(rootDir, subDir, specFile.Name())
+			spec, err := loadFile(fileName)
+			if err != nil {
+				t.Fatalf("Failed to load contents of file %s: %v", fileName, err)
+			}
+			checker.checkSpec(fileName, spec, bidder)
+		}
+	}
+}
+
+type conformanceChecker struct {
+	t     *testing.T
+	rules map[ConformanceRule]bool
+}
+
+func (c *conformanceChecker) errorf(rule ConformanceRule, format string, args ...interface{}) {
+	if c.rules[rule] {
+		c.t.Errorf("[%s] %s", rule, fmt.Sprintf(format, args...))
+	}
+}
+
+func (c *conformanceChecker) checkSpec(fileName string, spec *testSpec, bidder adapters.Bidder) {
+	impIDs := make(map[string]bool, len(spec.BidRequest.Imp))
+	for _, imp := range spec.BidRequest.Imp {
+		impIDs[imp.ID] = true
+	}
+
+	// The Bidders may change the request they're given, so they get a copy of it.
+	request := spec.BidRequest
+	request.Imp = append([]openrtb.Imp(nil), spec.BidRequest.Imp...)
+
+	reqs, errs := bidder.MakeRequests(&request, &adapters.ExtraRequestInfo{})
+	c.checkErrors(fmt.Sprintf("%s: MakeRequests", fileName), errs)
+	for i, req := range reqs {
+		description := fmt.Sprintf("%s: httpRequest[%d]", fileName, i)
+		if req == nil {
+			c.errorf(RuleNoNilElements, "%s was nil", description)
+			continue
+		}
+		c.checkRequest(description, req)
+
+		if i < len(spec.HttpCalls) {
+			response := spec.HttpCalls[i].Response.ToResponseData(c.t)
+			c.checkBids(fmt.Sprintf("%s: MakeBids[%d]", fileName, i), bidder, &spec.BidRequest, req, response, impIDs)
+		}
+
+		noContent := &adapters.ResponseData{StatusCode: http.StatusNoContent, Headers: http.Header{}}
+		bidderResponse, errs := c.checkBids(fmt.Sprintf("%s: MakeBids[%d] with a 204 response", fileName, i), bidder, &spec.BidRequest, req, noContent, impIDs)
+		if countBids(bidderResponse) > 0 || len(errs) > 0 {
+			c.errorf(RuleNoContentNoBids, "%s: MakeBids[%d] returned %d bids and %d errors for a 204 response", fileName, i, countBids(bidderResponse), len(errs))
+		}
+
+		serverError := &adapters.ResponseData{StatusCode: http.StatusInternalServerError, Headers: http.Header{}}
+		bidderResponse, _ = c.checkBids(fmt.Sprintf("%s: MakeBids[%d] with a 500 response", fileName, i), bidder, &spec.BidRequest, req, serverError, impIDs)
+		if countBids(bidderResponse) > 0 {
+			c.errorf(RuleNoContentNoBids, "%s: MakeBids[%d] returned %d bids for a 500 response", fileName, i, countBids(bidderResponse))
+		}
+	}
+}
+
+func (c *conformanceChecker) checkRequest(description string, req *adapters.RequestData) {
+	if req.Method == "" {
+		c.errorf(RuleValidRequests, "%s has no method", description)
+	}
+	if uri, err := url.Parse(req.Uri); err != nil || (uri.Scheme != "http" && uri.Scheme != "https") || uri.Host == "" {
+		c.errorf(RuleValidRequests, "%s has an invalid uri %q", description, req.Uri)
+	}
+}
+
+// checkBids calls MakeBids, and checks its results. The Bidders which panic fail the test.
+func (c *conformanceChecker) checkBids(description string, bidder adapters.Bidder, request *openrtb.BidRequest, req *adapters.RequestData, response *adapters.ResponseData, impIDs map[string]bool) (bidderResponse *adapters.BidderResponse, errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.t.Errorf("%s panicked: %v", description, r)
+		}
+	}()
+
+	bidderResponse, errs = bidder.MakeBids(request, req, response)
+	c.checkErrors(description, errs)
+	if bidderResponse == nil {
+		return
+	}
+
+	if bidderResponse.Currency == "" {
+		c.errorf(RuleCurrencySet, "%s returned a response without currency", description)
+	}
+	for i, typedBid := range bidderResponse.Bids {
+		if typedBid == nil || typedBid.Bid == nil {
+			c.errorf(RuleNoNilElements, "%s: typedBid[%d] was nil, or had a nil bid", description, i)
+			continue
+		}
+		if !impIDs[typedBid.Bid.ImpID] {
+			c.errorf(RuleImpIDsPreserved, "%s: typedBid[%d] has the impid %q, which isn't in the request", description, i, typedBid.Bid.ImpID)
+		}
+		if !validBidTypes[typedBid.BidType] {
+			c.errorf(RuleValidBidTypes, "%s: typedBid[%d] has the invalid type %q", description, i, typedBid.BidType)
+		}
+	}
+	return
+}
+
+func (c *conformanceChecker) checkErrors(description string, errs []error) {
+	for i, err := range errs {
+		if err == nil {
+			c.errorf(RuleNoNilElements, "%s: error[%d] was nil", description, i)
+			continue
+		}
+		if errortypes.ReadCode(err) == errortypes.UnknownErrorCode {
+			c.errorf(RuleTypedErrors, "%s: error[%d] isn't one of the errortypes: %v", description, i, err)
+		}
+	}
+}
+
+func countBids(bidderResponse *adapters.BidderResponse) int {
+	if bidderResponse == nil {
+		return 0
+	}
+	return len(bidderResponse.Bids)
+}
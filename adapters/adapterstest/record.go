@@ -0,0 +1,152 @@
+package adapterstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+)
+
+// StubResponse is the response which the stub server of RecordJSONBidderTest gives to every request.
+type StubResponse struct {
+	Status  int
+	Body    []byte
+	Headers http.Header
+}
+
+// RecordJSONBidderTest runs a Bidder against a local stub server, and returns the exchange as a JSON test file
+// which RunJSONBidderTest can run. The HTTP requests made by the Bidder are sent to the stub server whatever
+// their URI, and the stub server answers them all with response.
+//
+// The file expects the requests, bids and errors which the Bidder made, so it should be reviewed before it's
+// saved under adapters/{bidder}/{bidder}test.
+func RecordJSONBidderTest(bidder adapters.Bidder, request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo, response StubResponse) ([]byte, error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, values := range response.Headers {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+		w.WriteHeader(response.Status)
+		w.Write(response.Body)
+	}))
+	defer server.Close()
+
+	spec := testSpec{BidRequest: *request}
+
+	// The Bidders may change the request they're given, so they get a copy of it.
+	requestCopy := *request
+	requestCopy.Imp = append([]openrtb.Imp(nil), request.Imp...)
+	reqs, errs := bidder.MakeRequests(&requestCopy, reqInfo)
+	spec.MakeRequestErrors = recordErrors(errs)
+
+	recordBids := true
+	for i, req := range reqs {
+		if req == nil {
+			return nil, fmt.Errorf("MakeRequests returned a nil request at index %d", i)
+		}
+		if len(req.Body) > 0 && !json.Valid(req.Body) {
+			return nil, fmt.Errorf("the body of request %d isn't JSON, which the JSON test files can't hold", i)
+		}
+		responseData, err := sendToStub(server.URL, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request %d to the stub server: %v", i, err)
+		}
+		// The headers added by net/http are left out, so that the test file holds the same response.
+		responseData.Headers = response.Headers
+
+		call := httpCall{
+			Request:  httpRequest{Uri: req.Uri, Body: req.Body, Headers: req.Headers},
+			Response: httpResponse{Status: responseData.StatusCode, Headers: response.Headers},
+		}
+		if len(responseData.Body) > 0 {
+			if !json.Valid(responseData.Body) {
+				return nil, fmt.Errorf("the stub response isn't JSON, which the JSON test files can't hold")
+			}
+			call.Response.Body = responseData.Body
+		}
+		spec.HttpCalls = append(spec.HttpCalls, call)
+
+		bidderResponse, errs := bidder.MakeBids(request, req, responseData)
+		spec.MakeBidsErrors = append(spec.MakeBidsErrors, recordErrors(errs)...)
+
+		// RunJSONBidderTest matches the expected bid responses with the requests by index, so they're only
+		// recorded until a request gets no response.
+		if bidderResponse == nil {
+			recordBids = false
+		}
+		if recordBids {
+			expected, err := recordBidResponse(bidderResponse)
+			if err != nil {
+				return nil, err
+			}
+			spec.BidResponses = append(spec.BidResponses, expected)
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	enc := json.NewEncoder(buffer)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(spec); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// sendToStub sends the request to the stub server, keeping its path and query.
+func sendToStub(stubURL string, req *adapters.RequestData) (*adapters.ResponseData, error) {
+	uri := stubURL
+	if parsed, err := url.Parse(req.Uri); err == nil {
+		uri += parsed.RequestURI()
+	}
+	httpReq, err := http.NewRequest(req.Method, uri, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header = req.Headers
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &adapters.ResponseData{StatusCode: httpResp.StatusCode, Body: body, Headers: httpResp.Header}, nil
+}
+
+func recordBidResponse(bidderResponse *adapters.BidderResponse) (expectedBidResponse, error) {
+	expected := expectedBidResponse{
+		Currency: bidderResponse.Currency,
+		Bids:     make([]expectedBid, 0, len(bidderResponse.Bids)),
+	}
+	for _, typedBid := range bidderResponse.Bids {
+		if typedBid == nil || typedBid.Bid == nil {
+			return expected, fmt.Errorf("MakeBids returned a nil bid")
+		}
+		bid, err := json.Marshal(typedBid.Bid)
+		if err != nil {
+			return expected, err
+		}
+		expected.Bids = append(expected.Bids, expectedBid{Bid: bid, Type: string(typedBid.BidType)})
+	}
+	return expected, nil
+}
+
+func recordErrors(errs []error) []testSpecExpectedError {
+	var recorded []testSpecExpectedError
+	for _, err := range errs {
+		if err != nil {
+			recorded = append(recorded, testSpecExpectedError{Value: err.Error(), Comparison: "literal"})
+		}
+	}
+	return recorded
+}
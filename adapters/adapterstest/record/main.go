@@ -0,0 +1,88 @@
+// The record command writes a JSON test file for a bidder's adapter. It runs the adapter on a bid request,
+// sends the HTTP requests which the adapter makes to a local stub server, and records what the adapter did
+// with the stub's response. For example:
+//
+//	go run ./adapters/adapterstest/record -bidder appnexus -request request.json -response response.json \
+//	    -out adapters/appnexus/appnexustest/exemplary/new-test.json
+//
+// The request file holds an OpenRTB bid request, with the bidder's params in imp[i].ext.bidder. The response
+// file holds the body which the stub server returns. The host config is read like PBS reads it, so the
+// adapter uses the endpoint of pbs.yaml, or its default one.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/adapters/adapterstest"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	bidderName := flag.String("bidder", "", "The bidder whose adapter is recorded")
+	requestFile := flag.String("request", "", "The file holding the bid request given to the adapter")
+	responseFile := flag.String("response", "", "The file holding the body returned by the stub server. The stub returns no body if it's empty")
+	status := flag.Int("status", http.StatusOK, "The status returned by the stub server")
+	entryPoint := flag.String("entrypoint", "", "The PBS entry point seen by the adapter: amp or video. Defaults to the auction endpoint")
+	out := flag.String("out", "", "The file to write the JSON test to. Defaults to the standard output")
+	flag.Parse()
+
+	if err := record(*bidderName, *requestFile, *responseFile, *status, *entryPoint, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func record(bidderName string, requestFile string, responseFile string, status int, entryPoint string, out string) error {
+	if bidderName == "" || requestFile == "" {
+		return fmt.Errorf("the -bidder and -request flags are required")
+	}
+
+	v := viper.New()
+	config.SetupViper(v, "pbs")
+	cfg, err := config.New(v)
+	if err != nil {
+		return fmt.Errorf("failed to load the config: %v", err)
+	}
+	bidder := exchange.NewCoreBidder(&http.Client{}, cfg, openrtb_ext.BidderName(bidderName))
+	if bidder == nil {
+		return fmt.Errorf("%q isn't the name of a core bidder", bidderName)
+	}
+
+	requestJSON, err := ioutil.ReadFile(requestFile)
+	if err != nil {
+		return err
+	}
+	var request openrtb.BidRequest
+	if err := json.Unmarshal(requestJSON, &request); err != nil {
+		return fmt.Errorf("failed to parse the bid request: %v", err)
+	}
+
+	response := adapterstest.StubResponse{Status: status}
+	if responseFile != "" {
+		if response.Body, err = ioutil.ReadFile(responseFile); err != nil {
+			return err
+		}
+		response.Headers = http.Header{"Content-Type": []string{"application/json"}}
+	}
+
+	testJSON, err := adapterstest.RecordJSONBidderTest(bidder, &request, &adapters.ExtraRequestInfo{PbsEntryPoint: pbsmetrics.RequestType(entryPoint)}, response)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(testJSON)
+		return err
+	}
+	return ioutil.WriteFile(out, testJSON, 0644)
+}
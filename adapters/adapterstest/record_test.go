@@ -0,0 +1,90 @@
+package adapterstest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordJSONBidderTest(t *testing.T) {
+	request := &openrtb.BidRequest{
+		ID:  "req1",
+		Imp: []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}, {ID: "imp2"}},
+	}
+	response := StubResponse{
+		Status:  http.StatusOK,
+		Body:    []byte(`{"seatbid":[{"bid":[{"id":"bid1","impid":"imp1","price":1.5,"adm":"<div>&</div>"}]}]}`),
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	testJSON, err := RecordJSONBidderTest(&recordedBidder{}, request, &adapters.ExtraRequestInfo{}, response)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var spec testSpec
+	if assert.NoError(t, json.Unmarshal(testJSON, &spec)) {
+		if assert.Len(t, spec.HttpCalls, 1) {
+			assert.Equal(t, "http://bidder.com/openrtb?imps=2", spec.HttpCalls[0].Request.Uri)
+			assert.Equal(t, http.StatusOK, spec.HttpCalls[0].Response.Status)
+			assert.JSONEq(t, string(response.Body), string(spec.HttpCalls[0].Response.Body))
+		}
+		if assert.Len(t, spec.BidResponses, 1) && assert.Len(t, spec.BidResponses[0].Bids, 1) {
+			assert.Equal(t, "banner", spec.BidResponses[0].Bids[0].Type)
+		}
+		assert.Equal(t, []testSpecExpectedError{{Value: "imp imp2 has no banner", Comparison: "literal"}}, spec.MakeRequestErrors)
+	}
+	assert.Contains(t, string(testJSON), "<div>&</div>", "HTML isn't escaped")
+
+	// The recorded file passes the JSON tests
+	dir, err := ioutil.TempDir("", "recordtest")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "supplemental"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "supplemental", "recorded.json"), testJSON, 0644))
+	RunJSONBidderTest(t, dir, &recordedBidder{})
+}
+
+// recordedBidder bids on the banner imps of the request, and makes an error for the others.
+type recordedBidder struct{}
+
+func (b *recordedBidder) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	var errs []error
+	for _, imp := range request.Imp {
+		if imp.Banner == nil {
+			errs = append(errs, &errortypes.BadInput{Message: "imp " + imp.ID + " has no banner"})
+		}
+	}
+	body, _ := json.Marshal(request)
+	return []*adapters.RequestData{{
+		Method:  "POST",
+		Uri:     "http://bidder.com/openrtb?imps=2",
+		Body:    body,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+	}}, errs
+}
+
+func (b *recordedBidder) MakeBids(internalRequest *openrtb.BidRequest, externalRequest *adapters.RequestData, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	var bidResponse openrtb.BidResponse
+	if err := json.Unmarshal(response.Body, &bidResponse); err != nil {
+		return nil, []error{&errortypes.BadServerResponse{Message: err.Error()}}
+	}
+	bidderResponse := adapters.NewBidderResponse()
+	for _, seatBid := range bidResponse.SeatBid {
+		for i := range seatBid.Bid {
+			bidderResponse.Bids = append(bidderResponse.Bids, &adapters.TypedBid{Bid: &seatBid.Bid[i], BidType: openrtb_ext.BidTypeBanner})
+		}
+	}
+	return bidderResponse, nil
+}
@@ -131,8 +131,8 @@ type testSpec struct {
 	BidRequest        openrtb.BidRequest      `json:"mockBidRequest"`
 	HttpCalls         []httpCall              `json:"httpCalls"`
 	BidResponses      []expectedBidResponse   `json:"expectedBidResponses"`
-	MakeRequestErrors []testSpecExpectedError `json:"expectedMakeRequestsErrors"`
-	MakeBidsErrors    []testSpecExpectedError `json:"expectedMakeBidsErrors"`
+	MakeRequestErrors []testSpecExpectedError `json:"expectedMakeRequestsErrors,omitempty"`
+	MakeBidsErrors    []testSpecExpectedError `json:"expectedMakeBidsErrors,omitempty"`
 }
 
 type testSpecExpectedError struct {
@@ -160,13 +160,13 @@ func (req *httpRequest) ToRequestData(t *testing.T) *adapters.RequestData {
 type httpRequest struct {
 	Body    json.RawMessage `json:"body"`
 	Uri     string          `json:"uri"`
-	Headers http.Header     `json:"headers"`
+	Headers http.Header     `json:"headers,omitempty"`
 }
 
 type httpResponse struct {
 	Status  int             `json:"status"`
 	Body    json.RawMessage `json:"body"`
-	Headers http.Header     `json:"headers"`
+	Headers http.Header     `json:"headers,omitempty"`
 }
 
 func (resp *httpResponse) ToResponseData(t *testing.T) *adapters.ResponseData {
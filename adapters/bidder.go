@@ -52,6 +52,16 @@ type TimeoutBidder interface {
 	MakeTimeoutNotification(req *RequestData) (*RequestData, []error)
 }
 
+// NotifyingBidder is used to identify bidders which build their own win, loss and billing notifications.
+// The bidders which don't implement it are notified through the nurl, lurl and burl of their bids.
+type NotifyingBidder interface {
+	Bidder
+
+	// MakeNotification builds the request which tells the bidder about the outcome of one of its bids.
+	// A nil request means that the bidder doesn't want this notice.
+	MakeNotification(notice *BidNotice) (*RequestData, []error)
+}
+
 type MisconfiguredBidder struct {
 	Name  string
 	Error error
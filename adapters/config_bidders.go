@@ -0,0 +1,189 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/usersync"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// OpenRTBInfo declares a bidder which takes plain OpenRTB requests, so that it can run without an adapter
+// of its own. It's read from the openrtb section of static/bidder-info/{bidder}.yaml.
+type OpenRTBInfo struct {
+	// Endpoint is a template which may use the macros of macros.EndpointTemplateParams. They're filled in
+	// from the bidder params. The host can override it with adapters.{bidder}.endpoint.
+	Endpoint string `yaml:"endpoint"`
+	// ParamsSchema is the JSON schema of the bidder params. Bidders without one accept any params.
+	ParamsSchema string        `yaml:"paramsSchema"`
+	GVLVendorID  uint16        `yaml:"gvlVendorID"`
+	ImpSplitting ImpSplitting  `yaml:"impSplitting"`
+	BidType      BidTypeRule   `yaml:"bidType"`
+	Usersync     *UsersyncInfo `yaml:"usersync"`
+}
+
+// ImpSplitting tells how the imps of a request are sent to a config-only bidder.
+type ImpSplitting string
+
+const (
+	// ImpSplittingNone sends all the imps in a single request. It's the default.
+	ImpSplittingNone ImpSplitting = "none"
+	// ImpSplittingPerImp sends one request for each imp.
+	ImpSplittingPerImp ImpSplitting = "per_imp"
+)
+
+// BidTypeRule tells how the media type of the bids from a config-only bidder is found.
+type BidTypeRule string
+
+const (
+	// BidTypeFromImp uses the media type of the imp the bid is for. It's the default.
+	// Imps with several media types resolve to banner, then video, then native, then audio.
+	BidTypeFromImp BidTypeRule = "imp"
+	// BidTypeFromExt uses bid.ext.prebid.type, which the bidder has to set.
+	BidTypeFromExt BidTypeRule = "ext"
+)
+
+// UsersyncInfo describes the user sync of a config-only bidder.
+type UsersyncInfo struct {
+	// URL is a template which may use the macros of macros.UserSyncTemplateParams, along with
+	// {{.RedirectURL}} for the escaped /setuid URL of this host. The host can override it with
+	// adapters.{bidder}.usersync_url.
+	URL  string   `yaml:"url"`
+	Type SyncType `yaml:"type"`
+	// CookieFamily defaults to the bidder name.
+	CookieFamily string `yaml:"cookieFamily"`
+	// UIDMacro is the macro which the bidder replaces with the user ID in the redirect. It defaults to $UID.
+	UIDMacro string `yaml:"uidMacro"`
+}
+
+// RegisterConfigBidders registers the bidders declared in the static/bidder-info/{bidder}.yaml files which
+// don't match a BidderName. These files must have an openrtb section. It must be called at startup, before
+// the BidderMap is read.
+func RegisterConfigBidders(infoDir string) ([]openrtb_ext.BidderName, error) {
+	fileInfos, err := ioutil.ReadDir(infoDir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the bidder info directory %s: %v", infoDir, err)
+	}
+
+	var bidders []openrtb_ext.BidderName
+	for _, fileInfo := range fileInfos {
+		bidderName := strings.TrimSuffix(fileInfo.Name(), ".yaml")
+		if _, exists := openrtb_ext.BidderMap[bidderName]; exists || bidderName == fileInfo.Name() {
+			continue
+		}
+
+		fileData, err := ioutil.ReadFile(filepath.Join(infoDir, fileInfo.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s/%s: %v", infoDir, fileInfo.Name(), err)
+		}
+		var info BidderInfo
+		if err := yaml.Unmarshal(fileData, &info); err != nil {
+			return nil, fmt.Errorf("Failed to parse %s/%s: %v", infoDir, fileInfo.Name(), err)
+		}
+		if info.OpenRTB == nil {
+			return nil, fmt.Errorf("%s/%s does not match a valid BidderName, and has no openrtb section", infoDir, fileInfo.Name())
+		}
+		if err := validateConfigBidder(&info); err != nil {
+			return nil, fmt.Errorf("Invalid bidder %s in %s/%s: %v", bidderName, infoDir, fileInfo.Name(), err)
+		}
+
+		bidder, err := openrtb_ext.RegisterBidder(bidderName, info.OpenRTB.ParamsSchema)
+		if err != nil {
+			return nil, err
+		}
+		bidders = append(bidders, bidder)
+	}
+	return bidders, nil
+}
+
+// RegisterHostAliases registers the adapters which the host configured with an alias_of as bidders of their own.
+// Like RegisterConfigBidders, it must be called at startup, after the config-only bidders are registered.
+func RegisterHostAliases(cfg map[string]config.Adapter) ([]openrtb_ext.BidderName, error) {
+	aliasNames := make([]string, 0)
+	for adapterName, adapter := range cfg {
+		if adapter.AliasOf != "" {
+			aliasNames = append(aliasNames, adapterName)
+		}
+	}
+	sort.Strings(aliasNames)
+
+	aliases := make([]openrtb_ext.BidderName, 0, len(aliasNames))
+	for _, aliasName := range aliasNames {
+		alias, err := openrtb_ext.RegisterAlias(aliasName, openrtb_ext.BidderName(cfg[aliasName].AliasOf))
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+func validateConfigBidder(info *BidderInfo) error {
+	if info.Capabilities == nil || (info.Capabilities.App == nil && info.Capabilities.Site == nil) {
+		return fmt.Errorf("capabilities must list the media types supported for app or site")
+	}
+
+	openRTB := info.OpenRTB
+	if openRTB.Endpoint == "" {
+		return fmt.Errorf("openrtb.endpoint is required")
+	}
+	if _, err := template.New("endpointTemplate").Parse(openRTB.Endpoint); err != nil {
+		return fmt.Errorf("openrtb.endpoint is not a valid template: %v", err)
+	}
+	if openRTB.ParamsSchema != "" && !json.Valid([]byte(openRTB.ParamsSchema)) {
+		return fmt.Errorf("openrtb.paramsSchema is not valid JSON")
+	}
+	switch openRTB.ImpSplitting {
+	case "", ImpSplittingNone, ImpSplittingPerImp:
+	default:
+		return fmt.Errorf("openrtb.impSplitting must be %q or %q. Got %q", ImpSplittingNone, ImpSplittingPerImp, openRTB.ImpSplitting)
+	}
+	switch openRTB.BidType {
+	case "", BidTypeFromImp, BidTypeFromExt:
+	default:
+		return fmt.Errorf("openrtb.bidType must be %q or %q. Got %q", BidTypeFromImp, BidTypeFromExt, openRTB.BidType)
+	}
+
+	if usersync := openRTB.Usersync; usersync != nil {
+		if usersync.URL == "" {
+			return fmt.Errorf("openrtb.usersync.url is required")
+		}
+		if _, err := template.New("usersyncTemplate").Parse(usersync.URL); err != nil {
+			return fmt.Errorf("openrtb.usersync.url is not a valid template: %v", err)
+		}
+		if usersync.Type != SyncTypeRedirect && usersync.Type != SyncTypeIframe {
+			return fmt.Errorf("openrtb.usersync.type must be %q or %q. Got %q", SyncTypeRedirect, SyncTypeIframe, usersync.Type)
+		}
+	}
+	return nil
+}
+
+// NewConfigBidderSyncer makes the user syncer of a config-only bidder, which must have a usersync section.
+// The host's adapters.{bidder}.usersync_url replaces the URL of the bidder info when it's set.
+func NewConfigBidderSyncer(bidder openrtb_ext.BidderName, openRTB *OpenRTBInfo, externalURL string, hostURL string) usersync.Usersyncer {
+	info := openRTB.Usersync
+	familyName := info.CookieFamily
+	if familyName == "" {
+		familyName = string(bidder)
+	}
+	urlText := hostURL
+	if urlText == "" {
+		uidMacro := info.UIDMacro
+		if uidMacro == "" {
+			uidMacro = "$UID"
+		}
+		redirectURL := url.QueryEscape(externalURL) + "%2Fsetuid%3Fbidder%3D" + url.QueryEscape(familyName) +
+			"%26gdpr%3D{{.GDPR}}%26gdpr_consent%3D{{.GDPRConsent}}%26uid%3D" + url.QueryEscape(uidMacro)
+		urlText = strings.Replace(info.URL, "{{.RedirectURL}}", redirectURL, -1)
+	}
+	urlTemplate := template.Must(template.New(strings.ToLower(string(bidder)) + "_usersync_url").Parse(urlText))
+	return NewSyncer(familyName, openRTB.GVLVendorID, urlTemplate, info.Type)
+}
@@ -0,0 +1,189 @@
+package adapters
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/privacy"
+	"github.com/prebid/prebid-server/privacy/gdpr"
+	"github.com/stretchr/testify/assert"
+)
+
+const configBidderYAML = `
+maintainer:
+  email: "partner@example.com"
+capabilities:
+  site:
+    mediaTypes:
+      - banner
+openrtb:
+  endpoint: "https://{{.Host}}/openrtb"
+  gvlVendorID: 1234
+  impSplitting: per_imp
+  bidType: ext
+  usersync:
+    url: "https://sync.example.com/?gdpr={{.GDPR}}&redir={{.RedirectURL}}"
+    type: redirect
+`
+
+func writeBidderInfos(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "bidder-info")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestRegisterConfigBidders(t *testing.T) {
+	dir := writeBidderInfos(t, map[string]string{
+		"appnexus.yaml":      "maintainer:\n  email: \"info@prebid.org\"\n",
+		"configpartner.yaml": configBidderYAML,
+		"README.md":          "Not a bidder",
+	})
+	defer os.RemoveAll(dir)
+	defer delete(openrtb_ext.BidderMap, "configpartner")
+
+	bidders, err := RegisterConfigBidders(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []openrtb_ext.BidderName{"configpartner"}, bidders)
+	assert.Contains(t, openrtb_ext.BidderMap, "configpartner")
+
+	infos := ParseBidderInfos(map[string]config.Adapter{}, dir, bidders)
+	assert.True(t, infos.IsActive("configpartner"), "Config-only bidders shouldn't need any host config")
+	if assert.NotNil(t, infos["configpartner"].OpenRTB) {
+		assert.Equal(t, "https://{{.Host}}/openrtb", infos["configpartner"].OpenRTB.Endpoint)
+		assert.Equal(t, ImpSplittingPerImp, infos["configpartner"].OpenRTB.ImpSplitting)
+		assert.Equal(t, BidTypeFromExt, infos["configpartner"].OpenRTB.BidType)
+	}
+
+	infos = ParseBidderInfos(map[string]config.Adapter{"configpartner": {Disabled: true}}, dir, bidders)
+	assert.False(t, infos.IsActive("configpartner"), "The host should be able to disable config-only bidders")
+}
+
+func TestRegisterConfigBiddersErrors(t *testing.T) {
+	capabilities := "capabilities:\n  site:\n    mediaTypes:\n      - banner\n"
+	testCases := []struct {
+		description string
+		yaml        string
+		expected    string
+	}{
+		{
+			description: "No openrtb section",
+			yaml:        capabilities,
+			expected:    "does not match a valid BidderName, and has no openrtb section",
+		},
+		{
+			description: "No capabilities",
+			yaml:        "openrtb:\n  endpoint: \"https://example.com\"\n",
+			expected:    "capabilities must list the media types supported for app or site",
+		},
+		{
+			description: "No endpoint",
+			yaml:        capabilities + "openrtb:\n  gvlVendorID: 12\n",
+			expected:    "openrtb.endpoint is required",
+		},
+		{
+			description: "Bad endpoint template",
+			yaml:        capabilities + "openrtb:\n  endpoint: \"https://{{.Host\"\n",
+			expected:    "openrtb.endpoint is not a valid template",
+		},
+		{
+			description: "Bad params schema",
+			yaml:        capabilities + "openrtb:\n  endpoint: \"https://example.com\"\n  paramsSchema: \"{\"\n",
+			expected:    "openrtb.paramsSchema is not valid JSON",
+		},
+		{
+			description: "Bad imp splitting",
+			yaml:        capabilities + "openrtb:\n  endpoint: \"https://example.com\"\n  impSplitting: per_format\n",
+			expected:    `openrtb.impSplitting must be "none" or "per_imp". Got "per_format"`,
+		},
+		{
+			description: "Bad bid type rule",
+			yaml:        capabilities + "openrtb:\n  endpoint: \"https://example.com\"\n  bidType: adm\n",
+			expected:    `openrtb.bidType must be "imp" or "ext". Got "adm"`,
+		},
+		{
+			description: "Bad usersync type",
+			yaml:        capabilities + "openrtb:\n  endpoint: \"https://example.com\"\n  usersync:\n    url: \"https://sync.example.com\"\n    type: pixel\n",
+			expected:    `openrtb.usersync.type must be "redirect" or "iframe". Got "pixel"`,
+		},
+	}
+
+	for _, test := range testCases {
+		dir := writeBidderInfos(t, map[string]string{"badpartner.yaml": test.yaml})
+		_, err := RegisterConfigBidders(dir)
+		if assert.Error(t, err, test.description) {
+			assert.Contains(t, err.Error(), test.expected, test.description)
+		}
+		assert.NotContains(t, openrtb_ext.BidderMap, "badpartner", test.description)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestNewConfigBidderSyncer(t *testing.T) {
+	openRTB := &OpenRTBInfo{
+		GVLVendorID: 1234,
+		Usersync: &UsersyncInfo{
+			URL:  "https://sync.example.com/?gdpr={{.GDPR}}&redir={{.RedirectURL}}",
+			Type: SyncTypeIframe,
+		},
+	}
+	policies := privacy.Policies{GDPR: gdpr.Policy{Signal: "1", Consent: "consent"}}
+
+	syncer := NewConfigBidderSyncer("configpartner", openRTB, "http://pbs.example.com", "")
+	syncInfo, err := syncer.GetUsersyncInfo(policies)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://sync.example.com/?gdpr=1&redir=http%3A%2F%2Fpbs.example.com%2Fsetuid%3Fbidder%3Dconfigpartner%26gdpr%3D1%26gdpr_consent%3Dconsent%26uid%3D%24UID", syncInfo.URL)
+	assert.Equal(t, "iframe", syncInfo.Type)
+	assert.Equal(t, "configpartner", syncer.FamilyName())
+	assert.Equal(t, uint16(1234), syncer.GDPRVendorID())
+
+	openRTB.Usersync.CookieFamily = "partner"
+	openRTB.Usersync.UIDMacro = "[UID]"
+	syncer = NewConfigBidderSyncer("configpartner", openRTB, "http://pbs.example.com", "")
+	syncInfo, err = syncer.GetUsersyncInfo(policies)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://sync.example.com/?gdpr=1&redir=http%3A%2F%2Fpbs.example.com%2Fsetuid%3Fbidder%3Dpartner%26gdpr%3D1%26gdpr_consent%3Dconsent%26uid%3D%5BUID%5D", syncInfo.URL)
+	assert.Equal(t, "partner", syncer.FamilyName())
+
+	syncer = NewConfigBidderSyncer("configpartner", openRTB, "http://pbs.example.com", "https://host.example.com/sync?gdpr={{.GDPR}}")
+	syncInfo, err = syncer.GetUsersyncInfo(policies)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://host.example.com/sync?gdpr=1", syncInfo.URL, "The host's usersync_url should replace the bidder's")
+}
+
+func TestRegisterHostAliases(t *testing.T) {
+	dir := writeBidderInfos(t, map[string]string{
+		"appnexus.yaml": "maintainer:\n  email: \"info@prebid.org\"\ncapabilities:\n  site:\n    mediaTypes:\n      - banner\n",
+	})
+	defer os.RemoveAll(dir)
+	defer delete(openrtb_ext.BidderMap, "whitelabel")
+	defer delete(openrtb_ext.BidderMap, "disabledlabel")
+
+	cfg := map[string]config.Adapter{
+		"appnexus":      {},
+		"whitelabel":    {AliasOf: "appnexus"},
+		"disabledlabel": {AliasOf: "appnexus", Disabled: true},
+	}
+	aliases, err := RegisterHostAliases(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []openrtb_ext.BidderName{"disabledlabel", "whitelabel"}, aliases)
+
+	infos := ParseBidderInfos(cfg, dir, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, "whitelabel", "disabledlabel"})
+	assert.Equal(t, "appnexus", infos["whitelabel"].AliasOf)
+	assert.Equal(t, infos["appnexus"].Capabilities, infos["whitelabel"].Capabilities, "Aliases should share the info of their core bidder")
+	assert.True(t, infos.IsActive("whitelabel"))
+	assert.False(t, infos.IsActive("disabledlabel"))
+
+	_, err = RegisterHostAliases(map[string]config.Adapter{"badlabel": {AliasOf: "unknown"}})
+	assert.EqualError(t, err, "The alias badlabel refers to an unknown bidder unknown")
+}
@@ -0,0 +1,182 @@
+package generic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/golang/glog"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/macros"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// ConfigBidder runs a bidder declared in static/bidder-info. It forwards the OpenRTB request as it is,
+// resolving the endpoint macros from the bidder params.
+type ConfigBidder struct {
+	endpointTemplate template.Template
+	impSplitting     adapters.ImpSplitting
+	bidType          adapters.BidTypeRule
+}
+
+// NewConfigBidder creates a bidder from the openrtb section of its bidder info. The endpoint is either the one
+// from the bidder info, or the host's override.
+func NewConfigBidder(endpoint string, info *adapters.OpenRTBInfo) *ConfigBidder {
+	template, err := template.New("endpointTemplate").Parse(endpoint)
+	if err != nil {
+		glog.Fatalf("Unable to parse endpoint url template %s: %v", endpoint, err)
+		return nil
+	}
+
+	return &ConfigBidder{
+		endpointTemplate: *template,
+		impSplitting:     info.ImpSplitting,
+		bidType:          info.BidType,
+	}
+}
+
+// MakeRequests sends the whole request, or one request for each imp if the bidder splits them
+func (a *ConfigBidder) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	if len(request.Imp) == 0 {
+		return nil, []error{&errortypes.BadInput{Message: "No imps present in request"}}
+	}
+
+	if a.impSplitting != adapters.ImpSplittingPerImp {
+		requestData, err := a.makeRequest(request, &request.Imp[0])
+		if err != nil {
+			return nil, []error{err}
+		}
+		return []*adapters.RequestData{requestData}, nil
+	}
+
+	var errs []error
+	requests := make([]*adapters.RequestData, 0, len(request.Imp))
+	imps := request.Imp
+	for i := range imps {
+		request.Imp = imps[i : i+1]
+		requestData, err := a.makeRequest(request, &imps[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		requests = append(requests, requestData)
+	}
+	request.Imp = imps
+	return requests, errs
+}
+
+func (a *ConfigBidder) makeRequest(request *openrtb.BidRequest, paramsImp *openrtb.Imp) (*adapters.RequestData, error) {
+	var bidderExt adapters.ExtImpBidder
+	if err := json.Unmarshal(paramsImp.Ext, &bidderExt); err != nil {
+		return nil, &errortypes.BadInput{
+			Message: fmt.Sprintf("Missing bidder ext in imp %s: %s", paramsImp.ID, err.Error()),
+		}
+	}
+	var endpointParams macros.EndpointTemplateParams
+	if err := json.Unmarshal(bidderExt.Bidder, &endpointParams); err != nil {
+		return nil, &errortypes.BadInput{
+			Message: fmt.Sprintf("Bad bidder params in imp %s: %s", paramsImp.ID, err.Error()),
+		}
+	}
+	url, err := macros.ResolveMacros(a.endpointTemplate, endpointParams)
+	if err != nil {
+		return nil, &errortypes.BadInput{
+			Message: fmt.Sprintf("Unable to construct the URL from the params of imp %s: %s", paramsImp.ID, err.Error()),
+		}
+	}
+
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	headers.Add("Content-Type", "application/json;charset=utf-8")
+	headers.Add("Accept", "application/json")
+	return &adapters.RequestData{
+		Method:  "POST",
+		Uri:     url,
+		Body:    requestJSON,
+		Headers: headers,
+	}, nil
+}
+
+// MakeBids reads the bids, finding their media type according to the bidder's bid type rule
+func (a *ConfigBidder) MakeBids(internalRequest *openrtb.BidRequest, externalRequest *adapters.RequestData, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	if response.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	if response.StatusCode == http.StatusBadRequest {
+		return nil, []error{&errortypes.BadInput{
+			Message: fmt.Sprintf("Unexpected status code: %d. Run with request.debug = 1 for more info", response.StatusCode),
+		}}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, []error{&errortypes.BadServerResponse{
+			Message: fmt.Sprintf("Unexpected status code: %d. Run with request.debug = 1 for more info", response.StatusCode),
+		}}
+	}
+
+	var bidResp openrtb.BidResponse
+	if err := json.Unmarshal(response.Body, &bidResp); err != nil {
+		return nil, []error{&errortypes.BadServerResponse{
+			Message: fmt.Sprintf("Bad server response: %s", err.Error()),
+		}}
+	}
+
+	var errs []error
+	bidResponse := adapters.NewBidderResponseWithBidsCapacity(len(internalRequest.Imp))
+	if bidResp.Cur != "" {
+		bidResponse.Currency = bidResp.Cur
+	}
+	for _, seatBid := range bidResp.SeatBid {
+		for i := range seatBid.Bid {
+			bidType, err := a.bidTypeOf(&seatBid.Bid[i], internalRequest.Imp)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			bidResponse.Bids = append(bidResponse.Bids, &adapters.TypedBid{
+				Bid:     &seatBid.Bid[i],
+				BidType: bidType,
+			})
+		}
+	}
+	return bidResponse, errs
+}
+
+func (a *ConfigBidder) bidTypeOf(bid *openrtb.Bid, imps []openrtb.Imp) (openrtb_ext.BidType, error) {
+	if a.bidType == adapters.BidTypeFromExt {
+		var bidExt openrtb_ext.ExtBid
+		if err := json.Unmarshal(bid.Ext, &bidExt); err != nil || bidExt.Prebid == nil || bidExt.Prebid.Type == "" {
+			return "", &errortypes.BadServerResponse{
+				Message: fmt.Sprintf("Bid %s has no media type in bid.ext.prebid.type", bid.ID),
+			}
+		}
+		return bidExt.Prebid.Type, nil
+	}
+
+	for _, imp := range imps {
+		if imp.ID != bid.ImpID {
+			continue
+		}
+		switch {
+		case imp.Banner != nil:
+			return openrtb_ext.BidTypeBanner, nil
+		case imp.Video != nil:
+			return openrtb_ext.BidTypeVideo, nil
+		case imp.Native != nil:
+			return openrtb_ext.BidTypeNative, nil
+		case imp.Audio != nil:
+			return openrtb_ext.BidTypeAudio, nil
+		}
+	}
+	return "", &errortypes.BadServerResponse{
+		Message: fmt.Sprintf("Bid %s is for an unknown imp %s", bid.ID, bid.ImpID),
+	}
+}
@@ -0,0 +1,61 @@
+package generic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/adapters/adapterstest"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigBidderJsonSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "configtest", NewConfigBidder("http://{{.Host}}/bid?pub={{.PublisherID}}", &adapters.OpenRTBInfo{
+		ImpSplitting: adapters.ImpSplittingPerImp,
+	}))
+}
+
+func TestConfigBidderSingleRequest(t *testing.T) {
+	bidder := NewConfigBidder("http://{{.Host}}/bid", &adapters.OpenRTBInfo{})
+	request := &openrtb.BidRequest{
+		ID: "request-id",
+		Imp: []openrtb.Imp{
+			{ID: "imp-1", Banner: &openrtb.Banner{}, Ext: json.RawMessage(`{"bidder":{"host":"east.example.com"}}`)},
+			{ID: "imp-2", Banner: &openrtb.Banner{}, Ext: json.RawMessage(`{"bidder":{"host":"west.example.com"}}`)},
+		},
+	}
+
+	requests, errs := bidder.MakeRequests(request, &adapters.ExtraRequestInfo{})
+	assert.Empty(t, errs)
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "http://east.example.com/bid", requests[0].Uri, "The endpoint should come from the params of the first imp")
+		var sent openrtb.BidRequest
+		assert.NoError(t, json.Unmarshal(requests[0].Body, &sent))
+		assert.Len(t, sent.Imp, 2)
+	}
+}
+
+func TestConfigBidderBidTypeFromExt(t *testing.T) {
+	bidder := NewConfigBidder("http://example.com/bid", &adapters.OpenRTBInfo{BidType: adapters.BidTypeFromExt})
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "imp-1", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}}},
+	}
+	response := &adapters.ResponseData{
+		StatusCode: 200,
+		Body: []byte(`{"seatbid":[{"bid":[
+			{"id":"typed","impid":"imp-1","price":1,"ext":{"prebid":{"type":"video"}}},
+			{"id":"untyped","impid":"imp-1","price":1}
+		]}]}`),
+	}
+
+	bidResponse, errs := bidder.MakeBids(request, &adapters.RequestData{}, response)
+	if assert.Len(t, bidResponse.Bids, 1) {
+		assert.Equal(t, "typed", bidResponse.Bids[0].Bid.ID)
+		assert.Equal(t, openrtb_ext.BidTypeVideo, bidResponse.Bids[0].BidType)
+	}
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "Bid untyped has no media type in bid.ext.prebid.type")
+	}
+}
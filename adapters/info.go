@@ -140,10 +140,16 @@ type BidderInfos map[string]BidderInfo
 // ParseBidderInfos reads all the static/bidder-info/{bidder}.yaml files from the filesystem.
 // The map it returns will have a key for every element of the bidders array.
 // If a {bidder}.yaml file does not exist for some bidder, it will panic.
+// The bidders which the host configured as aliases share the info of their core bidder.
 func ParseBidderInfos(cfg map[string]config.Adapter, infoDir string, bidders []openrtb_ext.BidderName) BidderInfos {
 	bidderInfos := make(map[string]BidderInfo, len(bidders))
+	var aliases []string
 	for _, bidderName := range bidders {
 		bidderString := string(bidderName)
+		if cfg[strings.ToLower(bidderString)].AliasOf != "" {
+			aliases = append(aliases, bidderString)
+			continue
+		}
 		fileData, err := ioutil.ReadFile(infoDir + "/" + bidderString + ".yaml")
 		if err != nil {
 			glog.Fatalf("error reading from file %s: %v", infoDir+"/"+bidderString+".yaml", err)
@@ -153,8 +159,17 @@ func ParseBidderInfos(cfg map[string]config.Adapter, infoDir string, bidders []o
 		if err := yaml.Unmarshal(fileData, &parsedInfo); err != nil {
 			glog.Fatalf("error parsing yaml in file %s: %v", infoDir+"/"+bidderString+".yaml", err)
 		}
+		if parsedInfo.EndpointCompression != "" && parsedInfo.EndpointCompression != CompressionGZIP {
+			glog.Fatalf("unsupported endpointCompression %q in file %s. Only %q is supported", parsedInfo.EndpointCompression, infoDir+"/"+bidderString+".yaml", CompressionGZIP)
+		}
+		if parsedInfo.OpenRTBVersion != "" && parsedInfo.OpenRTBVersion != OpenRTBVersion25 && parsedInfo.OpenRTBVersion != OpenRTBVersion26 {
+			glog.Fatalf("unsupported openrtbVersion %q in file %s. It must be %q or %q", parsedInfo.OpenRTBVersion, infoDir+"/"+bidderString+".yaml", OpenRTBVersion25, OpenRTBVersion26)
+		}
+		if parsedInfo.NativeVersion != "" && parsedInfo.NativeVersion != openrtb_ext.NativeVersion11 && parsedInfo.NativeVersion != openrtb_ext.NativeVersion12 {
+			glog.Fatalf("unsupported nativeVersion %q in file %s. It must be %q or %q", parsedInfo.NativeVersion, infoDir+"/"+bidderString+".yaml", openrtb_ext.NativeVersion11, openrtb_ext.NativeVersion12)
+		}
 
-		if isEnabledBidder(cfg, bidderString) {
+		if isEnabledBidder(cfg, bidderString, parsedInfo.OpenRTB != nil) {
 			parsedInfo.Status = StatusActive
 		} else {
 			parsedInfo.Status = StatusDisabled
@@ -162,6 +177,21 @@ func ParseBidderInfos(cfg map[string]config.Adapter, infoDir string, bidders []o
 
 		bidderInfos[bidderString] = parsedInfo
 	}
+
+	for _, alias := range aliases {
+		aliasCfg := cfg[strings.ToLower(alias)]
+		aliasInfo, ok := bidderInfos[aliasCfg.AliasOf]
+		if !ok {
+			glog.Fatalf("The alias %s refers to %s, which has no bidder info", alias, aliasCfg.AliasOf)
+		}
+		aliasInfo.AliasOf = aliasCfg.AliasOf
+		if aliasCfg.Disabled {
+			aliasInfo.Status = StatusDisabled
+		} else {
+			aliasInfo.Status = StatusActive
+		}
+		bidderInfos[alias] = aliasInfo
+	}
 	return bidderInfos
 }
 
@@ -185,10 +215,11 @@ func (infos BidderInfos) SupportsWebMediaType(bidder openrtb_ext.BidderName, med
 	return containsMediaType(infos[string(bidder)].Capabilities.Site.MediaTypes, mediaType)
 }
 
-// isEnabledBidder Checks that a bidder config exists and is not disabled
-func isEnabledBidder(cfg map[string]config.Adapter, bidder string) bool {
+// isEnabledBidder Checks that a bidder config exists and is not disabled.
+// Config-only bidders carry their own endpoint, so they're enabled unless the host disables them.
+func isEnabledBidder(cfg map[string]config.Adapter, bidder string, configOnly bool) bool {
 	a, ok := cfg[strings.ToLower(bidder)]
-	return ok && !a.Disabled
+	return (ok || configOnly) && !a.Disabled
 }
 
 // BidderStatus represents a bidder status in PBS, can be either active or disabled
@@ -205,8 +236,29 @@ type BidderInfo struct {
 	Maintainer   *MaintainerInfo   `yaml:"maintainer" json:"maintainer"`
 	Capabilities *CapabilitiesInfo `yaml:"capabilities" json:"capabilities"`
 	AliasOf      string            `json:"aliasOf,omitempty"`
+	// OpenRTB is only set for the bidders which are declared in static/bidder-info rather than in code.
+	OpenRTB *OpenRTBInfo `yaml:"openrtb" json:"-"`
+	// EndpointCompression is the content encoding of the request bodies sent to the bidder. The bodies
+	// are sent uncompressed unless it's set.
+	EndpointCompression string `yaml:"endpointCompression" json:"-"`
+	// OpenRTBVersion is the OpenRTB version which the bidder's endpoint speaks. The adapters of the bidders which
+	// speak 2.6 get the request with the 2.6 fields outside of the ext in ExtraRequestInfo.OpenRTB26Request, and
+	// may parse the responses into an openrtb_ext.BidResponse26. Defaults to 2.5.
+	OpenRTBVersion string `yaml:"openrtbVersion" json:"-"`
+	// NativeVersion is the version of the Native specification which the bidder's endpoint speaks. The bidders
+	// which speak 1.1 get their imp.native.request wrapped in a "native" object. Defaults to 1.2.
+	NativeVersion string `yaml:"nativeVersion" json:"-"`
 }
 
+// CompressionGZIP is the only EndpointCompression which the bidders may ask for.
+const CompressionGZIP = "gzip"
+
+// The OpenRTB versions which the bidders may declare
+const (
+	OpenRTBVersion25 = "2.5"
+	OpenRTBVersion26 = "2.6"
+)
+
 type MaintainerInfo struct {
 	Email string `yaml:"email" json:"email"`
 }
@@ -258,4 +310,7 @@ func parseBidderInfo(info BidderInfo) parsedBidderInfo {
 
 type ExtraRequestInfo struct {
 	PbsEntryPoint pbsmetrics.RequestType
+	// OpenRTB26Request is the request with the OpenRTB 2.6 fields outside of the ext. It's only set for the bidders
+	// which declare openrtbVersion: 2.6, whose adapters should send it instead of the 2.5 request.
+	OpenRTB26Request *openrtb_ext.BidRequest26
 }
@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// NoticeType tells which outcome of a bid a BidNotice reports.
+type NoticeType string
+
+const (
+	NoticeWin     NoticeType = "win"
+	NoticeLoss    NoticeType = "loss"
+	NoticeBilling NoticeType = "billing"
+)
+
+// BidNotice describes the outcome of a bid, for the bidder which made it.
+type BidNotice struct {
+	Type NoticeType
+	// Bid is a copy of the bid, as the bidder returned it
+	Bid *openrtb.Bid
+	// AuctionID is the ID of the bid request
+	AuctionID string
+	// Seat is the name the bidder was called with. It differs from the bidder's name for aliases.
+	Seat string
+	// Price is the price paid by the winner, or the winning price of the imp for losses. It's 0 when unknown.
+	Price float64
+	// Currency is the currency of Price and of the bid's price
+	Currency string
+	// LossReason is only set on loss notices
+	LossReason openrtb_ext.LossReason
+}
+
+// MakeNotification is used for the bidders which aren't NotifyingBidders. It returns a GET on the bid's nurl,
+// lurl or burl, with the OpenRTB substitution macros replaced. It returns nil if the bid has no URL for the notice.
+func MakeNotification(notice *BidNotice) *RequestData {
+	var noticeURL string
+	switch notice.Type {
+	case NoticeWin:
+		noticeURL = notice.Bid.NURL
+	case NoticeLoss:
+		noticeURL = notice.Bid.LURL
+	case NoticeBilling:
+		noticeURL = notice.Bid.BURL
+	}
+	if noticeURL == "" {
+		return nil
+	}
+	return &RequestData{
+		Method: "GET",
+		Uri:    ReplaceNoticeMacros(noticeURL, notice),
+	}
+}
+
+// ReplaceNoticeMacros replaces the OpenRTB substitution macros in a notice URL.
+func ReplaceNoticeMacros(noticeURL string, notice *BidNotice) string {
+	price := ""
+	if notice.Price > 0 {
+		price = strconv.FormatFloat(notice.Price, 'f', -1, 64)
+	}
+	loss := ""
+	if notice.Type == NoticeLoss {
+		loss = strconv.Itoa(int(notice.LossReason))
+	}
+	replacer := strings.NewReplacer(
+		"${AUCTION_ID}", url.QueryEscape(notice.AuctionID),
+		"${AUCTION_BID_ID}", url.QueryEscape(notice.Bid.ID),
+		"${AUCTION_IMP_ID}", url.QueryEscape(notice.Bid.ImpID),
+		"${AUCTION_SEAT_ID}", url.QueryEscape(notice.Seat),
+		"${AUCTION_AD_ID}", url.QueryEscape(notice.Bid.AdID),
+		"${AUCTION_PRICE}", price,
+		"${AUCTION_CURRENCY}", notice.Currency,
+		"${AUCTION_LOSS}", loss,
+	)
+	return replacer.Replace(noticeURL)
+}
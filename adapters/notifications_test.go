@@ -0,0 +1,51 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeNotification(t *testing.T) {
+	bid := &openrtb.Bid{
+		ID:    "bid 1",
+		ImpID: "imp1",
+		NURL:  "http://bidder.com/win?price=${AUCTION_PRICE}&cur=${AUCTION_CURRENCY}",
+		LURL:  "http://bidder.com/loss?id=${AUCTION_ID}&bid=${AUCTION_BID_ID}&seat=${AUCTION_SEAT_ID}&reason=${AUCTION_LOSS}&price=${AUCTION_PRICE}",
+	}
+
+	testCases := []struct {
+		description string
+		notice      BidNotice
+		expectedURI string
+	}{
+		{
+			description: "Win notices go to the nurl with the price paid",
+			notice:      BidNotice{Type: NoticeWin, Bid: bid, Price: 1.25, Currency: "EUR"},
+			expectedURI: "http://bidder.com/win?price=1.25&cur=EUR",
+		},
+		{
+			description: "Loss notices go to the lurl with the loss reason",
+			notice:      BidNotice{Type: NoticeLoss, Bid: bid, AuctionID: "auction1", Seat: "appnexus", LossReason: openrtb_ext.LossReasonLostToHigherBid},
+			expectedURI: "http://bidder.com/loss?id=auction1&bid=bid+1&seat=appnexus&reason=102&price=",
+		},
+		{
+			description: "No request is made for bids without a burl",
+			notice:      BidNotice{Type: NoticeBilling, Bid: bid},
+		},
+	}
+
+	for _, test := range testCases {
+		request := MakeNotification(&test.notice)
+		if test.expectedURI == "" {
+			assert.Nil(t, request, test.description)
+			continue
+		}
+		if assert.NotNil(t, request, test.description) {
+			assert.Equal(t, "GET", request.Method, test.description)
+			assert.Equal(t, test.expectedURI, request.Uri, test.description)
+		}
+	}
+}
@@ -66,3 +66,21 @@ func (s *Syncer) FamilyName() string {
 func (s *Syncer) GDPRVendorID() uint16 {
 	return s.gdprVendorID
 }
+
+// NewAliasSyncer makes the syncer of a host alias which shares its core bidder's cookie.
+// It syncs like the core bidder, but the alias may have a vendor ID of its own.
+func NewAliasSyncer(coreSyncer usersync.Usersyncer, vendorID uint16) usersync.Usersyncer {
+	return &aliasSyncer{
+		Usersyncer:   coreSyncer,
+		gdprVendorID: vendorID,
+	}
+}
+
+type aliasSyncer struct {
+	usersync.Usersyncer
+	gdprVendorID uint16
+}
+
+func (s *aliasSyncer) GDPRVendorID() uint16 {
+	return s.gdprVendorID
+}
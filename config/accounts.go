@@ -1,8 +1,228 @@
 package config
 
+import (
+	"fmt"
+
+	"github.com/prebid/prebid-server/macros"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"golang.org/x/text/currency"
+)
+
 // Account represents a publisher account configuration
 type Account struct {
-	ID       string      `mapstructure:"id" json:"id"`
-	Disabled bool        `mapstructure:"disabled" json:"disabled"`
-	CacheTTL DefaultTTLs `mapstructure:"cache_ttl" json:"cache_ttl"`
+	ID             string                `mapstructure:"id" json:"id"`
+	Disabled       bool                  `mapstructure:"disabled" json:"disabled"`
+	CacheTTL       DefaultTTLs           `mapstructure:"cache_ttl" json:"cache_ttl"`
+	BidValidations AccountBidValidations `mapstructure:"bid_validations" json:"bid_validations"`
+	// BidAdjustments are applied to the account's bids along with the ones in request.ext.prebid.bidadjustments.
+	// The request wins when both define adjustments for the same media type, bidder and deal.
+	BidAdjustments *openrtb_ext.ExtRequestPrebidBidAdjustments `mapstructure:"bid_adjustments" json:"bid_adjustments,omitempty"`
+	// DefaultCurrency is the auction currency for requests which don't set request.cur. Defaults to USD.
+	DefaultCurrency string `mapstructure:"default_currency" json:"default_currency,omitempty"`
+	// MediaTypePriceGranularity is used for the media types which request.ext.prebid.targeting.mediatypepricegranularity
+	// doesn't set. It wins over request.ext.prebid.targeting.pricegranularity.
+	MediaTypePriceGranularity *openrtb_ext.MediaTypePriceGranularity `mapstructure:"media_type_price_granularity" json:"media_type_price_granularity,omitempty"`
+	Targeting                 AccountTargeting                       `mapstructure:"targeting" json:"targeting"`
+	// BidderThrottling limits the requests sent to each bidder for this account. They apply on top of the
+	// host's adapters.{bidder}.throttling.
+	BidderThrottling map[string]BidderThrottling `mapstructure:"bidder_throttling" json:"bidder_throttling,omitempty"`
+	Notifications    AccountNotifications        `mapstructure:"notifications" json:"notifications"`
+}
+
+// AccountNotifications moves the bidders' win and billing notices from the client to PBS, and turns on the
+// loss notices, which only PBS can send.
+type AccountNotifications struct {
+	// ServerSideWins fires the nurl of the app auctions' winners once the auction is over, and removes it
+	// from the response.
+	ServerSideWins bool `mapstructure:"server_side_wins" json:"server_side_wins"`
+	// ServerSideBilling keeps the burl of the winners, and fires it when the client calls the billing event
+	// URL put in bid.ext.prebid.events.billing. The burl is removed from the response.
+	ServerSideBilling bool `mapstructure:"server_side_billing" json:"server_side_billing"`
+	// LossNotices tells the bidders about their bids which didn't win their imp, with the OpenRTB loss reason:
+	// lost to a higher bid, below the floor, or removed by the category mapping or the competitive exclusions.
+	LossNotices bool `mapstructure:"loss_notices" json:"loss_notices"`
+}
+
+// BidderThrottling limits the requests sent to a bidder
+type BidderThrottling struct {
+	// MaxQPS caps the requests per second sent to the bidder. 0 means no cap.
+	MaxQPS float64 `mapstructure:"max_qps" json:"max_qps,omitempty"`
+	// Sampling only sends a share of the traffic to the bidder. The first rule matching the request is used,
+	// and the requests which don't match any rule are all sent.
+	Sampling []TrafficSampling `mapstructure:"sampling" json:"sampling,omitempty"`
+}
+
+// TrafficSampling sends Percent of the requests matching the countries and media types to the bidder
+type TrafficSampling struct {
+	// Countries are matched against device.geo.country, or user.geo.country without it (ISO-3166-1 alpha-3).
+	// Empty means any country.
+	Countries []string `mapstructure:"countries" json:"countries,omitempty"`
+	// MediaTypes match requests with at least one imp of these types. Empty means any media type.
+	MediaTypes []openrtb_ext.BidType `mapstructure:"media_types" json:"media_types,omitempty"`
+	Percent    float64               `mapstructure:"percent" json:"percent"`
+}
+
+func (throttling *BidderThrottling) validate(prefix string, errs configErrors) configErrors {
+	if throttling.MaxQPS < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_qps must be >= 0. Got %f", prefix, throttling.MaxQPS))
+	}
+	for i, sampling := range throttling.Sampling {
+		if sampling.Percent < 0 || sampling.Percent > 100 {
+			errs = append(errs, fmt.Errorf("%s.sampling[%d].percent must be in the range [0, 100]. Got %f", prefix, i, sampling.Percent))
+		}
+		for _, mediaType := range sampling.MediaTypes {
+			if _, err := openrtb_ext.ParseBidType(string(mediaType)); err != nil {
+				errs = append(errs, fmt.Errorf("%s.sampling[%d].media_types: %v", prefix, i, err))
+			}
+		}
+	}
+	return errs
+}
+
+func (account *Account) validateBidderThrottling(field string, errs configErrors) configErrors {
+	for bidder, throttling := range account.BidderThrottling {
+		errs = throttling.validate(field+"."+bidder, errs)
+	}
+	return errs
+}
+
+// AccountTargeting holds the defaults for the targeting keys options of request.ext.prebid.targeting.
+// The request's options win over these. Only the custom keys apply to /openrtb2/video and /openrtb2/vast,
+// which read the pods from the standard keys.
+type AccountTargeting struct {
+	// Prefix replaces "hb" at the start of the targeting keys
+	Prefix string `mapstructure:"prefix" json:"prefix,omitempty"`
+	// IncludeKeys limits the standard targeting keys to the ones listed, using their unprefixed names (e.g. "hb_pb")
+	IncludeKeys []string `mapstructure:"include_keys" json:"include_keys,omitempty"`
+	// MaxKeyLength truncates the targeting keys which are longer. Defaults to 20.
+	MaxKeyLength int `mapstructure:"max_key_length" json:"max_key_length,omitempty"`
+	// AdServerMaxKeyLength overrides MaxKeyLength for the requests which name the ad server in
+	// request.ext.prebid.targeting.adserver
+	AdServerMaxKeyLength map[string]int `mapstructure:"ad_server_max_key_length" json:"ad_server_max_key_length,omitempty"`
+	// CustomKeys are added to the request's custom keys. The request wins when both use the same key.
+	CustomKeys []openrtb_ext.ExtRequestTargetingCustomKey `mapstructure:"custom_keys" json:"custom_keys,omitempty"`
+}
+
+func (targeting *AccountTargeting) validate(prefix string, errs configErrors) configErrors {
+	if targeting.MaxKeyLength < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_key_length must be >= 0. Got %d", prefix, targeting.MaxKeyLength))
+	}
+	for adServer, maxKeyLength := range targeting.AdServerMaxKeyLength {
+		if maxKeyLength <= 0 {
+			errs = append(errs, fmt.Errorf("%s.ad_server_max_key_length.%s must be a positive number. Got %d", prefix, adServer, maxKeyLength))
+		}
+	}
+	for i, customKey := range targeting.CustomKeys {
+		if customKey.Key == "" {
+			errs = append(errs, fmt.Errorf("%s.custom_keys[%d].key must not be empty", prefix, i))
+		}
+		if _, err := macros.ParseTargetingKeyValue(customKey.Value); err != nil {
+			errs = append(errs, fmt.Errorf("%s.custom_keys[%d].value is invalid: %v", prefix, i, err))
+		}
+	}
+	return errs
+}
+
+// validateBidAdjustments runs the checks of request.ext.prebid.bidadjustments. The bidders may be the
+// host's aliases, since the request's aliases aren't known yet.
+func (account *Account) validateBidAdjustments(field string, adapters map[string]Adapter, errs configErrors) configErrors {
+	aliases := make(map[string]string)
+	for name, adapter := range adapters {
+		if adapter.AliasOf != "" {
+			aliases[name] = adapter.AliasOf
+		}
+	}
+	if err := account.BidAdjustments.Validate(field, aliases); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (account *Account) validateMediaTypePriceGranularity(field string, errs configErrors) configErrors {
+	if account.MediaTypePriceGranularity == nil {
+		return errs
+	}
+	errs = validatePriceGranularity(field+".banner", account.MediaTypePriceGranularity.Banner, errs)
+	errs = validatePriceGranularity(field+".video", account.MediaTypePriceGranularity.Video, errs)
+	errs = validatePriceGranularity(field+".native", account.MediaTypePriceGranularity.Native, errs)
+	return errs
+}
+
+func validatePriceGranularity(field string, granularity *openrtb_ext.PriceGranularity, errs configErrors) configErrors {
+	if granularity == nil {
+		return errs
+	}
+	if granularity.Precision < 0 {
+		errs = append(errs, fmt.Errorf("%s.precision must be non-negative. Got %d", field, granularity.Precision))
+	}
+	if len(granularity.Ranges) == 0 {
+		errs = append(errs, fmt.Errorf("%s.ranges must not be empty", field))
+	}
+	prevMax := 0.0
+	for i, granularityRange := range granularity.Ranges {
+		if granularityRange.Max <= prevMax {
+			errs = append(errs, fmt.Errorf("%s.ranges[%d].max must be greater than the previous range's max", field, i))
+		}
+		if granularityRange.Increment <= 0 {
+			errs = append(errs, fmt.Errorf("%s.ranges[%d].increment must be a positive number. Got %f", field, i, granularityRange.Increment))
+		}
+		prevMax = granularityRange.Max
+	}
+	return errs
+}
+
+func (account *Account) validateDefaultCurrency(field string, errs configErrors) configErrors {
+	if account.DefaultCurrency == "" {
+		return errs
+	}
+	if _, err := currency.ParseISO(account.DefaultCurrency); err != nil {
+		errs = append(errs, fmt.Errorf("%s must be an ISO 4217 currency code. Got %q", field, account.DefaultCurrency))
+	}
+	return errs
+}
+
+// AccountBidValidations controls how strictly bids returned by the bidders are checked against the request
+type AccountBidValidations struct {
+	// BlockedCreatives applies to bids which use an advertiser domain, category or creative attribute
+	// blocked through bcat, badv or battr
+	BlockedCreatives ValidationMode `mapstructure:"blocked_creatives" json:"blocked_creatives"`
+	// BannerCreativeSize applies to banner bids whose w and h don't match any of the imp's formats.
+	// An empty mode means skip.
+	BannerCreativeSize ValidationMode `mapstructure:"banner_creative_size" json:"banner_creative_size"`
+	// SecureMarkup applies to bids on secure imps whose adm loads resources over http.
+	// An empty mode means skip.
+	SecureMarkup ValidationMode `mapstructure:"secure_markup" json:"secure_markup"`
+	// PrivateDeals applies to bids on private auction imps (imp.pmp.private_auction=1) which don't have a dealid,
+	// or whose dealid isn't one of the imp's deals.
+	PrivateDeals ValidationMode `mapstructure:"private_deals" json:"private_deals"`
+}
+
+func (validations *AccountBidValidations) validate(prefix string, errs configErrors) configErrors {
+	errs = validations.BlockedCreatives.validate(prefix+".blocked_creatives", errs)
+	errs = validations.BannerCreativeSize.validate(prefix+".banner_creative_size", errs)
+	errs = validations.SecureMarkup.validate(prefix+".secure_markup", errs)
+	errs = validations.PrivateDeals.validate(prefix+".private_deals", errs)
+	return errs
+}
+
+// ValidationMode tells what to do with a bid which fails a validation.
+// An empty mode means enforce, unless the validation says otherwise.
+type ValidationMode string
+
+const (
+	// ValidationEnforce rejects the bid
+	ValidationEnforce ValidationMode = "enforce"
+	// ValidationWarn keeps the bid and reports a warning
+	ValidationWarn ValidationMode = "warn"
+	// ValidationSkip doesn't run the validation at all
+	ValidationSkip ValidationMode = "skip"
+)
+
+func (mode ValidationMode) validate(field string, errs configErrors) configErrors {
+	switch mode {
+	case "", ValidationEnforce, ValidationWarn, ValidationSkip:
+	default:
+		errs = append(errs, fmt.Errorf("%s must be one of [%s, %s, %s]. Got %q", field, ValidationEnforce, ValidationWarn, ValidationSkip, mode))
+	}
+	return errs
 }
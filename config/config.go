@@ -2,11 +2,13 @@ package config
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -82,6 +84,12 @@ type Configuration struct {
 	RequestValidation RequestValidation `mapstructure:"request_validation"`
 	// When true, PBS will assign a randomly generated UUID to req.Source.TID if it is empty
 	AutoGenSourceTID bool `mapstructure:"auto_gen_source_tid"`
+	// Auction holds the settings for how the exchange picks and prices the winning bids
+	Auction Auction `mapstructure:"auction"`
+	// CircuitBreaker stops sending requests to bidder endpoints which keep failing
+	CircuitBreaker CircuitBreaker `mapstructure:"circuit_breaker"`
+	// Regions picks the bidders' regional endpoints
+	Regions Regions `mapstructure:"regions"`
 }
 
 const MIN_COOKIE_SIZE_BYTES = 500
@@ -93,6 +101,13 @@ type HTTPClient struct {
 	IdleConnTimeout     int `mapstructure:"idle_connection_timeout_seconds"`
 }
 
+func (cfg *HTTPClient) validate(prefix string, errs configErrors) configErrors {
+	if cfg.MaxConnsPerHost < 0 || cfg.MaxIdleConns < 0 || cfg.MaxIdleConnsPerHost < 0 || cfg.IdleConnTimeout < 0 {
+		errs = append(errs, fmt.Errorf("%s settings must be >= 0", prefix))
+	}
+	return errs
+}
+
 type configErrors []error
 
 func (c configErrors) Error() string {
@@ -113,7 +128,15 @@ func (c configErrors) Error() string {
 func (cfg *Configuration) validate() configErrors {
 	var errs configErrors
 	errs = cfg.AuctionTimeouts.validate(errs)
+	errs = cfg.Auction.validate(errs)
+	errs = cfg.CircuitBreaker.validate(errs)
 	errs = cfg.StoredRequests.validate(errs)
+	errs = cfg.AccountDefaults.BidValidations.validate("account_defaults.bid_validations", errs)
+	errs = cfg.AccountDefaults.validateDefaultCurrency("account_defaults.default_currency", errs)
+	errs = cfg.AccountDefaults.validateBidAdjustments("account_defaults.bid_adjustments", cfg.Adapters, errs)
+	errs = cfg.AccountDefaults.validateMediaTypePriceGranularity("account_defaults.media_type_price_granularity", errs)
+	errs = cfg.AccountDefaults.Targeting.validate("account_defaults.targeting", errs)
+	errs = cfg.AccountDefaults.validateBidderThrottling("account_defaults.bidder_throttling", errs)
 	errs = cfg.StoredRequestsAMP.validate(errs)
 	errs = cfg.Accounts.validate(errs)
 	errs = cfg.CategoryMapping.validate(errs)
@@ -125,6 +148,7 @@ func (cfg *Configuration) validate() configErrors {
 	errs = cfg.GDPR.validate(errs)
 	errs = cfg.CurrencyConverter.validate(errs)
 	errs = validateAdapters(cfg.Adapters, errs)
+	errs = cfg.Regions.validate(cfg.Adapters, errs)
 	errs = cfg.Debug.validate(errs)
 	errs = cfg.ExtCacheURL.validate(errs)
 	if cfg.AccountDefaults.Disabled {
@@ -147,6 +171,198 @@ func (cfg *AuctionTimeouts) validate(errs configErrors) configErrors {
 	return errs
 }
 
+type Auction struct {
+	// SecondPriceIncrement is added to the second highest bid to get the clearing price of a second price auction (at=2)
+	SecondPriceIncrement float64 `mapstructure:"second_price_increment"`
+	// BidderTimeouts gives each bidder its own timeout, based on how fast it has been responding
+	BidderTimeouts BidderTimeouts `mapstructure:"bidder_timeouts"`
+	// Notifications configures the win, loss and billing notices sent to the bidders after the auction
+	Notifications BidNotifications `mapstructure:"notifications"`
+}
+
+func (cfg *Auction) validate(errs configErrors) configErrors {
+	if cfg.SecondPriceIncrement < 0 {
+		errs = append(errs, fmt.Errorf("auction.second_price_increment must be >= 0. Got %f", cfg.SecondPriceIncrement))
+	}
+	errs = cfg.BidderTimeouts.validate(errs)
+	errs = cfg.Notifications.validate(errs)
+	return errs
+}
+
+// BidNotifications configures the notices sent to the bidders about the outcome of their bids. The billing
+// notices of the accounts which ask PBS to send them wait in memory until the client's billing event arrives.
+type BidNotifications struct {
+	// TimeoutMillis is how long PBS waits on a bidder's notification endpoint
+	TimeoutMillis uint64 `mapstructure:"timeout_ms"`
+	// BillingCacheSize is the memory, in bytes, used for the pending billing notices. The oldest notices are
+	// dropped when it's full.
+	BillingCacheSize int `mapstructure:"billing_cache_size_bytes"`
+	// BillingTTLSeconds is how long a billing notice waits for its event before being dropped. 0 keeps the
+	// notices until they're pushed out of the cache.
+	BillingTTLSeconds int `mapstructure:"billing_ttl_seconds"`
+	// MaxConcurrent is how many notices may be in flight at once. The notices sent while that many are
+	// waiting on the bidders are dropped. Defaults to 100.
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+}
+
+func (cfg *BidNotifications) validate(errs configErrors) configErrors {
+	if cfg.BillingCacheSize < 0 {
+		errs = append(errs, fmt.Errorf("auction.notifications.billing_cache_size_bytes must be >= 0. Got %d", cfg.BillingCacheSize))
+	}
+	if cfg.BillingTTLSeconds < 0 {
+		errs = append(errs, fmt.Errorf("auction.notifications.billing_ttl_seconds must be >= 0. Got %d", cfg.BillingTTLSeconds))
+	}
+	if cfg.MaxConcurrent < 0 {
+		errs = append(errs, fmt.Errorf("auction.notifications.max_concurrent must be >= 0. Got %d", cfg.MaxConcurrent))
+	}
+	return errs
+}
+
+// BidderTimeouts configures the per-bidder timeouts. Every bidder is sent the time left in the auction, minus
+// the network buffer, as its tmax. Bidders whose recent latency percentile doesn't fit in that budget would
+// mostly time out anyway, so they're only given SlowBidderPercent of it and the auction doesn't wait on them.
+type BidderTimeouts struct {
+	Enabled bool `mapstructure:"enabled"`
+	// NetworkBufferMillis is kept out of the tmax sent to the bidders, to leave time for the request and response to travel
+	NetworkBufferMillis uint64 `mapstructure:"network_buffer_ms"`
+	// LatencyPercentile is the percentile of the bidder's recent response times compared with the budget
+	LatencyPercentile float64 `mapstructure:"latency_percentile"`
+	// SampleSize is how many of the bidder's most recent requests are used to compute the percentile
+	SampleSize int `mapstructure:"sample_size"`
+	// MinSamples is how many requests a bidder must have made before its timeout can be cut
+	MinSamples int `mapstructure:"min_samples"`
+	// SlowBidderPercent is the share of the budget given to slow bidders. Their timeouts are recorded as the time
+	// they were given, so they get the full budget back unless that time plus the network buffer is over it.
+	SlowBidderPercent int `mapstructure:"slow_bidder_percent"`
+}
+
+func (cfg *BidderTimeouts) validate(errs configErrors) configErrors {
+	if !cfg.Enabled {
+		return errs
+	}
+	if cfg.LatencyPercentile <= 0 || cfg.LatencyPercentile > 100 {
+		errs = append(errs, fmt.Errorf("auction.bidder_timeouts.latency_percentile must be in the range (0, 100]. Got %f", cfg.LatencyPercentile))
+	}
+	if cfg.SampleSize <= 0 {
+		errs = append(errs, fmt.Errorf("auction.bidder_timeouts.sample_size must be > 0. Got %d", cfg.SampleSize))
+	}
+	if cfg.MinSamples <= 0 || cfg.MinSamples > cfg.SampleSize {
+		errs = append(errs, fmt.Errorf("auction.bidder_timeouts.min_samples must be in the range [1, sample_size]. Got %d", cfg.MinSamples))
+	}
+	if cfg.SlowBidderPercent <= 0 || cfg.SlowBidderPercent > 100 {
+		errs = append(errs, fmt.Errorf("auction.bidder_timeouts.slow_bidder_percent must be in the range [1, 100]. Got %d", cfg.SlowBidderPercent))
+	}
+	return errs
+}
+
+// CircuitBreaker configures the circuit breakers kept for each bidder endpoint host. A circuit breaker opens when
+// the share of failed requests among the most recent ones reaches ErrorRatio. While it's open, no requests are
+// sent to the endpoint. After OpenIntervalMillis, a single request is let through to probe the endpoint. The
+// circuit breaker closes if the probe succeeds, and opens again if it fails.
+type CircuitBreaker struct {
+	Enabled bool `mapstructure:"enabled"`
+	// WindowSize is how many of the most recent requests are considered
+	WindowSize int `mapstructure:"window_size"`
+	// MinRequests is how many requests must be made to the endpoint before the circuit breaker can open
+	MinRequests int `mapstructure:"min_requests"`
+	// ErrorRatio is the share of timeouts, connection errors and 5xx responses which opens the circuit breaker
+	ErrorRatio float64 `mapstructure:"error_ratio"`
+	// OpenIntervalMillis is how long the circuit breaker stays open before probing the endpoint
+	OpenIntervalMillis int `mapstructure:"open_interval_ms"`
+}
+
+func (cfg *CircuitBreaker) validate(errs configErrors) configErrors {
+	if !cfg.Enabled {
+		return errs
+	}
+	if cfg.WindowSize <= 0 {
+		errs = append(errs, fmt.Errorf("circuit_breaker.window_size must be > 0. Got %d", cfg.WindowSize))
+	}
+	if cfg.MinRequests <= 0 || cfg.MinRequests > cfg.WindowSize {
+		errs = append(errs, fmt.Errorf("circuit_breaker.min_requests must be in the range [1, window_size]. Got %d", cfg.MinRequests))
+	}
+	if cfg.ErrorRatio <= 0 || cfg.ErrorRatio > 1 {
+		errs = append(errs, fmt.Errorf("circuit_breaker.error_ratio must be in the range (0, 1]. Got %f", cfg.ErrorRatio))
+	}
+	if cfg.OpenIntervalMillis <= 0 {
+		errs = append(errs, fmt.Errorf("circuit_breaker.open_interval_ms must be > 0. Got %d", cfg.OpenIntervalMillis))
+	}
+	return errs
+}
+
+// Regions names the regions which the bidders' regional endpoints are set up for
+type Regions struct {
+	// Datacenter is the region of this PBS host. It's used when the device's country isn't in any region.
+	Datacenter string `mapstructure:"datacenter"`
+	// Countries lists the countries of each region, as ISO-3166-1-alpha-3 codes like device.geo.country
+	Countries map[string][]string `mapstructure:"countries"`
+}
+
+// Names returns the names of the regions, sorted
+func (cfg *Regions) Names() []string {
+	names := make([]string, 0, len(cfg.Countries)+1)
+	for region := range cfg.Countries {
+		names = append(names, region)
+	}
+	if _, ok := cfg.Countries[cfg.Datacenter]; !ok && cfg.Datacenter != "" {
+		names = append(names, cfg.Datacenter)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (cfg *Regions) validate(adapters map[string]Adapter, errs configErrors) configErrors {
+	regions := make(map[string]bool)
+	regionOf := make(map[string]string)
+	for _, region := range cfg.Names() {
+		regions[region] = true
+		for _, country := range cfg.Countries[region] {
+			country = strings.ToUpper(country)
+			if other, ok := regionOf[country]; ok {
+				errs = append(errs, fmt.Errorf("regions.countries: %s is in both %s and %s", country, other, region))
+			}
+			regionOf[country] = region
+		}
+	}
+
+	for adapterName, adapter := range adapters {
+		for region := range adapter.Endpoints {
+			if !regions[region] {
+				errs = append(errs, fmt.Errorf("adapters.%s.endpoints.%s isn't the datacenter or one of the regions.countries", adapterName, region))
+			}
+		}
+	}
+	return errs
+}
+
+// setRegionalEndpoints resolves the {{.Region}} macro of the adapters' endpoints. The regions which an adapter
+// doesn't list get its default endpoint, and the default endpoint becomes the one of the datacenter. It's left
+// as it is without a datacenter, for the validation to report it.
+func (cfg *Configuration) setRegionalEndpoints() {
+	regions := cfg.Regions.Names()
+	for adapterName, adapter := range cfg.Adapters {
+		if len(adapter.Endpoints) == 0 && !strings.Contains(adapter.Endpoint, macros.RegionMacro) {
+			continue
+		}
+		endpoints := make(map[string]string, len(regions))
+		for region, endpoint := range adapter.Endpoints {
+			endpoints[region] = strings.Replace(endpoint, macros.RegionMacro, region, -1)
+		}
+		if strings.Contains(adapter.Endpoint, macros.RegionMacro) {
+			for _, region := range regions {
+				if _, ok := endpoints[region]; !ok {
+					endpoints[region] = strings.Replace(adapter.Endpoint, macros.RegionMacro, region, -1)
+				}
+			}
+			if cfg.Regions.Datacenter != "" {
+				adapter.Endpoint = strings.Replace(adapter.Endpoint, macros.RegionMacro, cfg.Regions.Datacenter, -1)
+			}
+		}
+		adapter.Endpoints = endpoints
+		cfg.Adapters[adapterName] = adapter
+	}
+}
+
 func (data *ExternalCache) validate(errs configErrors) configErrors {
 	if data.Host == "" && data.Path == "" {
 		// Both host and path can be blank. No further validation needed
@@ -373,6 +589,167 @@ type Adapter struct {
 	// needed for Facebook
 	PlatformID string `mapstructure:"platform_id"`
 	AppSecret  string `mapstructure:"app_secret"`
+
+	// Throttling limits the requests sent to this bidder across all accounts
+	Throttling BidderThrottling `mapstructure:"throttling"`
+
+	// AliasOf makes this adapter a permanent alias of another bidder. The alias runs the core bidder's
+	// code under its own name, so it gets its own metrics, /info/bidders entry and cookie sync. The
+	// endpoint, platform ID, extra info and http client default to those of the core bidder, and so do the
+	// TLS and HTTP/2 settings when the alias uses the core bidder's endpoint.
+	AliasOf string `mapstructure:"alias_of"`
+	// GVLVendorID is the alias' vendor ID in the IAB Global Vendor List. It defaults to the core bidder's.
+	GVLVendorID uint16 `mapstructure:"gvl_vendor_id"`
+
+	// TLS sets up the TLS connections to this bidder, for the bidders which need a client certificate or their
+	// own CAs.
+	TLS AdapterTLS `mapstructure:"tls"`
+	// HTTPClient sizes the connection pool used for this bidder. The settings left at 0 are those of the
+	// http_client section. The bidders which set it, or their TLS, get an http client of their own.
+	HTTPClient HTTPClient `mapstructure:"http_client"`
+
+	// Endpoints are the bidder's regional endpoints, by region name. An auction uses the endpoint of the region
+	// holding the device's country, then the one of the host's datacenter, and falls back to Endpoint. If
+	// Endpoint uses the {{.Region}} macro, every region which isn't listed gets it with the region's name.
+	Endpoints map[string]string `mapstructure:"endpoints"`
+
+	// Warmup opens connections to this bidder before its first auction, and keeps them open while it's idle.
+	Warmup AdapterWarmup `mapstructure:"warmup"`
+	// HTTP2 negotiates HTTP/2 with the bidder over TLS. The bidders which don't support it are called over
+	// HTTP/1.1, like with the setting off. The bidders which set it get an http client of their own.
+	HTTP2 bool `mapstructure:"http2"`
+}
+
+// AdapterWarmup sets up the probes which open a bidder's connections and keep them open. The probes are sent to
+// the root of each of the bidder's endpoint hosts, including its regional ones, and their responses are ignored.
+type AdapterWarmup struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Connections is how many connections are opened to each host. It defaults to 1, and is limited by the
+	// idle connections kept by the bidder's http client.
+	Connections int `mapstructure:"connections"`
+	// IntervalSeconds is how often the probes are sent again, and should be shorter than the idle connection
+	// timeout. The probes are only sent at startup if it's 0.
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// TimeoutMillis is how long a round of probes may take. It defaults to 1000.
+	TimeoutMillis int `mapstructure:"timeout_ms"`
+	// URL is probed instead of the endpoints' hosts. It's needed if the host of an endpoint uses macros.
+	URL string `mapstructure:"url"`
+}
+
+// WarmupURLs returns the URLs probed to warm up the adapter's connections
+func (cfg *Adapter) WarmupURLs() []string {
+	if cfg.Warmup.URL != "" {
+		return []string{cfg.Warmup.URL}
+	}
+	endpoints := make([]string, 0, len(cfg.Endpoints)+1)
+	endpoints = append(endpoints, cfg.Endpoint)
+	for _, endpoint := range cfg.Endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+
+	seen := make(map[string]bool, len(endpoints))
+	urls := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		origin := endpoint
+		if schemeEnd := strings.Index(origin, "://"); schemeEnd >= 0 {
+			if pathStart := strings.Index(origin[schemeEnd+3:], "/"); pathStart >= 0 {
+				origin = origin[:schemeEnd+3+pathStart]
+			}
+		}
+		origin += "/"
+		if endpoint != "" && !seen[origin] {
+			seen[origin] = true
+			urls = append(urls, origin)
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func (cfg *AdapterWarmup) validate(prefix string, urls []string, errs configErrors) configErrors {
+	if !cfg.Enabled {
+		return errs
+	}
+	if cfg.Connections < 0 || cfg.IntervalSeconds < 0 || cfg.TimeoutMillis < 0 {
+		errs = append(errs, fmt.Errorf("%s settings must be >= 0", prefix))
+	}
+	for _, url := range urls {
+		if strings.Contains(url, "{{") {
+			errs = append(errs, fmt.Errorf("%s.url must be set, since the host of %s uses macros", prefix, url))
+		} else if !validator.IsURL(url) || !validator.IsRequestURL(url) {
+			errs = append(errs, fmt.Errorf("%s: %s is not a valid URL", prefix, url))
+		}
+	}
+	return errs
+}
+
+// AdapterTLS holds the TLS settings of a bidder's connections
+type AdapterTLS struct {
+	// CertFile and KeyFile are the PEM files of the client certificate sent to the bidder, for mutual TLS.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile is a PEM file of extra CAs trusted for this bidder, on top of the ones trusted for every bidder.
+	CAFile string `mapstructure:"ca_file"`
+	// MinVersion is the lowest TLS version accepted from the bidder: "1.0", "1.1", "1.2" or "1.3".
+	MinVersion string `mapstructure:"min_version"`
+	// ServerName overrides the name sent with SNI and checked against the bidder's certificate.
+	ServerName string `mapstructure:"server_name"`
+}
+
+// TLSVersions maps the values of AdapterTLS.MinVersion to the versions of crypto/tls
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// IsSet tells whether any of the TLS settings is used
+func (cfg *AdapterTLS) IsSet() bool {
+	return *cfg != AdapterTLS{}
+}
+
+func (cfg *AdapterTLS) validate(prefix string, errs configErrors) configErrors {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("%s.cert_file and %s.key_file must be set together", prefix, prefix))
+	}
+	if _, ok := TLSVersions[cfg.MinVersion]; cfg.MinVersion != "" && !ok {
+		errs = append(errs, fmt.Errorf("%s.min_version must be one of 1.0, 1.1, 1.2 or 1.3. Got %s", prefix, cfg.MinVersion))
+	}
+	return errs
+}
+
+// setAliasDefaults fills in the alias adapters' settings which weren't configured with those of their core bidders.
+func setAliasDefaults(adapterMap map[string]Adapter) {
+	for adapterName, adapter := range adapterMap {
+		if adapter.AliasOf == "" {
+			continue
+		}
+		core := adapterMap[strings.ToLower(adapter.AliasOf)]
+		if adapter.Endpoint == "" {
+			adapter.Endpoint = core.Endpoint
+			if len(adapter.Endpoints) == 0 {
+				adapter.Endpoints = core.Endpoints
+			}
+		}
+		if adapter.PlatformID == "" {
+			adapter.PlatformID = core.PlatformID
+		}
+		if adapter.ExtraAdapterInfo == "" {
+			adapter.ExtraAdapterInfo = core.ExtraAdapterInfo
+		}
+		// The alias connects to the core bidder's servers unless it has its own endpoint
+		if adapter.Endpoint == core.Endpoint {
+			if !adapter.TLS.IsSet() {
+				adapter.TLS = core.TLS
+			}
+			adapter.HTTP2 = adapter.HTTP2 || core.HTTP2
+		}
+		if adapter.HTTPClient == (HTTPClient{}) {
+			adapter.HTTPClient = core.HTTPClient
+		}
+		adapterMap[adapterName] = adapter
+	}
 }
 
 // validateAdapterEndpoint makes sure that an adapter has a valid endpoint
@@ -407,6 +784,18 @@ func validateAdapterEndpoint(endpoint string, adapterName string, errs configErr
 	return errs
 }
 
+// validateAdapterAlias makes sure that an alias doesn't refer to itself or to another alias.
+// Whether the core bidder exists is checked when the alias gets registered.
+func validateAdapterAlias(adapterMap map[string]Adapter, adapterName string, aliasOf string, errs configErrors) configErrors {
+	if strings.EqualFold(adapterName, aliasOf) {
+		return append(errs, fmt.Errorf("adapters.%s.alias_of must not refer to itself", adapterName))
+	}
+	if core, ok := adapterMap[strings.ToLower(aliasOf)]; ok && core.AliasOf != "" {
+		return append(errs, fmt.Errorf("adapters.%s.alias_of must refer to a core bidder, but %s is an alias of %s", adapterName, aliasOf, core.AliasOf))
+	}
+	return errs
+}
+
 // validateAdapterUserSyncURL validates an adapter's user sync URL if it is set
 func validateAdapterUserSyncURL(userSyncURL string, adapterName string, errs configErrors) configErrors {
 	if userSyncURL != "" {
@@ -444,11 +833,28 @@ func validateAdapterUserSyncURL(userSyncURL string, adapterName string, errs con
 func validateAdapters(adapterMap map[string]Adapter, errs configErrors) configErrors {
 	for adapterName, adapter := range adapterMap {
 		if !adapter.Disabled {
-			// Verify that every adapter has a valid endpoint associated with it
-			errs = validateAdapterEndpoint(adapter.Endpoint, adapterName, errs)
+			if adapter.AliasOf != "" {
+				errs = validateAdapterAlias(adapterMap, adapterName, adapter.AliasOf, errs)
+			}
+
+			// Verify that every adapter has a valid endpoint associated with it. The aliases of bidders which
+			// are declared in static/bidder-info may leave it out, since it's in their core bidder's info file.
+			if strings.Contains(adapter.Endpoint, macros.RegionMacro) {
+				errs = append(errs, fmt.Errorf("adapters.%s.endpoint uses the %s macro, so regions.datacenter must be set", adapterName, macros.RegionMacro))
+			} else if adapter.AliasOf == "" || adapter.Endpoint != "" {
+				errs = validateAdapterEndpoint(adapter.Endpoint, adapterName, errs)
+			}
+			for region, endpoint := range adapter.Endpoints {
+				errs = validateAdapterEndpoint(endpoint, adapterName+" in region "+region, errs)
+			}
 
 			// Verify that valid user_sync URLs are specified in the config
 			errs = validateAdapterUserSyncURL(adapter.UserSyncURL, adapterName, errs)
+
+			errs = adapter.Throttling.validate(fmt.Sprintf("adapters.%s.throttling", adapterName), errs)
+			errs = adapter.TLS.validate(fmt.Sprintf("adapters.%s.tls", adapterName), errs)
+			errs = adapter.HTTPClient.validate(fmt.Sprintf("adapters.%s.http_client", adapterName), errs)
+			errs = adapter.Warmup.validate(fmt.Sprintf("adapters.%s.warmup", adapterName), adapter.WarmupURLs(), errs)
 		}
 	}
 	return errs
@@ -590,6 +996,8 @@ func New(v *viper.Viper) (*Configuration, error) {
 		return nil, fmt.Errorf("viper failed to unmarshal app config: %v", err)
 	}
 	c.setDerivedDefaults()
+	setAliasDefaults(c.Adapters)
+	c.setRegionalEndpoints()
 
 	if err := c.RequestValidation.Parse(); err != nil {
 		return nil, err
@@ -1016,8 +1424,28 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("blacklisted_accts", []string{""})
 	v.SetDefault("account_required", false)
 	v.SetDefault("account_defaults.disabled", false)
+	v.SetDefault("account_defaults.bid_validations.blocked_creatives", string(ValidationEnforce))
+	v.SetDefault("account_defaults.bid_validations.banner_creative_size", string(ValidationSkip))
+	v.SetDefault("account_defaults.bid_validations.secure_markup", string(ValidationSkip))
+	v.SetDefault("account_defaults.bid_validations.private_deals", string(ValidationEnforce))
 	v.SetDefault("certificates_file", "")
 	v.SetDefault("auto_gen_source_tid", true)
+	v.SetDefault("auction.second_price_increment", 0.01)
+	v.SetDefault("auction.bidder_timeouts.enabled", false)
+	v.SetDefault("auction.bidder_timeouts.network_buffer_ms", 50)
+	v.SetDefault("auction.bidder_timeouts.latency_percentile", 90)
+	v.SetDefault("auction.bidder_timeouts.sample_size", 200)
+	v.SetDefault("auction.bidder_timeouts.min_samples", 20)
+	v.SetDefault("auction.bidder_timeouts.slow_bidder_percent", 50)
+	v.SetDefault("auction.notifications.timeout_ms", 200)
+	v.SetDefault("auction.notifications.billing_cache_size_bytes", 10*1024*1024)
+	v.SetDefault("auction.notifications.billing_ttl_seconds", 3600)
+	v.SetDefault("circuit_breaker.enabled", false)
+	v.SetDefault("circuit_breaker.window_size", 100)
+	v.SetDefault("circuit_breaker.min_requests", 20)
+	v.SetDefault("circuit_breaker.error_ratio", 0.5)
+	v.SetDefault("circuit_breaker.open_interval_ms", 10000)
+	v.SetDefault("regions.datacenter", "")
 
 	v.SetDefault("request_timeout_headers.request_time_in_queue", "")
 	v.SetDefault("request_timeout_headers.request_timeout_in_queue", "")
@@ -140,6 +140,13 @@ func TestDefaults(t *testing.T) {
 	cmpBools(t, "stored_requests.filesystem.enabled", false, cfg.StoredRequests.Files.Enabled)
 	cmpStrings(t, "stored_requests.filesystem.directorypath", "./stored_requests/data/by_id", cfg.StoredRequests.Files.Path)
 	cmpBools(t, "auto_gen_source_tid", cfg.AutoGenSourceTID, true)
+	assert.Equal(t, 0.01, cfg.Auction.SecondPriceIncrement, "auction.second_price_increment")
+	cmpBools(t, "auction.bidder_timeouts.enabled", cfg.Auction.BidderTimeouts.Enabled, false)
+	cmpInts(t, "auction.bidder_timeouts.min_samples", cfg.Auction.BidderTimeouts.MinSamples, 20)
+	assert.Equal(t, uint64(200), cfg.Auction.Notifications.TimeoutMillis, "auction.notifications.timeout_ms")
+	cmpInts(t, "auction.notifications.billing_ttl_seconds", cfg.Auction.Notifications.BillingTTLSeconds, 3600)
+	cmpBools(t, "circuit_breaker.enabled", cfg.CircuitBreaker.Enabled, false)
+	assert.Equal(t, 0.5, cfg.CircuitBreaker.ErrorRatio, "circuit_breaker.error_ratio")
 }
 
 var fullConfig = []byte(`
@@ -227,6 +234,19 @@ adapters:
 blacklisted_apps: ["spamAppID","sketchy-app-id"]
 account_required: true
 auto_gen_source_tid: false
+auction:
+  second_price_increment: 0.05
+  bidder_timeouts:
+    enabled: true
+    network_buffer_ms: 100
+    latency_percentile: 95
+account_defaults:
+  media_type_price_granularity:
+    video:
+      precision: 2
+      ranges:
+        - max: 20
+          increment: 0.5
 certificates_file: /etc/ssl/cert.pem
 request_validation:
     ipv4_private_networks: ["1.1.1.0/24"]
@@ -410,6 +430,18 @@ func TestFullConfig(t *testing.T) {
 	cmpStrings(t, "adapters.rhythmone.usersync_url", cfg.Adapters[string(openrtb_ext.BidderRhythmone)].UserSyncURL, "https://sync.1rx.io/usersync2/rmphb?gdpr={{.GDPR}}&gdpr_consent={{.GDPRConsent}}&us_privacy={{.USPrivacy}}&redir=http%3A%2F%2Fprebid-server.prebid.org%2F%2Fsetuid%3Fbidder%3Drhythmone%26gdpr%3D{{.GDPR}}%26gdpr_consent%3D{{.GDPRConsent}}%26uid%3D%5BRX_UUID%5D")
 	cmpBools(t, "account_required", cfg.AccountRequired, true)
 	cmpBools(t, "auto_gen_source_tid", cfg.AutoGenSourceTID, false)
+	assert.Equal(t, 0.05, cfg.Auction.SecondPriceIncrement, "auction.second_price_increment")
+	cmpBools(t, "auction.bidder_timeouts.enabled", cfg.Auction.BidderTimeouts.Enabled, true)
+	assert.Equal(t, uint64(100), cfg.Auction.BidderTimeouts.NetworkBufferMillis, "auction.bidder_timeouts.network_buffer_ms")
+	assert.Equal(t, 95.0, cfg.Auction.BidderTimeouts.LatencyPercentile, "auction.bidder_timeouts.latency_percentile")
+	cmpInts(t, "auction.bidder_timeouts.sample_size", cfg.Auction.BidderTimeouts.SampleSize, 200)
+	if assert.NotNil(t, cfg.AccountDefaults.MediaTypePriceGranularity, "account_defaults.media_type_price_granularity") {
+		assert.Nil(t, cfg.AccountDefaults.MediaTypePriceGranularity.Banner, "account_defaults.media_type_price_granularity.banner")
+		assert.Equal(t, &openrtb_ext.PriceGranularity{
+			Precision: 2,
+			Ranges:    []openrtb_ext.GranularityRange{{Max: 20, Increment: 0.5}},
+		}, cfg.AccountDefaults.MediaTypePriceGranularity.Video, "account_defaults.media_type_price_granularity.video")
+	}
 	cmpBools(t, "account_adapter_details", cfg.Metrics.Disabled.AccountAdapterDetails, true)
 	cmpBools(t, "adapter_connections_metrics", cfg.Metrics.Disabled.AdapterConnectionMetrics, true)
 	cmpStrings(t, "certificates_file", cfg.PemCertsFile, "/etc/ssl/cert.pem")
@@ -522,12 +554,259 @@ func TestInvalidAdapterUserSyncURLConfig(t *testing.T) {
 	assert.Error(t, err, "invalid user_sync URL in config should return an error")
 }
 
+func TestAdapterAliasDefaults(t *testing.T) {
+	v := viper.New()
+	SetupViper(v, "")
+	v.SetConfigType("yaml")
+	v.ReadConfig(bytes.NewBuffer([]byte(`
+adapters:
+  whitelabel:
+    alias_of: appnexus
+    gvl_vendor_id: 1234
+  customlabel:
+    alias_of: appnexus
+    endpoint: http://whitelabel.example.com/openrtb2
+`)))
+	cfg, err := New(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, cfg.Adapters[string(openrtb_ext.BidderAppnexus)].Endpoint, cfg.Adapters["whitelabel"].Endpoint, "The alias should default to the core bidder's endpoint")
+	assert.Equal(t, uint16(1234), cfg.Adapters["whitelabel"].GVLVendorID)
+	assert.Equal(t, "http://whitelabel.example.com/openrtb2", cfg.Adapters["customlabel"].Endpoint)
+}
+
+func TestInvalidAdapterAlias(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.Adapters["whitelabel"] = Adapter{AliasOf: "whitelabel", Endpoint: "http://whitelabel.example.com"}
+	assertOneError(t, cfg.validate(), "adapters.whitelabel.alias_of must not refer to itself")
+
+	cfg.Adapters["whitelabel"] = Adapter{AliasOf: "appnexus", Endpoint: "http://whitelabel.example.com"}
+	cfg.Adapters["otherlabel"] = Adapter{AliasOf: "whitelabel", Endpoint: "http://otherlabel.example.com"}
+	assertOneError(t, cfg.validate(), "adapters.otherlabel.alias_of must refer to a core bidder, but whitelabel is an alias of appnexus")
+
+	delete(cfg.Adapters, "otherlabel")
+	cfg.Adapters["configlabel"] = Adapter{AliasOf: "configpartner"}
+	assert.Empty(t, cfg.validate(), "The aliases of config-only bidders may leave out the endpoint")
+}
+
 func TestNegativeRequestSize(t *testing.T) {
 	cfg := newDefaultConfig(t)
 	cfg.MaxRequestSize = -1
 	assertOneError(t, cfg.validate(), "cfg.max_request_size must be >= 0. Got -1")
 }
 
+func TestNegativeSecondPriceIncrement(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.Auction.SecondPriceIncrement = -0.5
+	assertOneError(t, cfg.validate(), "auction.second_price_increment must be >= 0. Got -0.500000")
+}
+
+func TestInvalidBidNotifications(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.Auction.Notifications.BillingTTLSeconds = -1
+	assertOneError(t, cfg.validate(), "auction.notifications.billing_ttl_seconds must be >= 0. Got -1")
+
+	cfg.Auction.Notifications.BillingTTLSeconds = 0
+	cfg.Auction.Notifications.MaxConcurrent = -1
+	assertOneError(t, cfg.validate(), "auction.notifications.max_concurrent must be >= 0. Got -1")
+}
+
+func TestInvalidBidderTimeouts(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.Auction.BidderTimeouts.LatencyPercentile = 120
+	assert.Empty(t, cfg.validate(), "Bidder timeouts shouldn't be validated while disabled")
+
+	cfg.Auction.BidderTimeouts.Enabled = true
+	assertOneError(t, cfg.validate(), "auction.bidder_timeouts.latency_percentile must be in the range (0, 100]. Got 120.000000")
+
+	cfg.Auction.BidderTimeouts.LatencyPercentile = 90
+	cfg.Auction.BidderTimeouts.MinSamples = 500
+	assertOneError(t, cfg.validate(), "auction.bidder_timeouts.min_samples must be in the range [1, sample_size]. Got 500")
+}
+
+func TestInvalidCircuitBreaker(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.CircuitBreaker.ErrorRatio = 2
+	assert.Empty(t, cfg.validate(), "The circuit breaker shouldn't be validated while disabled")
+
+	cfg.CircuitBreaker.Enabled = true
+	assertOneError(t, cfg.validate(), "circuit_breaker.error_ratio must be in the range (0, 1]. Got 2.000000")
+
+	cfg.CircuitBreaker.ErrorRatio = 0.5
+	cfg.CircuitBreaker.OpenIntervalMillis = 0
+	assertOneError(t, cfg.validate(), "circuit_breaker.open_interval_ms must be > 0. Got 0")
+}
+
+func TestInvalidBidderThrottling(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.BidderThrottling = map[string]BidderThrottling{
+		"appnexus": {
+			MaxQPS:   10,
+			Sampling: []TrafficSampling{{Countries: []string{"USA"}, MediaTypes: []openrtb_ext.BidType{"display"}, Percent: 50}},
+		},
+	}
+	assertOneError(t, cfg.validate(), "account_defaults.bidder_throttling.appnexus.sampling[0].media_types: invalid BidType: display")
+
+	cfg.AccountDefaults.BidderThrottling["appnexus"] = BidderThrottling{MaxQPS: -1}
+	assertOneError(t, cfg.validate(), "account_defaults.bidder_throttling.appnexus.max_qps must be >= 0. Got -1.000000")
+}
+
+func TestInvalidAccountBidAdjustments(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.Adapters["whitelabel"] = Adapter{AliasOf: "appnexus", Endpoint: "http://whitelabel.example.com"}
+	cfg.AccountDefaults.BidAdjustments = &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment{
+			"banner": {"whitelabel": {"*": {{AdjType: "cpm", Value: 0.1}}}},
+		},
+	}
+	assert.Empty(t, cfg.validate(), "The host's aliases should be allowed")
+
+	cfg.AccountDefaults.BidAdjustments.MediaType["banner"]["whitelabel"]["*"] = []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: "multiplier", Value: -1}}
+	assertOneError(t, cfg.validate(), "account_defaults.bid_adjustments.mediatype.banner.whitelabel.*[0].value must be a positive number for adjtype multiplier. Got -1.000000")
+}
+
+func TestInvalidAdapterThrottling(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	appnexus := cfg.Adapters[string(openrtb_ext.BidderAppnexus)]
+	appnexus.Throttling = BidderThrottling{Sampling: []TrafficSampling{{Percent: 120}}}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.throttling.sampling[0].percent must be in the range [0, 100]. Got 120.000000")
+}
+
+func TestInvalidAdapterTLS(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	appnexus := cfg.Adapters[string(openrtb_ext.BidderAppnexus)]
+	appnexus.TLS = AdapterTLS{CertFile: "client.pem"}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.tls.cert_file and adapters.appnexus.tls.key_file must be set together")
+
+	appnexus.TLS = AdapterTLS{MinVersion: "1.4"}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.tls.min_version must be one of 1.0, 1.1, 1.2 or 1.3. Got 1.4")
+
+	appnexus.TLS = AdapterTLS{}
+	appnexus.HTTPClient = HTTPClient{MaxIdleConns: -1}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.http_client settings must be >= 0")
+}
+
+func TestAdapterAliasTLSDefaults(t *testing.T) {
+	v := viper.New()
+	SetupViper(v, "")
+	v.SetConfigType("yaml")
+	v.ReadConfig(bytes.NewBuffer([]byte(`
+adapters:
+  appnexus:
+    http2: true
+    tls:
+      min_version: "1.2"
+    http_client:
+      max_idle_connections: 10
+  whitelabel:
+    alias_of: appnexus
+  customlabel:
+    alias_of: appnexus
+    endpoint: http://whitelabel.example.com/openrtb2
+`)))
+	cfg, err := New(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "1.2", cfg.Adapters["whitelabel"].TLS.MinVersion, "An alias using the core bidder's endpoint should use its TLS settings")
+	assert.Empty(t, cfg.Adapters["customlabel"].TLS.MinVersion, "An alias with its own endpoint should have its own TLS settings")
+	assert.True(t, cfg.Adapters["whitelabel"].HTTP2, "An alias using the core bidder's endpoint should use HTTP/2 like it")
+	assert.False(t, cfg.Adapters["customlabel"].HTTP2, "An alias with its own endpoint should have its own HTTP/2 setting")
+	assert.Equal(t, 10, cfg.Adapters["customlabel"].HTTPClient.MaxIdleConns)
+}
+
+func TestRegionalEndpoints(t *testing.T) {
+	v := viper.New()
+	SetupViper(v, "")
+	v.SetConfigType("yaml")
+	v.ReadConfig(bytes.NewBuffer([]byte(`
+regions:
+  datacenter: us
+  countries:
+    eu: [DEU, fra]
+    apac: [JPN]
+adapters:
+  appnexus:
+    endpoint: http://{{.Region}}.appnexus.com/openrtb2
+    endpoints:
+      apac: http://asia.appnexus.com/openrtb2
+  rubicon:
+    endpoints:
+      eu: http://{{.Region}}.rubicon.com/openrtb2?host={{.Host}}
+  whitelabel:
+    alias_of: appnexus
+`)))
+	cfg, err := New(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"apac", "eu", "us"}, cfg.Regions.Names())
+
+	appnexus := cfg.Adapters[string(openrtb_ext.BidderAppnexus)]
+	assert.Equal(t, "http://us.appnexus.com/openrtb2", appnexus.Endpoint, "The default endpoint should be the datacenter's")
+	assert.Equal(t, map[string]string{
+		"apac": "http://asia.appnexus.com/openrtb2",
+		"eu":   "http://eu.appnexus.com/openrtb2",
+		"us":   "http://us.appnexus.com/openrtb2",
+	}, appnexus.Endpoints)
+	assert.Equal(t, map[string]string{"eu": "http://eu.rubicon.com/openrtb2?host={{.Host}}"}, cfg.Adapters[string(openrtb_ext.BidderRubicon)].Endpoints)
+	assert.Equal(t, appnexus.Endpoints, cfg.Adapters["whitelabel"].Endpoints, "An alias using the core bidder's endpoint should use its regional endpoints")
+}
+
+func TestInvalidRegions(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	appnexus := cfg.Adapters[string(openrtb_ext.BidderAppnexus)]
+	appnexus.Endpoint = "http://{{.Region}}.appnexus.com/openrtb2"
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.endpoint uses the {{.Region}} macro, so regions.datacenter must be set")
+
+	appnexus.Endpoint = "http://appnexus.com/openrtb2"
+	appnexus.Endpoints = map[string]string{"eu": "http://eu.appnexus.com/openrtb2"}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.endpoints.eu isn't the datacenter or one of the regions.countries")
+
+	cfg.Regions = Regions{Countries: map[string][]string{"eu": {"DEU"}, "emea": {"deu"}}}
+	assertOneError(t, cfg.validate(), "regions.countries: DEU is in both emea and eu")
+
+	cfg.Regions = Regions{Datacenter: "eu"}
+	appnexus.Endpoints = map[string]string{"eu": "eu.appnexus.com"}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "The endpoint: eu.appnexus.com for appnexus in region eu is not a valid URL")
+}
+
+func TestWarmupURLs(t *testing.T) {
+	adapter := Adapter{
+		Endpoint:  "https://ib.adnxs.com/openrtb2?key=1",
+		Endpoints: map[string]string{"eu": "https://eu.adnxs.com/openrtb2", "us": "https://ib.adnxs.com/openrtb2"},
+	}
+	assert.Equal(t, []string{"https://eu.adnxs.com/", "https://ib.adnxs.com/"}, adapter.WarmupURLs())
+
+	adapter.Warmup.URL = "https://ib.adnxs.com/status"
+	assert.Equal(t, []string{"https://ib.adnxs.com/status"}, adapter.WarmupURLs())
+}
+
+func TestInvalidAdapterWarmup(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	appnexus := cfg.Adapters[string(openrtb_ext.BidderAppnexus)]
+	appnexus.Warmup = AdapterWarmup{Enabled: true, IntervalSeconds: -1}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.warmup settings must be >= 0")
+
+	appnexus.Endpoint = "http://{{.Host}}/openrtb2"
+	appnexus.Warmup = AdapterWarmup{Enabled: true}
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assertOneError(t, cfg.validate(), "adapters.appnexus.warmup.url must be set, since the host of http://{{.Host}}/ uses macros")
+
+	appnexus.Warmup.URL = "http://ib.adnxs.com/status"
+	cfg.Adapters[string(openrtb_ext.BidderAppnexus)] = appnexus
+	assert.Empty(t, cfg.validate())
+}
+
 func TestNegativeVendorID(t *testing.T) {
 	cfg := newDefaultConfig(t)
 	cfg.GDPR.HostVendorID = -1
@@ -645,6 +924,65 @@ func TestValidateDebug(t *testing.T) {
 	assert.NotNil(t, err, "cfg.debug.timeout_notification.sampling_rate should not be allowed to be greater than 1.0, but it was allowed")
 }
 
+func TestInvalidBlockedCreativesMode(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.BidValidations.BlockedCreatives = "ignore"
+	assertOneError(t, cfg.validate(), `account_defaults.bid_validations.blocked_creatives must be one of [enforce, warn, skip]. Got "ignore"`)
+}
+
+func TestInvalidBannerCreativeSizeMode(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.BidValidations.BannerCreativeSize = "strict"
+	assertOneError(t, cfg.validate(), `account_defaults.bid_validations.banner_creative_size must be one of [enforce, warn, skip]. Got "strict"`)
+}
+
+func TestInvalidSecureMarkupMode(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.BidValidations.SecureMarkup = "on"
+	assertOneError(t, cfg.validate(), `account_defaults.bid_validations.secure_markup must be one of [enforce, warn, skip]. Got "on"`)
+}
+
+func TestInvalidPrivateDealsMode(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.BidValidations.PrivateDeals = "strict"
+	assertOneError(t, cfg.validate(), `account_defaults.bid_validations.private_deals must be one of [enforce, warn, skip]. Got "strict"`)
+}
+
+func TestInvalidDefaultCurrency(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.DefaultCurrency = "EURO"
+	assertOneError(t, cfg.validate(), `account_defaults.default_currency must be an ISO 4217 currency code. Got "EURO"`)
+}
+
+func TestInvalidMediaTypePriceGranularity(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.MediaTypePriceGranularity = &openrtb_ext.MediaTypePriceGranularity{
+		Video: &openrtb_ext.PriceGranularity{
+			Precision: 2,
+			Ranges:    []openrtb_ext.GranularityRange{{Max: 20, Increment: 0}},
+		},
+	}
+	assertOneError(t, cfg.validate(), "account_defaults.media_type_price_granularity.video.ranges[0].increment must be a positive number. Got 0.000000")
+}
+
+func TestInvalidTargetingCustomKey(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.Targeting.CustomKeys = []openrtb_ext.ExtRequestTargetingCustomKey{{Value: "{{.CrID}}"}}
+	assertOneError(t, cfg.validate(), "account_defaults.targeting.custom_keys[0].key must not be empty")
+}
+
+func TestInvalidTargetingCustomKeyValue(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.Targeting.CustomKeys = []openrtb_ext.ExtRequestTargetingCustomKey{{Key: "hb_crid", Value: "{{.Unknown}}"}}
+	assertOneError(t, cfg.validate(), "account_defaults.targeting.custom_keys[0].value is invalid: unknown placeholder {{.Unknown}}")
+}
+
+func TestInvalidAdServerMaxKeyLength(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.AccountDefaults.Targeting.AdServerMaxKeyLength = map[string]int{"gam": 0}
+	assertOneError(t, cfg.validate(), "account_defaults.targeting.ad_server_max_key_length.gam must be a positive number. Got 0")
+}
+
 func TestValidateAccountsConfigRestrictions(t *testing.T) {
 	cfg := newDefaultConfig(t)
 	cfg.Accounts.Files.Enabled = true
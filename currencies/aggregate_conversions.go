@@ -0,0 +1,29 @@
+package currencies
+
+// AggregateConversions looks up rates in the custom rates first, and falls back on the server rates
+// when the custom ones can't convert between the two currencies.
+type AggregateConversions struct {
+	customRates Conversions
+	serverRates Conversions
+}
+
+// NewAggregateConversions creates a new AggregateConversions from custom rates and the server's rates
+func NewAggregateConversions(customRates Conversions, serverRates Conversions) *AggregateConversions {
+	return &AggregateConversions{
+		customRates: customRates,
+		serverRates: serverRates,
+	}
+}
+
+// GetRate returns the conversion rate between two currencies, preferring the custom rates
+func (ac *AggregateConversions) GetRate(from string, to string) (float64, error) {
+	if rate, err := ac.customRates.GetRate(from, to); err == nil {
+		return rate, nil
+	}
+	return ac.serverRates.GetRate(from, to)
+}
+
+// GetRates returns the custom rates
+func (ac *AggregateConversions) GetRates() *map[string]map[string]float64 {
+	return ac.customRates.GetRates()
+}
@@ -0,0 +1,54 @@
+package currencies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prebid/prebid-server/currencies"
+)
+
+func TestAggregateGetRate(t *testing.T) {
+
+	// Setup:
+	customRates := currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"USD": {
+			"PLN": 4.5,
+		},
+	})
+	serverRates := currencies.NewRates(time.Now(), map[string]map[string]float64{
+		"USD": {
+			"PLN": 4,
+			"GBP": 0.8,
+		},
+	})
+	aggregate := currencies.NewAggregateConversions(customRates, serverRates)
+
+	testCases := []struct {
+		from         string
+		to           string
+		expectedRate float64
+		hasError     bool
+		description  string
+	}{
+		{from: "USD", to: "PLN", expectedRate: 4.5, description: "case 1 - Custom rates are used first"},
+		{from: "USD", to: "GBP", expectedRate: 0.8, description: "case 2 - Server rates are used when custom rates can't convert"},
+		{from: "USD", to: "SEK", hasError: true, description: "case 3 - Neither can convert"},
+		{from: "foo", to: "PLN", hasError: true, description: "case 4 - Invalid currency"},
+	}
+
+	for _, tc := range testCases {
+		// Execute:
+		rate, err := aggregate.GetRate(tc.from, tc.to)
+
+		// Verify:
+		if tc.hasError {
+			assert.NotNil(t, err, "err shouldn't be nil: "+tc.description)
+			assert.Equal(t, float64(0), rate, "rate should be 0: "+tc.description)
+		} else {
+			assert.Nil(t, err, "err should be nil: "+tc.description)
+			assert.Equal(t, tc.expectedRate, rate, "rate doesn't match the expected one: "+tc.description)
+		}
+	}
+}
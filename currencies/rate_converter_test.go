@@ -168,17 +168,14 @@ func TestRateStaleness(t *testing.T) {
 
 	defer mockedHttpServer.Close()
 
-	expectedRates := &Rates{
-		DataAsOf: time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC),
-		Conversions: map[string]map[string]float64{
-			"USD": {
-				"GBP": 0.77208,
-			},
-			"GBP": {
-				"USD": 1.2952,
-			},
+	expectedRates := NewRates(time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC), map[string]map[string]float64{
+		"USD": {
+			"GBP": 0.77208,
 		},
-	}
+		"GBP": {
+			"USD": 1.2952,
+		},
+	})
 
 	initialFakeTime := time.Date(2018, time.September, 12, 30, 0, 0, 0, time.UTC)
 	fakeTime := &FakeTime{time: initialFakeTime}
@@ -256,17 +253,14 @@ func TestRatesAreNeverConsideredStale(t *testing.T) {
 
 	defer mockedHttpServer.Close()
 
-	expectedRates := &Rates{
-		DataAsOf: time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC),
-		Conversions: map[string]map[string]float64{
-			"USD": {
-				"GBP": 0.77208,
-			},
-			"GBP": {
-				"USD": 1.2952,
-			},
+	expectedRates := NewRates(time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC), map[string]map[string]float64{
+		"USD": {
+			"GBP": 0.77208,
 		},
-	}
+		"GBP": {
+			"USD": 1.2952,
+		},
+	})
 
 	initialFakeTime := time.Date(2018, time.September, 12, 30, 0, 0, 0, time.UTC)
 	fakeTime := &FakeTime{time: initialFakeTime}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"golang.org/x/text/currency"
@@ -15,6 +16,8 @@ import (
 type Rates struct {
 	DataAsOf    time.Time                     `json:"dataAsOf"`
 	Conversions map[string]map[string]float64 `json:"conversions"`
+	// pivots are the currencies of the Conversions, sorted. They're the candidates for the intermediate rates.
+	pivots []string
 }
 
 // NewRates creates a new Rates object holding currencies rates
@@ -22,6 +25,7 @@ func NewRates(dataAsOf time.Time, conversions map[string]map[string]float64) *Ra
 	return &Rates{
 		DataAsOf:    dataAsOf,
 		Conversions: conversions,
+		pivots:      findPivots(conversions),
 	}
 }
 
@@ -36,6 +40,7 @@ func (r *Rates) UnmarshalJSON(b []byte) error {
 	}
 
 	r.Conversions = c.Conversions
+	r.pivots = findPivots(c.Conversions)
 
 	layout := "2006-01-02"
 	if date, err := time.Parse(layout, c.DataAsOf); err == nil {
@@ -61,18 +66,67 @@ func (r *Rates) GetRate(from string, to string) (float64, error) {
 		return 1, nil
 	}
 	if r.Conversions != nil {
-		if conversion, present := r.Conversions[fromUnit.String()][toUnit.String()]; present {
-			// In case we have an entry FROM -> TO
+		if conversion, present := r.getDirectRate(fromUnit.String(), toUnit.String()); present {
+			return conversion, err
+		}
+		if conversion, present := r.getIntermediateRate(fromUnit.String(), toUnit.String()); present {
 			return conversion, err
-		} else if conversion, present := r.Conversions[toUnit.String()][fromUnit.String()]; present {
-			// In case we have an entry TO -> FROM
-			return 1 / conversion, err
 		}
 		return 0, fmt.Errorf("Currency conversion rate not found: '%s' => '%s'", fromUnit.String(), toUnit.String())
 	}
 	return 0, errors.New("rates are nil")
 }
 
+// getDirectRate returns the rate from an entry FROM -> TO, or the inverse of an entry TO -> FROM
+func (r *Rates) getDirectRate(from string, to string) (float64, bool) {
+	if conversion, present := r.Conversions[from][to]; present {
+		return conversion, true
+	}
+	if conversion, present := r.Conversions[to][from]; present && conversion != 0 {
+		return 1 / conversion, true
+	}
+	return 0, false
+}
+
+// getIntermediateRate converts through a third currency which has rates with both FROM and TO.
+// Candidates are tried in alphabetical order so the result doesn't depend on map iteration.
+func (r *Rates) getIntermediateRate(from string, to string) (float64, bool) {
+	pivots := r.pivots
+	if pivots == nil {
+		// The Rates weren't built by NewRates or UnmarshalJSON
+		pivots = findPivots(r.Conversions)
+	}
+
+	for _, intermediate := range pivots {
+		if intermediate == from || intermediate == to {
+			continue
+		}
+		if first, present := r.getDirectRate(from, intermediate); present {
+			if second, present := r.getDirectRate(intermediate, to); present {
+				return first * second, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// findPivots returns the currencies found in the conversions, sorted
+func findPivots(conversions map[string]map[string]float64) []string {
+	codes := make(map[string]struct{})
+	for base, quotes := range conversions {
+		codes[base] = struct{}{}
+		for quote := range quotes {
+			codes[quote] = struct{}{}
+		}
+	}
+	pivots := make([]string, 0, len(codes))
+	for code := range codes {
+		pivots = append(pivots, code)
+	}
+	sort.Strings(pivots)
+	return pivots
+}
+
 // GetRates returns current rates
 func (r *Rates) GetRates() *map[string]map[string]float64 {
 	return &r.Conversions
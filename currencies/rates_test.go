@@ -30,17 +30,14 @@ func TestUnMarshallRates(t *testing.T) {
 					}
 				}
 			}`,
-			expectedRates: currencies.Rates{
-				DataAsOf: time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC),
-				Conversions: map[string]map[string]float64{
-					"USD": {
-						"GBP": 0.7662523901,
-					},
-					"GBP": {
-						"USD": 1.3050530256,
-					},
+			expectedRates: *currencies.NewRates(time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC), map[string]map[string]float64{
+				"USD": {
+					"GBP": 0.7662523901,
+				},
+				"GBP": {
+					"USD": 1.3050530256,
 				},
-			},
+			}),
 			expectsError: false,
 		},
 		{
@@ -55,17 +52,14 @@ func TestUnMarshallRates(t *testing.T) {
 					}
 				}
 			}`,
-			expectedRates: currencies.Rates{
-				DataAsOf: time.Time{},
-				Conversions: map[string]map[string]float64{
-					"USD": {
-						"GBP": 0.7662523901,
-					},
-					"GBP": {
-						"USD": 1.3050530256,
-					},
+			expectedRates: *currencies.NewRates(time.Time{}, map[string]map[string]float64{
+				"USD": {
+					"GBP": 0.7662523901,
+				},
+				"GBP": {
+					"USD": 1.3050530256,
 				},
-			},
+			}),
 			expectsError: false,
 		},
 		{
@@ -80,17 +74,14 @@ func TestUnMarshallRates(t *testing.T) {
 					}
 				}
 			}`,
-			expectedRates: currencies.Rates{
-				DataAsOf: time.Time{},
-				Conversions: map[string]map[string]float64{
-					"USD": {
-						"GBP": 0.7662523901,
-					},
-					"GBP": {
-						"USD": 1.3050530256,
-					},
+			expectedRates: *currencies.NewRates(time.Time{}, map[string]map[string]float64{
+				"USD": {
+					"GBP": 0.7662523901,
+				},
+				"GBP": {
+					"USD": 1.3050530256,
 				},
-			},
+			}),
 			expectsError: false,
 		},
 		{
@@ -218,6 +209,73 @@ func TestGetRate_ReverseConversion(t *testing.T) {
 	}
 }
 
+func TestGetRate_IntermediateConversion(t *testing.T) {
+
+	// Setup:
+	rates := currencies.NewRates(time.Now(), map[string]map[string]float64{
+		"USD": {
+			"PLN": 4,
+			"GBP": 0.8,
+		},
+		"EUR": {
+			"USD": 1.25,
+			"SEK": 10,
+		},
+	})
+
+	testCases := []struct {
+		from         string
+		to           string
+		expectedRate float64
+		hasError     bool
+		description  string
+	}{
+		{
+			from:         "PLN",
+			to:           "GBP",
+			expectedRate: 0.2,
+			description:  "case 1 - Both currencies have a rate with USD",
+		},
+		{
+			from:         "EUR",
+			to:           "PLN",
+			expectedRate: 5,
+			description:  "case 2 - Direct entry EUR -> USD and USD -> PLN",
+		},
+		{
+			from:         "SEK",
+			to:           "USD",
+			expectedRate: 0.125,
+			description:  "case 3 - Reverse entry EUR -> SEK and direct entry EUR -> USD",
+		},
+		{
+			from:        "SEK",
+			to:          "PLN",
+			hasError:    true,
+			description: "case 4 - Conversions needing two intermediate currencies aren't supported",
+		},
+	}
+
+	// Rates built without NewRates find their intermediate currencies on the fly
+	literalRates := &currencies.Rates{DataAsOf: rates.DataAsOf, Conversions: rates.Conversions}
+
+	for _, tc := range testCases {
+		for _, r := range []*currencies.Rates{rates, literalRates} {
+			// Execute:
+			rate, err := r.GetRate(tc.from, tc.to)
+
+			// Verify:
+			if tc.hasError {
+				assert.NotNil(t, err, "err shouldn't be nil: "+tc.description)
+				assert.Equal(t, float64(0), rate, "rate should be 0: "+tc.description)
+			} else {
+				assert.Nil(t, err, "err should be nil: "+tc.description)
+				assert.InDelta(t, tc.expectedRate, rate, 0.000001, "rate doesn't match the expected one: "+tc.description)
+			}
+		}
+	}
+}
+
 func TestGetRate_EmptyRates(t *testing.T) {
 
 	// Setup:
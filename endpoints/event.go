@@ -0,0 +1,45 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/julienschmidt/httprouter"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/exchange"
+)
+
+// NewEventEndpoint implements the /event endpoint, which the clients call in place of the burl of the
+// winning bids when the account lets PBS send the billing notices.
+//
+// The event URLs are put in bid.ext.prebid.events by the auction. They look like:
+//
+//   /event?t=billing&a={account}&aid={auction ID}&bidder={bidder}&b={bid ID}
+func NewEventEndpoint(notifier exchange.BillingNotifier) httprouter.Handle {
+	return httprouter.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		query := r.URL.Query()
+		if eventType := query.Get("t"); eventType != string(adapters.NoticeBilling) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Unsupported event type: %q", eventType)
+			return
+		}
+		accountID, auctionID, bidder, bidID := query.Get("a"), query.Get("aid"), query.Get("bidder"), query.Get("b")
+		if auctionID == "" || bidder == "" || bidID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("The aid, bidder and b query params are required"))
+			return
+		}
+
+		if err := notifier.NotifyBilling(accountID, auctionID, bidder, bidID); err != nil {
+			if err == exchange.ErrBillingNoticeNotFound {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				glog.Errorf("/event failed to send the billing notice of bid %s from %s: %v", bidID, bidder, err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
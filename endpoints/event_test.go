@@ -0,0 +1,84 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBillingNotifier struct {
+	pending map[string]bool
+	sent    []string
+}
+
+func (n *mockBillingNotifier) NotifyBilling(accountID string, auctionID string, bidder string, bidID string) error {
+	key := accountID + "|" + auctionID + "|" + bidder + "|" + bidID
+	if key == "acct|auction|broken|bid" {
+		return errors.New("broken")
+	}
+	if !n.pending[key] {
+		return exchange.ErrBillingNoticeNotFound
+	}
+	delete(n.pending, key)
+	n.sent = append(n.sent, key)
+	return nil
+}
+
+func TestEventEndpoint(t *testing.T) {
+	testCases := []struct {
+		description    string
+		url            string
+		expectedStatus int
+		expectedSent   []string
+	}{
+		{
+			description:    "Billing events send the pending notice",
+			url:            "/event?t=billing&a=acct&aid=auction&bidder=appnexus&b=bid1",
+			expectedStatus: http.StatusNoContent,
+			expectedSent:   []string{"acct|auction|appnexus|bid1"},
+		},
+		{
+			description:    "Unknown bids are not found",
+			url:            "/event?t=billing&a=acct&aid=auction&bidder=appnexus&b=bid2",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:    "Bids from other auctions are not found",
+			url:            "/event?t=billing&a=acct&aid=other&bidder=appnexus&b=bid1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:    "Other event types are rejected",
+			url:            "/event?t=win&a=acct&aid=auction&bidder=appnexus&b=bid1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "The bid ID is required",
+			url:            "/event?t=billing&a=acct&aid=auction&bidder=appnexus",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "The auction ID is required",
+			url:            "/event?t=billing&a=acct&bidder=appnexus&b=bid1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "Notifier errors are reported",
+			url:            "/event?t=billing&a=acct&aid=auction&bidder=broken&b=bid",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		notifier := &mockBillingNotifier{pending: map[string]bool{"acct|auction|appnexus|bid1": true}}
+		res := httptest.NewRecorder()
+		NewEventEndpoint(notifier)(res, httptest.NewRequest("GET", test.url, nil), nil)
+
+		assert.Equal(t, test.expectedStatus, res.Code, test.description)
+		assert.Equal(t, test.expectedSent, notifier.sent, test.description)
+	}
+}
@@ -0,0 +1,314 @@
+package openrtb2
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// podOptimizerMaxNodes bounds the search for the best combination of bids in a single pod.
+// Pods are small in practice, but this keeps a pathological response from stalling the request.
+const podOptimizerMaxNodes = 100000
+
+// podCandidate is a bid which is eligible to fill part of an ad pod.
+type podCandidate struct {
+	seatIndex    int
+	bidIndex     int
+	impID        string
+	impIndex     int
+	price        float64
+	duration     int
+	dealPriority int
+	// exclusionKeys are the categories and advertiser domains of the bid. With competitive exclusion
+	// enabled, no two bids in the same pod may share one.
+	exclusionKeys []string
+}
+
+// podServable reports whether a bid can actually be delivered by the endpoint building the response.
+type podServable func(seat string, bid *openrtb.Bid, bidExt *openrtb_ext.ExtBid) bool
+
+// optimizeAdPods replaces the bids in bidResponse with the combination of bids which maximizes the total
+// price of each pod without exceeding its duration. Deal bids are placed first, in order of deal priority,
+// and the remaining time is filled with the most valuable open market bids.
+//
+// It returns the number of seconds left unfilled in each pod, keyed by pod ID.
+func optimizeAdPods(videoReq *openrtb_ext.BidRequestVideo, bidReq *openrtb.BidRequest, bidResponse *openrtb.BidResponse, servable podServable) (map[int]int, error) {
+	candidatesByPod, err := getPodCandidates(videoReq, bidReq, bidResponse, servable)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[int]map[int]bool, len(bidResponse.SeatBid))
+	unfilled := make(map[int]int, len(videoReq.PodConfig.Pods))
+	for _, pod := range videoReq.PodConfig.Pods {
+		podBids, remaining := selectPodBids(candidatesByPod[pod.PodId], pod.AdPodDurationSec, videoReq.PodConfig.CompetitiveExclusion)
+		for _, c := range podBids {
+			if selected[c.seatIndex] == nil {
+				selected[c.seatIndex] = make(map[int]bool)
+			}
+			selected[c.seatIndex][c.bidIndex] = true
+		}
+		unfilled[pod.PodId] = remaining
+	}
+
+	seatBids := make([]openrtb.SeatBid, 0, len(bidResponse.SeatBid))
+	for seatIndex, seatBid := range bidResponse.SeatBid {
+		bids := make([]openrtb.Bid, 0, len(selected[seatIndex]))
+		for bidIndex, bid := range seatBid.Bid {
+			if selected[seatIndex][bidIndex] {
+				bids = append(bids, bid)
+			}
+		}
+		if len(bids) > 0 {
+			seatBid.Bid = bids
+			seatBids = append(seatBids, seatBid)
+		}
+	}
+	bidResponse.SeatBid = seatBids
+
+	return unfilled, nil
+}
+
+func getPodCandidates(videoReq *openrtb_ext.BidRequestVideo, bidReq *openrtb.BidRequest, bidResponse *openrtb.BidResponse, servable podServable) (map[int][]podCandidate, error) {
+	minDuration, maxDuration := minMax(videoReq.PodConfig.DurationRangeSec)
+	exactDurations := make(map[int]bool, len(videoReq.PodConfig.DurationRangeSec))
+	for _, dur := range videoReq.PodConfig.DurationRangeSec {
+		exactDurations[dur] = true
+	}
+
+	impMaxDurations := make(map[string]int, len(bidReq.Imp))
+	for _, imp := range bidReq.Imp {
+		if imp.Video != nil && imp.Video.MaxDuration > 0 {
+			impMaxDurations[imp.ID] = int(imp.Video.MaxDuration)
+		}
+	}
+
+	candidates := make(map[int][]podCandidate)
+	for seatIndex, seatBid := range bidResponse.SeatBid {
+		for bidIndex := range seatBid.Bid {
+			bid := &seatBid.Bid[bidIndex]
+
+			var bidExt openrtb_ext.ExtBid
+			if err := json.Unmarshal(bid.Ext, &bidExt); err != nil {
+				return nil, err
+			}
+			if !servable(seatBid.Seat, bid, &bidExt) {
+				continue
+			}
+
+			podId, impIndex, ok := parsePodImpID(bid.ImpID)
+			if !ok {
+				continue
+			}
+
+			candidate := podCandidate{
+				seatIndex: seatIndex,
+				bidIndex:  bidIndex,
+				impID:     bid.ImpID,
+				impIndex:  impIndex,
+				price:     bid.Price,
+				duration:  maxDuration,
+			}
+			if impMaxDuration, ok := impMaxDurations[bid.ImpID]; ok {
+				candidate.duration = impMaxDuration
+			}
+			if bidExt.Prebid != nil {
+				candidate.dealPriority = bidExt.Prebid.DealPriority
+				if bidExt.Prebid.Video != nil {
+					if bidExt.Prebid.Video.Duration > 0 {
+						candidate.duration = bidExt.Prebid.Video.Duration
+					}
+					if bidExt.Prebid.Video.PrimaryCategory != "" {
+						candidate.exclusionKeys = append(candidate.exclusionKeys, "cat:"+bidExt.Prebid.Video.PrimaryCategory)
+					}
+				}
+			}
+			for _, cat := range bid.Cat {
+				candidate.exclusionKeys = append(candidate.exclusionKeys, "cat:"+cat)
+			}
+			for _, domain := range bid.ADomain {
+				candidate.exclusionKeys = append(candidate.exclusionKeys, "adomain:"+strings.ToLower(domain))
+			}
+
+			if candidate.duration < minDuration || candidate.duration > maxDuration {
+				continue
+			}
+			if videoReq.PodConfig.RequireExactDuration && !exactDurations[candidate.duration] {
+				continue
+			}
+
+			candidates[podId] = append(candidates[podId], candidate)
+		}
+	}
+
+	return candidates, nil
+}
+
+// parsePodImpID splits the "podId_impIndex" IDs generated by createImpressions.
+func parsePodImpID(impID string) (int, int, bool) {
+	parts := strings.SplitN(impID, "_", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	podId, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	impIndex, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return podId, impIndex, true
+}
+
+// podState tracks which imps, exclusion keys and how much time a partial pod has already used.
+type podState struct {
+	remaining            int
+	competitiveExclusion bool
+	usedImps             map[string]bool
+	usedKeys             map[string]int
+}
+
+func (s *podState) fits(c *podCandidate) bool {
+	if c.duration > s.remaining || s.usedImps[c.impID] {
+		return false
+	}
+	if s.competitiveExclusion {
+		for _, key := range c.exclusionKeys {
+			if s.usedKeys[key] > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *podState) add(c *podCandidate) {
+	s.remaining -= c.duration
+	s.usedImps[c.impID] = true
+	for _, key := range c.exclusionKeys {
+		s.usedKeys[key]++
+	}
+}
+
+func (s *podState) remove(c *podCandidate) {
+	s.remaining += c.duration
+	delete(s.usedImps, c.impID)
+	for _, key := range c.exclusionKeys {
+		s.usedKeys[key]--
+	}
+}
+
+// selectPodBids picks the bids for a single pod and returns them in imp order, along with the unfilled seconds.
+func selectPodBids(candidates []podCandidate, podDuration int, competitiveExclusion bool) ([]podCandidate, int) {
+	state := &podState{
+		remaining:            podDuration,
+		competitiveExclusion: competitiveExclusion,
+		usedImps:             make(map[string]bool),
+		usedKeys:             make(map[string]int),
+	}
+	selected := make([]podCandidate, 0, len(candidates))
+
+	// Deals are guaranteed a place ahead of the open market, highest priority first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].dealPriority != candidates[j].dealPriority {
+			return candidates[i].dealPriority > candidates[j].dealPriority
+		}
+		return candidates[i].price > candidates[j].price
+	})
+	openMarket := make([]podCandidate, 0, len(candidates))
+	for i := range candidates {
+		if candidates[i].dealPriority <= 0 {
+			openMarket = append(openMarket, candidates[i])
+			continue
+		}
+		if state.fits(&candidates[i]) {
+			state.add(&candidates[i])
+			selected = append(selected, candidates[i])
+		}
+	}
+
+	search := &podSearch{
+		candidates: openMarket,
+		state:      state,
+		chosen:     make([]int, 0, len(openMarket)),
+	}
+	search.suffixPrice = make([]float64, len(openMarket)+1)
+	for i := len(openMarket) - 1; i >= 0; i-- {
+		search.suffixPrice[i] = search.suffixPrice[i+1] + openMarket[i].price
+	}
+	search.run(0, 0)
+
+	for _, i := range search.best {
+		selected = append(selected, openMarket[i])
+		state.add(&openMarket[i])
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].impIndex < selected[j].impIndex
+	})
+	return selected, state.remaining
+}
+
+// podSearch is a branch and bound search for the most valuable combination of open market bids.
+// Candidates are sorted by descending price, which keeps the bound tight early on.
+type podSearch struct {
+	candidates  []podCandidate
+	suffixPrice []float64
+	state       *podState
+	chosen      []int
+	best        []int
+	bestPrice   float64
+	nodes       int
+}
+
+func (s *podSearch) run(index int, price float64) {
+	s.nodes++
+	if price > s.bestPrice || s.best == nil {
+		s.bestPrice = price
+		s.best = append(s.best[:0], s.chosen...)
+	}
+	if index == len(s.candidates) || s.nodes > podOptimizerMaxNodes {
+		return
+	}
+	if price+s.suffixPrice[index] <= s.bestPrice {
+		return
+	}
+
+	candidate := &s.candidates[index]
+	if s.state.fits(candidate) {
+		s.state.add(candidate)
+		s.chosen = append(s.chosen, index)
+		s.run(index+1, price+candidate.price)
+		s.chosen = s.chosen[:len(s.chosen)-1]
+		s.state.remove(candidate)
+	}
+	s.run(index+1, price)
+}
+
+// setUnfilledDurations reports the unfilled time of each pod, including the ones which got no bids at all.
+func setUnfilledDurations(bidResp *openrtb_ext.BidResponseVideo, unfilled map[int]int) {
+	for _, adPod := range bidResp.AdPods {
+		if remaining, ok := unfilled[int(adPod.PodId)]; ok {
+			adPod.UnfilledDurationSec = remaining
+			delete(unfilled, int(adPod.PodId))
+		}
+	}
+
+	emptyPods := make([]int, 0, len(unfilled))
+	for podId := range unfilled {
+		emptyPods = append(emptyPods, podId)
+	}
+	sort.Ints(emptyPods)
+	for _, podId := range emptyPods {
+		bidResp.AdPods = append(bidResp.AdPods, &openrtb_ext.AdPod{
+			PodId:               int64(podId),
+			Targeting:           []openrtb_ext.VideoTargeting{},
+			UnfilledDurationSec: unfilled[podId],
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package openrtb2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectPodBids(t *testing.T) {
+	testCases := []struct {
+		description          string
+		candidates           []podCandidate
+		podDuration          int
+		competitiveExclusion bool
+		expectedImps         []string
+		expectedUnfilled     int
+	}{
+		{
+			description: "Two short bids are worth more than the single most expensive one",
+			candidates: []podCandidate{
+				{impID: "1_0", impIndex: 0, price: 10, duration: 30},
+				{impID: "1_1", impIndex: 1, price: 7, duration: 15},
+				{impID: "1_2", impIndex: 2, price: 6, duration: 15},
+			},
+			podDuration:      30,
+			expectedImps:     []string{"1_1", "1_2"},
+			expectedUnfilled: 0,
+		},
+		{
+			description: "Only one bid per imp",
+			candidates: []podCandidate{
+				{impID: "1_0", impIndex: 0, price: 7, duration: 15},
+				{impID: "1_0", impIndex: 0, price: 6, duration: 15},
+			},
+			podDuration:      30,
+			expectedImps:     []string{"1_0"},
+			expectedUnfilled: 15,
+		},
+		{
+			description: "Competitive exclusion keeps the best combination without shared categories",
+			candidates: []podCandidate{
+				{impID: "1_0", impIndex: 0, price: 9, duration: 15, exclusionKeys: []string{"cat:IAB1"}},
+				{impID: "1_1", impIndex: 1, price: 8, duration: 15, exclusionKeys: []string{"cat:IAB1"}},
+				{impID: "1_2", impIndex: 2, price: 2, duration: 15, exclusionKeys: []string{"cat:IAB2"}},
+			},
+			podDuration:          30,
+			competitiveExclusion: true,
+			expectedImps:         []string{"1_0", "1_2"},
+			expectedUnfilled:     0,
+		},
+		{
+			description: "Shared categories are allowed without competitive exclusion",
+			candidates: []podCandidate{
+				{impID: "1_0", impIndex: 0, price: 9, duration: 15, exclusionKeys: []string{"cat:IAB1"}},
+				{impID: "1_1", impIndex: 1, price: 8, duration: 15, exclusionKeys: []string{"cat:IAB1"}},
+				{impID: "1_2", impIndex: 2, price: 2, duration: 15, exclusionKeys: []string{"cat:IAB2"}},
+			},
+			podDuration:      30,
+			expectedImps:     []string{"1_0", "1_1"},
+			expectedUnfilled: 0,
+		},
+		{
+			description: "Deals are placed ahead of more valuable open market bids",
+			candidates: []podCandidate{
+				{impID: "1_0", impIndex: 0, price: 20, duration: 30},
+				{impID: "1_1", impIndex: 1, price: 1, duration: 15, dealPriority: 5},
+				{impID: "1_2", impIndex: 2, price: 3, duration: 10},
+			},
+			podDuration:      30,
+			expectedImps:     []string{"1_1", "1_2"},
+			expectedUnfilled: 5,
+		},
+		{
+			description:      "No candidates",
+			candidates:       []podCandidate{},
+			podDuration:      60,
+			expectedImps:     []string{},
+			expectedUnfilled: 60,
+		},
+	}
+
+	for _, test := range testCases {
+		selected, unfilled := selectPodBids(test.candidates, test.podDuration, test.competitiveExclusion)
+
+		imps := make([]string, 0, len(selected))
+		for _, c := range selected {
+			imps = append(imps, c.impID)
+		}
+		assert.Equal(t, test.expectedImps, imps, test.description)
+		assert.Equal(t, test.expectedUnfilled, unfilled, test.description)
+	}
+}
+
+func TestOptimizeAdPods(t *testing.T) {
+	videoReq := &openrtb_ext.BidRequestVideo{
+		PodConfig: openrtb_ext.PodConfig{
+			DurationRangeSec:     []int{15, 30},
+			CompetitiveExclusion: true,
+			Pods: []openrtb_ext.Pod{
+				{PodId: 1, AdPodDurationSec: 30},
+				{PodId: 2, AdPodDurationSec: 30},
+			},
+		},
+	}
+	bidReq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "1_0", Video: &openrtb.Video{MaxDuration: 30}},
+			{ID: "1_1", Video: &openrtb.Video{MaxDuration: 30}},
+			{ID: "2_0", Video: &openrtb.Video{MaxDuration: 30}},
+		},
+	}
+	bidResponse := &openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{
+			{
+				Seat: "appnexus",
+				Bid: []openrtb.Bid{
+					{ID: "a", ImpID: "1_0", Price: 10, ADomain: []string{"ford.com"}, Ext: podBidExt(t, 15, 0)},
+					{ID: "b", ImpID: "1_1", Price: 9, ADomain: []string{"Ford.com"}, Ext: podBidExt(t, 15, 0)},
+					{ID: "c", ImpID: "2_0", Price: 9, Ext: podBidExt(t, 45, 0)},
+				},
+			},
+			{
+				Seat: "rubicon",
+				Bid: []openrtb.Bid{
+					{ID: "d", ImpID: "1_1", Price: 2, ADomain: []string{"toyota.com"}, Ext: podBidExt(t, 15, 0)},
+				},
+			},
+		},
+	}
+
+	unfilled, err := optimizeAdPods(videoReq, bidReq, bidResponse, func(string, *openrtb.Bid, *openrtb_ext.ExtBid) bool { return true })
+	assert.NoError(t, err, "Unexpected error optimizing pods")
+
+	bidIDs := make([]string, 0)
+	for _, seatBid := range bidResponse.SeatBid {
+		for _, bid := range seatBid.Bid {
+			bidIDs = append(bidIDs, bid.ID)
+		}
+	}
+	// "b" competes with "a" for ford.com, and "c" is longer than the longest allowed duration
+	assert.ElementsMatch(t, []string{"a", "d"}, bidIDs, "Incorrect bids kept")
+	assert.Equal(t, map[int]int{1: 0, 2: 30}, unfilled, "Incorrect unfilled durations")
+}
+
+func TestSetUnfilledDurations(t *testing.T) {
+	bidResp := &openrtb_ext.BidResponseVideo{
+		AdPods: []*openrtb_ext.AdPod{{PodId: 1}},
+	}
+
+	setUnfilledDurations(bidResp, map[int]int{1: 15, 3: 30, 2: 60})
+
+	assert.Len(t, bidResp.AdPods, 3, "Pods without bids should be reported")
+	assert.Equal(t, 15, bidResp.AdPods[0].UnfilledDurationSec)
+	assert.Equal(t, int64(2), bidResp.AdPods[1].PodId)
+	assert.Equal(t, 60, bidResp.AdPods[1].UnfilledDurationSec)
+	assert.Equal(t, int64(3), bidResp.AdPods[2].PodId)
+	assert.Equal(t, 30, bidResp.AdPods[2].UnfilledDurationSec)
+}
+
+func podBidExt(t *testing.T, duration int, dealPriority int) json.RawMessage {
+	ext, err := json.Marshal(openrtb_ext.ExtBid{
+		Prebid: &openrtb_ext.ExtBidPrebid{
+			DealPriority: dealPriority,
+			Type:         openrtb_ext.BidTypeVideo,
+			Video:        &openrtb_ext.ExtBidPrebidVideo{Duration: duration},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to marshal bid ext: %v", err)
+	}
+	return ext
+}
@@ -325,7 +325,11 @@ func (deps *endpointDeps) loadRequestJSONForAmp(httpRequest *http.Request) (req
 	}
 
 	// The fetched config becomes the entire OpenRTB request
-	requestJSON := storedRequests[ampID]
+	requestJSON, err := openrtb_ext.ConvertRequestTo25(storedRequests[ampID])
+	if err != nil {
+		errs = []error{err}
+		return
+	}
 	if err := json.Unmarshal(requestJSON, req); err != nil {
 		errs = []error{err}
 		return
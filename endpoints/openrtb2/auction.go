@@ -37,6 +37,7 @@ import (
 	"github.com/prebid/prebid-server/util/httputil"
 	"github.com/prebid/prebid-server/util/iputil"
 	"golang.org/x/net/publicsuffix"
+	"golang.org/x/text/currency"
 )
 
 const storedRequestTimeoutMillis = 50
@@ -235,6 +236,12 @@ func (deps *endpointDeps) parseRequest(httpRequest *http.Request) (req *openrtb.
 		return
 	}
 
+	// The OpenRTB 2.6 fields are carried in their 2.5 ext locations, since the openrtb structs don't have them
+	if requestJson, err = openrtb_ext.ConvertRequestTo25(requestJson); err != nil {
+		errs = []error{&errortypes.BadInput{Message: fmt.Sprintf("Invalid request: %v", err)}}
+		return
+	}
+
 	if err := json.Unmarshal(requestJson, req); err != nil {
 		errs = []error{err}
 		return
@@ -312,9 +319,25 @@ func (deps *endpointDeps) validateRequest(req *openrtb.BidRequest) []error {
 			return []error{err}
 		}
 
+		if err := bidExt.Prebid.BidAdjustments.Validate("request.ext.prebid.bidadjustments", aliases); err != nil {
+			return []error{err}
+		}
+
+		if err := validateCustomRates(bidExt.Prebid.Currency); err != nil {
+			return []error{err}
+		}
+
+		if err := validateTargeting(bidExt.Prebid.Targeting); err != nil {
+			return []error{err}
+		}
+
 		if err := validateSChains(bidExt); err != nil {
 			return []error{err}
 		}
+
+		if err := validateCompetitiveExclusion(bidExt.Prebid.CompetitiveExclusion); err != nil {
+			return []error{err}
+		}
 	}
 
 	if (req.Site == nil && req.App == nil) || (req.Site != nil && req.App != nil) {
@@ -398,11 +421,63 @@ func validateBidAdjustmentFactors(adjustmentFactors map[string]float64, aliases
 	return nil
 }
 
+func validateCustomRates(customRates *openrtb_ext.ExtRequestCurrency) error {
+	if customRates == nil {
+		return nil
+	}
+	for from, quotes := range customRates.ConversionRates {
+		if _, err := currency.ParseISO(from); err != nil {
+			return fmt.Errorf("request.ext.prebid.currency.rates.%s is not a valid ISO 4217 currency code", from)
+		}
+		for to, rate := range quotes {
+			if _, err := currency.ParseISO(to); err != nil {
+				return fmt.Errorf("request.ext.prebid.currency.rates.%s.%s is not a valid ISO 4217 currency code", from, to)
+			}
+			if rate <= 0 {
+				return fmt.Errorf("request.ext.prebid.currency.rates.%s.%s must be a positive number. Got %f", from, to, rate)
+			}
+		}
+	}
+	return nil
+}
+
+func validateTargeting(targeting *openrtb_ext.ExtRequestTargeting) error {
+	if targeting == nil {
+		return nil
+	}
+	if targeting.MaxKeyLength < 0 {
+		return fmt.Errorf("request.ext.prebid.targeting.maxkeylength must be >= 0. Got %d", targeting.MaxKeyLength)
+	}
+	for i, customKey := range targeting.CustomKeys {
+		if customKey.Key == "" {
+			return fmt.Errorf("request.ext.prebid.targeting.customkeys[%d].key must not be empty", i)
+		}
+	}
+	return nil
+}
+
 func validateSChains(req *openrtb_ext.ExtRequest) error {
 	_, err := exchange.BidderToPrebidSChains(req)
 	return err
 }
 
+func validateCompetitiveExclusion(exclusion *openrtb_ext.ExtRequestPrebidCompetitiveExclusion) error {
+	if exclusion == nil {
+		return nil
+	}
+	for i, field := range exclusion.Fields {
+		if field != openrtb_ext.CompetitiveExclusionFieldADomain && field != openrtb_ext.CompetitiveExclusionFieldCat {
+			return fmt.Errorf(`request.ext.prebid.competitiveexclusion.fields[%d] must be "%s" or "%s". Got "%s"`, i, openrtb_ext.CompetitiveExclusionFieldADomain, openrtb_ext.CompetitiveExclusionFieldCat, field)
+		}
+	}
+	switch exclusion.TieBreak {
+	case "", openrtb_ext.CompetitiveExclusionTieBreakPrice, openrtb_ext.CompetitiveExclusionTieBreakImpOrder:
+		return nil
+	default:
+		return fmt.Errorf(`request.ext.prebid.competitiveexclusion.tiebreak must be "%s" or "%s". Got "%s"`, openrtb_ext.CompetitiveExclusionTieBreakPrice, openrtb_ext.CompetitiveExclusionTieBreakImpOrder, exclusion.TieBreak)
+	}
+}
+
 func (deps *endpointDeps) validateImp(imp *openrtb.Imp, aliases map[string]string, index int) []error {
 	if imp.ID == "" {
 		return []error{fmt.Errorf("request.imp[%d] missing required field: \"id\"", index)}
@@ -486,6 +561,12 @@ func fillAndValidateNative(n *openrtb.Native, impIndex int) error {
 	if len(n.Request) == 0 {
 		return fmt.Errorf("request.imp[%d].native missing required property \"request\"", impIndex)
 	}
+	// Native 1.1 requests are converted to 1.2, which is the format used by the bidders and the exchange.
+	// The unmarshalling below reports the requests which can't be parsed.
+	if converted, err := openrtb_ext.ConvertNativeRequestTo12(n.Request); err == nil && converted != n.Request {
+		n.Request = converted
+		n.Ver = openrtb_ext.NativeVersion12
+	}
 	var nativePayload nativeRequests.Request
 	if err := json.Unmarshal(json.RawMessage(n.Request), &nativePayload); err != nil {
 		return err
@@ -0,0 +1,267 @@
+package openrtb2
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/prebid_cache_client"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+const (
+	vastVersion  = "4.0"
+	vastAdSystem = "Prebid Server"
+)
+
+// NewVastEndpoint builds the /openrtb2/vast handler. It accepts the same request as /openrtb2/video,
+// but responds with a single VAST document containing the winning ads of every pod instead of targeting keys.
+func NewVastEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, videoFetcher stored_requests.Fetcher, accounts stored_requests.AccountFetcher, categories stored_requests.CategoryFetcher, cfg *config.Configuration, met pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName, cache prebid_cache_client.Client) (httprouter.Handle, error) {
+	deps, err := newVideoEndpointDeps("NewVastEndpoint", ex, validator, requestsById, videoFetcher, accounts, categories, cfg, met, pbsAnalytics, disabledBidders, defReqJSON, bidderMap, cache)
+	if err != nil {
+		return nil, err
+	}
+	return httprouter.Handle(deps.VastAuctionEndpoint), nil
+}
+
+// VastAuctionEndpoint runs the same pod auction as VideoAuctionEndpoint and writes the result as a VAST 4
+// document, which server-side ad insertion platforms can consume directly.
+func (deps *endpointDeps) VastAuctionEndpoint(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	vo := analytics.VideoObject{
+		Status: http.StatusOK,
+		Errors: make([]error, 0),
+	}
+
+	start := time.Now()
+	labels := pbsmetrics.Labels{
+		Source:        pbsmetrics.DemandUnknown,
+		RType:         pbsmetrics.ReqTypeVideo,
+		PubID:         pbsmetrics.PublisherUnknown,
+		Browser:       getBrowserName(r),
+		CookieFlag:    pbsmetrics.CookieFlagUnknown,
+		RequestStatus: pbsmetrics.RequestStatusOK,
+	}
+
+	debugQuery := r.URL.Query().Get("debug")
+	cacheTTL := int64(3600)
+	if deps.cfg.CacheURL.DefaultTTLs.Video > 0 {
+		cacheTTL = int64(deps.cfg.CacheURL.DefaultTTLs.Video)
+	}
+	debugLog := exchange.DebugLog{
+		Enabled:   strings.EqualFold(debugQuery, "true"),
+		CacheType: prebid_cache_client.TypeXML,
+		TTL:       cacheTTL,
+		Regexp:    deps.debugLogRegexp,
+	}
+
+	defer func() {
+		if len(debugLog.CacheKey) > 0 {
+			err := debugLog.PutDebugLogError(deps.cache, deps.cfg.CacheURL.ExpectedTimeMillis, vo.Errors)
+			if err != nil {
+				vo.Errors = append(vo.Errors, err)
+			}
+		}
+		deps.metricsEngine.RecordRequest(labels)
+		deps.metricsEngine.RecordRequestTime(labels, time.Since(start))
+		deps.analytics.LogVideoObject(&vo)
+	}()
+
+	videoBidReq, bidReq, response, podErrors, ok := deps.holdVideoAuction(w, r, &labels, &vo, &debugLog, start)
+	if !ok {
+		return
+	}
+	for _, podErr := range podErrors {
+		vo.Errors = append(vo.Errors, errors.New(strings.Join(podErr.ErrMsgs, ", ")))
+	}
+
+	if videoBidReq.PodConfig.OptimizePods {
+		if _, err := optimizeAdPods(videoBidReq, bidReq, response, canMakeVastAd); err != nil {
+			handleError(&labels, w, []error{err}, &vo, &debugLog)
+			return
+		}
+	}
+
+	vast, err := buildVastResponse(videoBidReq, bidReq, response)
+	if err != nil {
+		handleError(&labels, w, []error{err}, &vo, &debugLog)
+		return
+	}
+
+	resp, err := xml.Marshal(vast)
+	if err != nil {
+		handleError(&labels, w, []error{err}, &vo, &debugLog)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	w.Write(resp)
+}
+
+// Vast is the root element of the document returned by the /openrtb2/vast endpoint.
+// An empty Ads list is the VAST way of saying "no fill".
+type Vast struct {
+	XMLName xml.Name `xml:"VAST"`
+	Version string   `xml:"version,attr"`
+	Ads     []VastAd `xml:"Ad"`
+}
+
+// VastAd is a single ad within the pod. Exactly one of Wrapper or InnerXML is set.
+type VastAd struct {
+	ID       string       `xml:"id,attr"`
+	Sequence int          `xml:"sequence,attr"`
+	Wrapper  *VastWrapper `xml:"Wrapper,omitempty"`
+	InnerXML string       `xml:",innerxml"`
+}
+
+type VastWrapper struct {
+	AdSystem     string    `xml:"AdSystem"`
+	Impression   vastCData `xml:"Impression"`
+	VASTAdTagURI vastCData `xml:"VASTAdTagURI"`
+}
+
+type vastCData struct {
+	Value string `xml:",cdata"`
+}
+
+// vastDocument is only used to pull the <Ad> contents out of a VAST document returned in bid.adm.
+type vastDocument struct {
+	Ads []struct {
+		InnerXML string `xml:",innerxml"`
+	} `xml:"Ad"`
+}
+
+type podSlot struct {
+	index    int
+	duration int
+	bid      openrtb.Bid
+	bidExt   openrtb_ext.ExtBid
+}
+
+func buildVastResponse(videoReq *openrtb_ext.BidRequestVideo, bidReq *openrtb.BidRequest, bidResponse *openrtb.BidResponse) (*Vast, error) {
+	vast := &Vast{Version: vastVersion}
+
+	impMaxDurations := make(map[string]int, len(bidReq.Imp))
+	for _, imp := range bidReq.Imp {
+		if imp.Video != nil {
+			impMaxDurations[imp.ID] = int(imp.Video.MaxDuration)
+		}
+	}
+
+	// Only the highest bid on each imp can make it into the pod
+	podSlots := make(map[int]map[string]*podSlot)
+	for _, seatBid := range bidResponse.SeatBid {
+		for _, bid := range seatBid.Bid {
+			var bidExt openrtb_ext.ExtBid
+			if err := json.Unmarshal(bid.Ext, &bidExt); err != nil {
+				return nil, err
+			}
+
+			podId, impIndex, ok := parsePodImpID(bid.ImpID)
+			if !ok {
+				continue
+			}
+
+			duration := impMaxDurations[bid.ImpID]
+			if bidExt.Prebid != nil && bidExt.Prebid.Video != nil && bidExt.Prebid.Video.Duration > 0 {
+				duration = bidExt.Prebid.Video.Duration
+			}
+
+			if podSlots[podId] == nil {
+				podSlots[podId] = make(map[string]*podSlot)
+			}
+			if current, ok := podSlots[podId][bid.ImpID]; ok && current.bid.Price >= bid.Price {
+				continue
+			}
+			podSlots[podId][bid.ImpID] = &podSlot{
+				index:    impIndex,
+				duration: duration,
+				bid:      bid,
+				bidExt:   bidExt,
+			}
+		}
+	}
+
+	for _, pod := range videoReq.PodConfig.Pods {
+		slots := make([]*podSlot, 0, len(podSlots[pod.PodId]))
+		for _, slot := range podSlots[pod.PodId] {
+			slots = append(slots, slot)
+		}
+		sort.Slice(slots, func(i, j int) bool {
+			return slots[i].index < slots[j].index
+		})
+
+		// The sequence gives the order of the ads within their pod
+		sequence := 0
+		remainingDuration := pod.AdPodDurationSec
+		for _, slot := range slots {
+			if slot.duration > remainingDuration {
+				continue
+			}
+			ad, ok := makeVastAd(slot)
+			if !ok {
+				continue
+			}
+			remainingDuration -= slot.duration
+			sequence++
+			ad.Sequence = sequence
+			vast.Ads = append(vast.Ads, ad)
+		}
+	}
+
+	return vast, nil
+}
+
+func canMakeVastAd(seat string, bid *openrtb.Bid, bidExt *openrtb_ext.ExtBid) bool {
+	_, ok := makeVastAd(&podSlot{bid: *bid, bidExt: *bidExt})
+	return ok
+}
+
+// makeVastAd wraps the cached creative if the bid was cached, and falls back to the inline adm otherwise.
+func makeVastAd(slot *podSlot) (VastAd, bool) {
+	ad := VastAd{ID: slot.bid.ID}
+
+	// VAST requires an <Impression> in the wrappers. It's left empty if there's nothing to track.
+	impression := vastCData{Value: slot.bid.BURL}
+	if prebid := slot.bidExt.Prebid; impression.Value == "" && prebid != nil && prebid.Events != nil {
+		impression.Value = prebid.Events.Billing
+	}
+
+	if prebid := slot.bidExt.Prebid; prebid != nil && prebid.Cache != nil && prebid.Cache.Bids != nil && prebid.Cache.Bids.Url != "" {
+		ad.Wrapper = &VastWrapper{
+			AdSystem:     vastAdSystem,
+			Impression:   impression,
+			VASTAdTagURI: vastCData{Value: prebid.Cache.Bids.Url},
+		}
+		return ad, true
+	}
+
+	adm := strings.TrimSpace(slot.bid.AdM)
+	if strings.HasPrefix(adm, "http://") || strings.HasPrefix(adm, "https://") {
+		ad.Wrapper = &VastWrapper{
+			AdSystem:     vastAdSystem,
+			Impression:   impression,
+			VASTAdTagURI: vastCData{Value: adm},
+		}
+		return ad, true
+	}
+
+	var doc vastDocument
+	if err := xml.Unmarshal([]byte(adm), &doc); err != nil || len(doc.Ads) == 0 {
+		return ad, false
+	}
+	ad.InnerXML = doc.Ads[0].InnerXML
+	return ad, true
+}
@@ -0,0 +1,171 @@
+package openrtb2
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVastEndpoint(t *testing.T) {
+	ex := &mockExchangeVideo{}
+	reqData, err := ioutil.ReadFile("sample-requests/video/video_valid_sample.json")
+	if err != nil {
+		t.Fatalf("Failed to fetch a valid request: %v", err)
+	}
+	reqBody := string(getRequestPayload(t, reqData))
+	req := httptest.NewRequest("POST", "/openrtb2/vast", strings.NewReader(reqBody))
+	recorder := httptest.NewRecorder()
+
+	deps := mockDeps(t, ex)
+	deps.VastAuctionEndpoint(recorder, req, nil)
+
+	if ex.lastRequest == nil {
+		t.Fatalf("The request never made it into the Exchange.")
+	}
+
+	// The mock exchange returns bids without cache URLs or adm, so nothing can be served
+	assert.Equal(t, 200, recorder.Code, "Expected a no-fill VAST document when none of the bids can be served")
+	vast := &Vast{}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), vast); err != nil {
+		t.Fatalf("Unable to unmarshal response: %v", err)
+	}
+	assert.Len(t, vast.Ads, 0, "No ads expected")
+}
+
+func TestVastEndpointNoBids(t *testing.T) {
+	ex := &mockExchangeVideoNoBids{}
+	reqData, err := ioutil.ReadFile("sample-requests/video/video_valid_sample.json")
+	if err != nil {
+		t.Fatalf("Failed to fetch a valid request: %v", err)
+	}
+	reqBody := string(getRequestPayload(t, reqData))
+	req := httptest.NewRequest("POST", "/openrtb2/vast", strings.NewReader(reqBody))
+	recorder := httptest.NewRecorder()
+
+	deps := mockDepsNoBids(t, ex)
+	deps.VastAuctionEndpoint(recorder, req, nil)
+
+	assert.Equal(t, 200, recorder.Code, "Expected a no-fill VAST document")
+	assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+
+	vast := &Vast{}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), vast); err != nil {
+		t.Fatalf("Unable to unmarshal response: %v", err)
+	}
+	assert.Equal(t, "4.0", vast.Version, "Incorrect VAST version")
+	assert.Len(t, vast.Ads, 0, "No ads expected")
+}
+
+func TestBuildVastResponse(t *testing.T) {
+	videoReq := &openrtb_ext.BidRequestVideo{
+		PodConfig: openrtb_ext.PodConfig{
+			Pods: []openrtb_ext.Pod{
+				{PodId: 1, AdPodDurationSec: 45},
+				{PodId: 2, AdPodDurationSec: 30},
+			},
+		},
+	}
+	bidReq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "1_0", Video: &openrtb.Video{MaxDuration: 30}},
+			{ID: "1_1", Video: &openrtb.Video{MaxDuration: 30}},
+			{ID: "1_2", Video: &openrtb.Video{MaxDuration: 30}},
+			{ID: "2_0", Video: &openrtb.Video{MaxDuration: 30}},
+		},
+	}
+	bidResponse := &openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{
+			{
+				Seat: "appnexus",
+				Bid: []openrtb.Bid{
+					{ID: "a", ImpID: "1_1", Price: 5, Ext: vastBidExt(t, 15, "https://cache.prebid.org/cache?uuid=a")},
+					{ID: "b", ImpID: "1_0", Price: 3, Ext: vastBidExt(t, 20, "https://cache.prebid.org/cache?uuid=b")},
+					{ID: "c", ImpID: "1_2", Price: 9, Ext: vastBidExt(t, 25, "https://cache.prebid.org/cache?uuid=c")},
+					{ID: "d", ImpID: "2_0", Price: 1, AdM: `<VAST version="3.0"><Ad id="x"><InLine><AdSystem>x</AdSystem></InLine></Ad></VAST>`, Ext: vastBidExt(t, 30, "")},
+				},
+			},
+			{
+				Seat: "rubicon",
+				Bid: []openrtb.Bid{
+					{ID: "e", ImpID: "1_0", Price: 4, BURL: "https://billing.rubicon.com", Ext: vastBidExt(t, 10, "https://cache.prebid.org/cache?uuid=e")},
+				},
+			},
+		},
+	}
+
+	vast, err := buildVastResponse(videoReq, bidReq, bidResponse)
+	assert.NoError(t, err, "Unexpected error building VAST")
+
+	ids := make([]string, 0, len(vast.Ads))
+	sequences := make([]int, 0, len(vast.Ads))
+	for _, ad := range vast.Ads {
+		ids = append(ids, ad.ID)
+		sequences = append(sequences, ad.Sequence)
+	}
+	// "b" is outbid by "e"; "c" no longer fits into the 45 seconds of pod 1
+	assert.Equal(t, []string{"e", "a", "d"}, ids, "Incorrect ads in VAST")
+	assert.Equal(t, []int{1, 2, 1}, sequences, "Ads must be sequenced in pod order, starting over in each pod")
+
+	assert.Equal(t, "https://billing.rubicon.com", vast.Ads[0].Wrapper.Impression.Value, "burl should be the wrapper impression")
+	assert.Equal(t, "https://cache.prebid.org/cache?uuid=a", vast.Ads[1].Wrapper.VASTAdTagURI.Value, "Cached bids should be wrapped")
+	assert.Empty(t, vast.Ads[1].Wrapper.Impression.Value, "Wrappers without a burl should have an empty impression")
+	assert.Nil(t, vast.Ads[2].Wrapper, "Uncached bids should be inlined")
+	assert.Equal(t, "<InLine><AdSystem>x</AdSystem></InLine>", vast.Ads[2].InnerXML, "Incorrect inline ad")
+}
+
+func TestMakeVastAdImpression(t *testing.T) {
+	bidExt := openrtb_ext.ExtBid{
+		Prebid: &openrtb_ext.ExtBidPrebid{
+			Cache:  &openrtb_ext.ExtBidPrebidCache{Bids: &openrtb_ext.ExtBidPrebidCacheBids{Url: "https://cache.prebid.org/cache?uuid=a"}},
+			Events: &openrtb_ext.ExtBidPrebidEvents{Billing: "https://prebid-server.com/event?t=billing"},
+		},
+	}
+
+	ad, ok := makeVastAd(&podSlot{bid: openrtb.Bid{ID: "a"}, bidExt: bidExt})
+	if assert.True(t, ok) && assert.NotNil(t, ad.Wrapper) {
+		assert.Equal(t, "https://prebid-server.com/event?t=billing", ad.Wrapper.Impression.Value, "The billing event should be the impression without a burl")
+	}
+
+	ad, ok = makeVastAd(&podSlot{bid: openrtb.Bid{ID: "b", AdM: "https://vast.bidder.com/b.xml"}})
+	if assert.True(t, ok) && assert.NotNil(t, ad.Wrapper) {
+		marshalled, err := xml.Marshal(ad)
+		assert.NoError(t, err)
+		assert.Contains(t, string(marshalled), "<Impression>", "VAST requires an impression in the wrappers")
+	}
+}
+
+func TestBuildVastResponseNoBids(t *testing.T) {
+	videoReq := &openrtb_ext.BidRequestVideo{
+		PodConfig: openrtb_ext.PodConfig{Pods: []openrtb_ext.Pod{{PodId: 1, AdPodDurationSec: 30}}},
+	}
+
+	vast, err := buildVastResponse(videoReq, &openrtb.BidRequest{}, &openrtb.BidResponse{})
+	assert.NoError(t, err, "Unexpected error building VAST")
+	assert.Len(t, vast.Ads, 0, "No ads expected")
+}
+
+func vastBidExt(t *testing.T, duration int, cacheURL string) json.RawMessage {
+	bidExt := openrtb_ext.ExtBid{
+		Prebid: &openrtb_ext.ExtBidPrebid{
+			Type:  openrtb_ext.BidTypeVideo,
+			Video: &openrtb_ext.ExtBidPrebidVideo{Duration: duration},
+		},
+	}
+	if cacheURL != "" {
+		bidExt.Prebid.Cache = &openrtb_ext.ExtBidPrebidCache{
+			Bids: &openrtb_ext.ExtBidPrebidCacheBids{Url: cacheURL},
+		}
+	}
+	ext, err := json.Marshal(bidExt)
+	if err != nil {
+		t.Fatalf("Unable to marshal bid ext: %v", err)
+	}
+	return ext
+}
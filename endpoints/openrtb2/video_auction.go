@@ -37,9 +37,19 @@ import (
 var defaultRequestTimeout int64 = 5000
 
 func NewVideoEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, videoFetcher stored_requests.Fetcher, accounts stored_requests.AccountFetcher, categories stored_requests.CategoryFetcher, cfg *config.Configuration, met pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName, cache prebid_cache_client.Client) (httprouter.Handle, error) {
+	deps, err := newVideoEndpointDeps("NewVideoEndpoint", ex, validator, requestsById, videoFetcher, accounts, categories, cfg, met, pbsAnalytics, disabledBidders, defReqJSON, bidderMap, cache)
+	if err != nil {
+		return nil, err
+	}
+	return httprouter.Handle(deps.VideoAuctionEndpoint), nil
+}
+
+// newVideoEndpointDeps builds the dependencies of the endpoints which run the pod auctions: /openrtb2/video
+// and /openrtb2/vast. The constructor's name is used in the error message.
+func newVideoEndpointDeps(constructor string, ex exchange.Exchange, validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, videoFetcher stored_requests.Fetcher, accounts stored_requests.AccountFetcher, categories stored_requests.CategoryFetcher, cfg *config.Configuration, met pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName, cache prebid_cache_client.Client) (*endpointDeps, error) {
 
 	if ex == nil || validator == nil || requestsById == nil || accounts == nil || cfg == nil || met == nil {
-		return nil, errors.New("NewVideoEndpoint requires non-nil arguments.")
+		return nil, fmt.Errorf("%s requires non-nil arguments.", constructor)
 	}
 
 	defRequest := defReqJSON != nil && len(defReqJSON) > 0
@@ -51,7 +61,7 @@ func NewVideoEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamVal
 
 	videoEndpointRegexp := regexp.MustCompile(`[<>]`)
 
-	return httprouter.Handle((&endpointDeps{
+	return &endpointDeps{
 		ex,
 		validator,
 		requestsById,
@@ -67,7 +77,7 @@ func NewVideoEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamVal
 		bidderMap,
 		cache,
 		videoEndpointRegexp,
-		ipValidator}).VideoAuctionEndpoint), nil
+		ipValidator}, nil
 }
 
 /*
@@ -134,14 +144,76 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 		deps.analytics.LogVideoObject(&vo)
 	}()
 
+	videoBidReq, bidReq, response, podErrors, ok := deps.holdVideoAuction(w, r, &labels, &vo, &debugLog, start)
+	if !ok {
+		return
+	}
+
+	var unfilledDurations map[int]int
+	if videoBidReq.PodConfig.OptimizePods {
+		var err error
+		unfilledDurations, err = optimizeAdPods(videoBidReq, bidReq, response, hasVastCacheKey)
+		if err != nil {
+			handleError(&labels, w, []error{err}, &vo, &debugLog)
+			return
+		}
+	}
+
+	//build simplified response
+	bidResp, err := buildVideoResponse(response, podErrors)
+	if err != nil {
+		errL := []error{err}
+		handleError(&labels, w, errL, &vo, &debugLog)
+		return
+	}
+	if unfilledDurations != nil {
+		setUnfilledDurations(bidResp, unfilledDurations)
+	}
+	if bidReq.Test == 1 {
+		bidResp.Ext = response.Ext
+	}
+
+	if len(bidResp.AdPods) == 0 && debugLog.Enabled {
+		err := debugLog.PutDebugLogError(deps.cache, deps.cfg.CacheURL.ExpectedTimeMillis, vo.Errors)
+		if err != nil {
+			vo.Errors = append(vo.Errors, err)
+		} else {
+			bidResp.AdPods = append(bidResp.AdPods, &openrtb_ext.AdPod{
+				Targeting: []openrtb_ext.VideoTargeting{
+					{
+						HbCacheID: debugLog.CacheKey,
+					},
+				},
+			})
+		}
+	}
+
+	vo.VideoResponse = bidResp
+
+	resp, err := json.Marshal(bidResp)
+	//resp, err := json.Marshal(response)
+	if err != nil {
+		errL := []error{err}
+		handleError(&labels, w, errL, &vo, &debugLog)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+
+}
+
+// holdVideoAuction reads and resolves a video request, expands its pods into impressions and runs the
+// auction. Errors are written to w, in which case the returned flag is false and the caller should stop.
+func (deps *endpointDeps) holdVideoAuction(w http.ResponseWriter, r *http.Request, labels *pbsmetrics.Labels, vo *analytics.VideoObject, debugLog *exchange.DebugLog, start time.Time) (*openrtb_ext.BidRequestVideo, *openrtb.BidRequest, *openrtb.BidResponse, []PodError, bool) {
 	lr := &io.LimitedReader{
 		R: r.Body,
 		N: deps.cfg.MaxRequestSize,
 	}
 	requestJson, err := ioutil.ReadAll(lr)
 	if err != nil {
-		handleError(&labels, w, []error{err}, &vo, &debugLog)
-		return
+		handleError(labels, w, []error{err}, vo, debugLog)
+		return nil, nil, nil, nil, false
 	}
 
 	resolvedRequest := requestJson
@@ -159,28 +231,28 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 
 	if err != nil {
 		if deps.cfg.VideoStoredRequestRequired {
-			handleError(&labels, w, []error{err}, &vo, &debugLog)
-			return
+			handleError(labels, w, []error{err}, vo, debugLog)
+			return nil, nil, nil, nil, false
 		}
 	} else {
 		storedRequest, errs := deps.loadStoredVideoRequest(context.Background(), storedRequestId)
 		if len(errs) > 0 {
-			handleError(&labels, w, errs, &vo, &debugLog)
-			return
+			handleError(labels, w, errs, vo, debugLog)
+			return nil, nil, nil, nil, false
 		}
 
 		//merge incoming req with stored video req
 		resolvedRequest, err = jsonpatch.MergePatch(storedRequest, requestJson)
 		if err != nil {
-			handleError(&labels, w, []error{err}, &vo, &debugLog)
-			return
+			handleError(labels, w, []error{err}, vo, debugLog)
+			return nil, nil, nil, nil, false
 		}
 	}
 	//unmarshal and validate combined result
 	videoBidReq, errL, podErrors := deps.parseVideoRequest(resolvedRequest, r.Header)
 	if len(errL) > 0 {
-		handleError(&labels, w, errL, &vo, &debugLog)
-		return
+		handleError(labels, w, errL, vo, debugLog)
+		return nil, nil, nil, nil, false
 	}
 
 	vo.VideoRequest = videoBidReq
@@ -189,8 +261,8 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 	if deps.defaultRequest {
 		if err := json.Unmarshal(deps.defReqJSON, bidReq); err != nil {
 			err = fmt.Errorf("Invalid JSON in Default Request Settings: %s", err)
-			handleError(&labels, w, []error{err}, &vo, &debugLog)
-			return
+			handleError(labels, w, []error{err}, vo, debugLog)
+			return nil, nil, nil, nil, false
 		}
 	}
 
@@ -217,8 +289,8 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 		}
 		err := errors.New(fmt.Sprintf("all pods are incorrect: %s", strings.Join(resPodErr, "; ")))
 		errL = append(errL, err)
-		handleError(&labels, w, errL, &vo, &debugLog)
-		return
+		handleError(labels, w, errL, vo, debugLog)
+		return nil, nil, nil, nil, false
 	}
 
 	bidReq.Imp = imps
@@ -229,8 +301,8 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 
 	errL = deps.validateRequest(bidReq)
 	if errortypes.ContainsFatalError(errL) {
-		handleError(&labels, w, errL, &vo, &debugLog)
-		return
+		handleError(labels, w, errL, vo, debugLog)
+		return nil, nil, nil, nil, false
 	}
 
 	ctx := context.Background()
@@ -258,58 +330,56 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 	// Look up account now that we have resolved the pubID value
 	account, acctIDErrs := accountService.GetAccount(ctx, deps.cfg, deps.accounts, labels.PubID)
 	if len(acctIDErrs) > 0 {
-		handleError(&labels, w, acctIDErrs, &vo, &debugLog)
-		return
+		handleError(labels, w, acctIDErrs, vo, debugLog)
+		return nil, nil, nil, nil, false
+	}
+	// The pods are built from the standard targeting keys, so neither the account nor the request can rename or drop them
+	if err := useStandardTargetingKeys(account, bidReq); err != nil {
+		handleError(labels, w, []error{err}, vo, debugLog)
+		return nil, nil, nil, nil, false
 	}
 	//execute auction logic
-	response, err := deps.ex.HoldAuction(ctx, bidReq, usersyncs, labels, account, &deps.categories, &debugLog)
+	response, err := deps.ex.HoldAuction(ctx, bidReq, usersyncs, *labels, account, &deps.categories, debugLog)
 	vo.Request = bidReq
 	vo.Response = response
 	if err != nil {
 		errL := []error{err}
-		handleError(&labels, w, errL, &vo, &debugLog)
-		return
+		handleError(labels, w, errL, vo, debugLog)
+		return nil, nil, nil, nil, false
 	}
 
-	//build simplified response
-	bidResp, err := buildVideoResponse(response, podErrors)
-	if err != nil {
-		errL := []error{err}
-		handleError(&labels, w, errL, &vo, &debugLog)
-		return
+	return videoBidReq, bidReq, response, podErrors, true
+}
+
+// useStandardTargetingKeys clears the account's and the request's options which change the names of the standard
+// targeting keys. The custom keys are still added.
+func useStandardTargetingKeys(account *config.Account, bidReq *openrtb.BidRequest) error {
+	account.Targeting.Prefix = ""
+	account.Targeting.IncludeKeys = nil
+	account.Targeting.MaxKeyLength = 0
+	account.Targeting.AdServerMaxKeyLength = nil
+
+	if len(bidReq.Ext) == 0 {
+		return nil
 	}
-	if bidReq.Test == 1 {
-		bidResp.Ext = response.Ext
+	var requestExt openrtb_ext.ExtRequest
+	if err := json.Unmarshal(bidReq.Ext, &requestExt); err != nil {
+		return err
 	}
-
-	if len(bidResp.AdPods) == 0 && debugLog.Enabled {
-		err := debugLog.PutDebugLogError(deps.cache, deps.cfg.CacheURL.ExpectedTimeMillis, vo.Errors)
-		if err != nil {
-			vo.Errors = append(vo.Errors, err)
-		} else {
-			bidResp.AdPods = append(bidResp.AdPods, &openrtb_ext.AdPod{
-				Targeting: []openrtb_ext.VideoTargeting{
-					{
-						HbCacheID: debugLog.CacheKey,
-					},
-				},
-			})
-		}
+	targeting := requestExt.Prebid.Targeting
+	if targeting == nil || (targeting.Prefix == "" && len(targeting.IncludeKeys) == 0 && targeting.MaxKeyLength == 0 && targeting.AdServer == "") {
+		return nil
 	}
-
-	vo.VideoResponse = bidResp
-
-	resp, err := json.Marshal(bidResp)
-	//resp, err := json.Marshal(response)
+	targeting.Prefix = ""
+	targeting.IncludeKeys = nil
+	targeting.MaxKeyLength = 0
+	targeting.AdServer = ""
+	ext, err := json.Marshal(requestExt)
 	if err != nil {
-		errL := []error{err}
-		handleError(&labels, w, errL, &vo, &debugLog)
-		return
+		return err
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(resp)
-
+	bidReq.Ext = ext
+	return nil
 }
 
 func cleanupVideoBidRequest(videoReq *openrtb_ext.BidRequestVideo, podErrors []PodError) *openrtb_ext.BidRequestVideo {
@@ -460,7 +530,7 @@ func buildVideoResponse(bidresponse *openrtb.BidResponse, podErrors []PodError)
 			if err := json.Unmarshal(bid.Ext, &tempRespBidExt); err != nil {
 				return nil, err
 			}
-			if tempRespBidExt.Prebid.Targeting[formatTargetingKey(openrtb_ext.HbVastCacheKey, seatBid.Seat)] == "" {
+			if !hasVastCacheKey(seatBid.Seat, &bid, &tempRespBidExt) {
 				continue
 			}
 
@@ -509,6 +579,11 @@ func buildVideoResponse(bidresponse *openrtb.BidResponse, podErrors []PodError)
 	return &openrtb_ext.BidResponseVideo{AdPods: adPods}, nil
 }
 
+// hasVastCacheKey reports whether the VAST of the bid was cached, which /openrtb2/video needs in order to serve it.
+func hasVastCacheKey(seat string, bid *openrtb.Bid, bidExt *openrtb_ext.ExtBid) bool {
+	return bidExt.Prebid != nil && bidExt.Prebid.Targeting[formatTargetingKey(openrtb_ext.HbVastCacheKey, seat)] != ""
+}
+
 func formatTargetingKey(key openrtb_ext.TargetingKey, bidderName string) string {
 	fullKey := fmt.Sprintf("%s_%s", string(key), bidderName)
 	if len(fullKey) > exchange.MaxKeyLength {
@@ -64,6 +64,60 @@ func TestVideoEndpointImpressionsNumber(t *testing.T) {
 
 }
 
+func TestVideoEndpointAccountTargetingKeys(t *testing.T) {
+	ex := &mockExchangeVideo{}
+	reqData, err := ioutil.ReadFile("sample-requests/video/video_valid_sample.json")
+	if err != nil {
+		t.Fatalf("Failed to fetch a valid request: %v", err)
+	}
+	reqBody := string(getRequestPayload(t, reqData))
+	req := httptest.NewRequest("POST", "/openrtb2/video", strings.NewReader(reqBody))
+	recorder := httptest.NewRecorder()
+
+	deps := mockDeps(t, ex)
+	customKeys := []openrtb_ext.ExtRequestTargetingCustomKey{{Key: "hb_adomain", Value: "{{.ADomain}}"}}
+	deps.cfg.AccountDefaults.Targeting = config.AccountTargeting{
+		Prefix:               "pbs",
+		IncludeKeys:          []string{"hb_pb"},
+		MaxKeyLength:         12,
+		AdServerMaxKeyLength: map[string]int{"gam": 10},
+		CustomKeys:           customKeys,
+	}
+	deps.VideoAuctionEndpoint(recorder, req, nil)
+
+	if ex.lastAccount == nil {
+		t.Fatalf("The request never made it into the Exchange.")
+	}
+	assert.Equal(t, config.AccountTargeting{CustomKeys: customKeys}, ex.lastAccount.Targeting, "Only the account's custom keys should be used")
+	assert.Equal(t, "pbs", deps.cfg.AccountDefaults.Targeting.Prefix, "The account defaults should be left alone")
+
+	resp := &openrtb_ext.BidResponseVideo{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), resp); err != nil {
+		t.Fatalf("Unable to unmarshal response.")
+	}
+	assert.Len(t, resp.AdPods, 5, "The pods should still be read from the standard keys")
+}
+
+func TestUseStandardTargetingKeysOnRequest(t *testing.T) {
+	account := &config.Account{Targeting: config.AccountTargeting{Prefix: "pbs"}}
+	bidReq := &openrtb.BidRequest{Ext: json.RawMessage(`{"prebid":{"targeting":{"prefix":"req","includekeys":["hb_pb"],"adserver":"gam","maxkeylength":10,"includebidderkeys":true,"customkeys":[{"key":"hb_crid","value":"{{.CrID}}"}]}}}`)}
+
+	if err := useStandardTargetingKeys(account, bidReq); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var requestExt openrtb_ext.ExtRequest
+	if err := json.Unmarshal(bidReq.Ext, &requestExt); err != nil {
+		t.Fatalf("Unable to unmarshal the request's ext: %v", err)
+	}
+	assert.Empty(t, account.Targeting.Prefix)
+	assert.Empty(t, requestExt.Prebid.Targeting.Prefix)
+	assert.Empty(t, requestExt.Prebid.Targeting.IncludeKeys)
+	assert.Empty(t, requestExt.Prebid.Targeting.AdServer)
+	assert.Zero(t, requestExt.Prebid.Targeting.MaxKeyLength)
+	assert.True(t, requestExt.Prebid.Targeting.IncludeBidderKeys, "The other options should be kept")
+	assert.Len(t, requestExt.Prebid.Targeting.CustomKeys, 1, "The custom keys should be kept")
+}
+
 func TestVideoEndpointImpressionsDuration(t *testing.T) {
 	ex := &mockExchangeVideo{}
 	reqData, err := ioutil.ReadFile("sample-requests/video/video_valid_sample_different_durations.json")
@@ -1275,11 +1329,13 @@ func (cf mockVideoStoredReqFetcher) FetchRequests(ctx context.Context, requestID
 
 type mockExchangeVideo struct {
 	lastRequest *openrtb.BidRequest
+	lastAccount *config.Account
 	cache       *mockCacheClient
 }
 
 func (m *mockExchangeVideo) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, debugLog *exchange.DebugLog) (*openrtb.BidResponse, error) {
 	m.lastRequest = bidRequest
+	m.lastAccount = account
 	if debugLog != nil && debugLog.Enabled {
 		m.cache.called = true
 	}
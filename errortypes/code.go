@@ -11,12 +11,16 @@ const (
 	BidderTemporarilyDisabledErrorCode
 	BlacklistedAcctErrorCode
 	AcctRequiredErrorCode
+	CircuitBreakerOpenErrorCode
 )
 
 // Defines numeric codes for well-known warnings.
 const (
 	UnknownWarningCode               = 10999
 	InvalidPrivacyConsentWarningCode = iota + 10000
+	BlockedBidWarningCode
+	InvalidCreativeWarningCode
+	BidAdjustmentFailedWarningCode
 )
 
 // Coder provides an error or warning code with severity.
@@ -165,6 +165,63 @@ func (err *BidderTemporarilyDisabled) Severity() Severity {
 	return SeverityWarning
 }
 
+// BlockedBid is a warning for when a bidder returns a bid whose advertiser domain, category or creative
+// attributes were blocked by the request through bcat, badv or battr, or a bid on a private auction imp
+// which isn't for one of the imp's deals. Depending on the account, the bid is dropped from the auction or
+// kept; either way the bidder's other bids aren't affected.
+type BlockedBid struct {
+	Message string
+}
+
+func (err *BlockedBid) Error() string {
+	return err.Message
+}
+
+func (err *BlockedBid) Code() int {
+	return BlockedBidWarningCode
+}
+
+func (err *BlockedBid) Severity() Severity {
+	return SeverityWarning
+}
+
+// InvalidCreative is a warning for when a bidder returns a creative which can't render properly in the imp,
+// for example a banner with the wrong size or insecure markup for a secure imp. Depending on the account, the bid
+// is dropped from the auction or kept; either way the bidder's other bids aren't affected.
+type InvalidCreative struct {
+	Message string
+}
+
+func (err *InvalidCreative) Error() string {
+	return err.Message
+}
+
+func (err *InvalidCreative) Code() int {
+	return InvalidCreativeWarningCode
+}
+
+func (err *InvalidCreative) Severity() Severity {
+	return SeverityWarning
+}
+
+// CircuitBreakerOpen should be used when a request wasn't sent to the bidder because its endpoint has been
+// failing, and the circuit breaker is giving it time to recover.
+type CircuitBreakerOpen struct {
+	Message string
+}
+
+func (err *CircuitBreakerOpen) Error() string {
+	return err.Message
+}
+
+func (err *CircuitBreakerOpen) Code() int {
+	return CircuitBreakerOpenErrorCode
+}
+
+func (err *CircuitBreakerOpen) Severity() Severity {
+	return SeverityFatal
+}
+
 // Warning is a generic non-fatal error.
 type Warning struct {
 	Message string
@@ -198,3 +255,22 @@ func (err *InvalidPrivacyConsent) Code() int {
 func (err *InvalidPrivacyConsent) Severity() Severity {
 	return SeverityWarning
 }
+
+// BidAdjustmentFailed is a warning for when a bid adjustment can't be applied to a bid, for example because
+// there's no conversion rate for its currency. The bid is dropped from the auction, since its price doesn't
+// reflect the adjustment.
+type BidAdjustmentFailed struct {
+	Message string
+}
+
+func (err *BidAdjustmentFailed) Error() string {
+	return err.Message
+}
+
+func (err *BidAdjustmentFailed) Code() int {
+	return BidAdjustmentFailedWarningCode
+}
+
+func (err *BidAdjustmentFailed) Severity() Severity {
+	return SeverityWarning
+}
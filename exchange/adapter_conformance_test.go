@@ -0,0 +1,119 @@
+package exchange
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/adapters/adapterstest"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/spf13/viper"
+)
+
+// conformanceExemptions are the rules which some bidders broke before they were enforced. New bidders must
+// follow them all, and the bidders below should be taken off the list as they're fixed.
+var conformanceExemptions = map[openrtb_ext.BidderName][]adapterstest.ConformanceRule{
+	openrtb_ext.BidderAdhese:           {adapterstest.RuleImpIDsPreserved, adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderAdman:            {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderAdOcean:          {adapterstest.RuleTypedErrors, adapterstest.RuleNoContentNoBids},
+	openrtb_ext.BidderAdpone:           {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderAdprime:          {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderApplogy:          {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderAppnexus:         {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderAvocet:           {adapterstest.RuleImpIDsPreserved},
+	openrtb_ext.BidderBeachfront:       {adapterstest.RuleImpIDsPreserved, adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderBeintoo:          {adapterstest.RuleImpIDsPreserved},
+	openrtb_ext.BidderColossus:         {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderDatablocks:       {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderEmxDigital:       {adapterstest.RuleImpIDsPreserved},
+	openrtb_ext.BidderEPlanning:        {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderGeneric:          {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderGrid:             {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderGumGum:           {adapterstest.RuleImpIDsPreserved},
+	openrtb_ext.BidderImprovedigital:   {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderKidoz:            {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderKubient:          {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderLockerDome:       {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderMgid:             {adapterstest.RuleCurrencySet},
+	openrtb_ext.BidderMobileFuse:       {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderNanoInteractive:  {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderPubmatic:         {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderPubnative:        {adapterstest.RuleImpIDsPreserved},
+	openrtb_ext.BidderRhythmone:        {adapterstest.RuleImpIDsPreserved},
+	openrtb_ext.BidderRTBHouse:         {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderSmaato:           {adapterstest.RuleImpIDsPreserved, adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderSmartadserver:    {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderSmartRTB:         {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderSynacormedia:     {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderTappx:            {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderTelaria:          {adapterstest.RuleImpIDsPreserved, adapterstest.RuleNoContentNoBids},
+	openrtb_ext.BidderTriplelift:       {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderTripleliftNative: {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderUnruly:           {adapterstest.RuleNoContentNoBids},
+	openrtb_ext.BidderVisx:             {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderYeahmobi:         {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderYieldlab:         {adapterstest.RuleNoContentNoBids},
+	openrtb_ext.BidderYieldone:         {adapterstest.RuleTypedErrors},
+	openrtb_ext.BidderZeroClickFraud:   {adapterstest.RuleTypedErrors},
+}
+
+// TestAdapterConformance runs the conformance tests over every Bidder in the adapter map, using the JSON test
+// files of its package.
+func TestAdapterConformance(t *testing.T) {
+	v := viper.New()
+	config.SetupViper(v, "")
+	cfg, err := config.New(v)
+	if err != nil {
+		t.Fatalf("Failed to load the default config: %v", err)
+	}
+
+	// Some bidders have no default endpoint
+	for name, adapterCfg := range cfg.Adapters {
+		if adapterCfg.Endpoint == "" {
+			adapterCfg.Endpoint = "http://" + name + ".example.com/openrtb2"
+			cfg.Adapters[name] = adapterCfg
+		}
+	}
+
+	ortbBidders, _ := newCoreBidders(&http.Client{}, cfg)
+	for name, bidder := range ortbBidders {
+		for _, testDir := range jsonTestDirs(t, bidder) {
+			t.Run(string(name)+"/"+filepath.Base(testDir), func(t *testing.T) {
+				adapterstest.RunConformanceTests(t, testDir, bidder, conformanceExemptions[name]...)
+			})
+		}
+	}
+}
+
+// jsonTestDirs returns the directories of the bidder's package which hold JSON test files.
+func jsonTestDirs(t *testing.T, bidder adapters.Bidder) []string {
+	bidderType := reflect.TypeOf(bidder)
+	if bidderType.Kind() == reflect.Ptr {
+		bidderType = bidderType.Elem()
+	}
+	const modulePath = "github.com/prebid/prebid-server/"
+	packageDir := filepath.Join("..", strings.TrimPrefix(bidderType.PkgPath(), modulePath))
+
+	files, err := ioutil.ReadDir(packageDir)
+	if err != nil {
+		t.Fatalf("Failed to read the package of %s: %v", bidderType, err)
+	}
+	var testDirs []string
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		for _, subDir := range []string{"exemplary", "supplemental"} {
+			if info, err := ioutil.ReadDir(filepath.Join(packageDir, file.Name(), subDir)); err == nil && len(info) > 0 {
+				testDirs = append(testDirs, filepath.Join(packageDir, file.Name()))
+				break
+			}
+		}
+	}
+	return testDirs
+}
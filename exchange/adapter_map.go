@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/golang/glog"
 	"github.com/prebid/prebid-server/pbsmetrics"
 
 	"github.com/prebid/prebid-server/adapters"
@@ -94,10 +95,97 @@ import (
 	"github.com/prebid/prebid-server/openrtb_ext"
 )
 
-// The newAdapterMap function is segregated to its own file to make it a simple and clean location for each Adapter
+// The newCoreBidders function is segregated to its own file to make it a simple and clean location for each Adapter
 // to register itself. No wading through Exchange code to find it.
 
-func newAdapterMap(client *http.Client, cfg *config.Configuration, infos adapters.BidderInfos, me pbsmetrics.MetricsEngine) map[openrtb_ext.BidderName]adaptedBidder {
+func newAdapterMap(client *http.Client, cfg *config.Configuration, infos adapters.BidderInfos, me pbsmetrics.MetricsEngine, timeouts *bidderTimeouts) map[openrtb_ext.BidderName]adaptedBidder {
+	ortbBidders, legacyBidders := newCoreBidders(client, cfg)
+
+	// Bidders declared in static/bidder-info run on the generic adapter. So do the aliases of these bidders,
+	// since they share their core bidder's info.
+	for name, info := range infos {
+		if info.OpenRTB != nil {
+			endpoint := cfg.Adapters[strings.ToLower(name)].Endpoint
+			if endpoint == "" {
+				endpoint = info.OpenRTB.Endpoint
+			}
+			ortbBidders[openrtb_ext.BidderName(name)] = generic.NewConfigBidder(endpoint, info.OpenRTB)
+		}
+	}
+
+	// The host's aliases run the code of their core bidder, set up with the alias' config
+	for name, adapterCfg := range cfg.Adapters {
+		if adapterCfg.AliasOf == "" || infos[name].OpenRTB != nil {
+			continue
+		}
+		core := openrtb_ext.BidderName(adapterCfg.AliasOf)
+		aliasOrtbBidders, aliasLegacyBidders := newCoreBidders(client, withCoreAdapterConfig(cfg, core, adapterCfg))
+		if bidder, ok := aliasOrtbBidders[core]; ok {
+			ortbBidders[openrtb_ext.BidderName(name)] = bidder
+		} else if bidder, ok := aliasLegacyBidders[core]; ok {
+			legacyBidders[openrtb_ext.BidderName(name)] = bidder
+		}
+	}
+
+	allBidders := make(map[openrtb_ext.BidderName]adaptedBidder, len(ortbBidders)+len(legacyBidders))
+	regions := newRegionSelector(cfg.Regions)
+
+	// Wrap legacy and openrtb Bidders behind a common interface, so that the Exchange doesn't need to concern
+	// itself with the differences.
+	for name, bidder := range legacyBidders {
+		// Clean out any disabled bidders
+		if infos[string(name)].Status == adapters.StatusActive {
+			allBidders[name] = adaptLegacyAdapter(bidder)
+		}
+	}
+	for name, bidder := range ortbBidders {
+		// Clean out any disabled bidders
+		if infos[string(name)].Status == adapters.StatusActive {
+			bidderClient, err := newBidderClient(client, cfg.Adapters[strings.ToLower(string(name))], cfg.PemCertsFile)
+			if err != nil {
+				glog.Fatalf("Failed to set up the http client of %s: %v", name, err)
+			}
+			adapted := adaptBidder(adapters.EnforceBidderInfo(bidder, infos[string(name)]), bidderClient, cfg, me, name)
+			adapted.latency = timeouts.trackerFor(name)
+			adapted.gzipRequests = infos[string(name)].EndpointCompression == adapters.CompressionGZIP
+			adapted.openRTB26 = infos[string(name)].OpenRTBVersion == adapters.OpenRTBVersion26
+			adapted.native11 = infos[string(name)].NativeVersion == openrtb_ext.NativeVersion11
+			adapted.regionalBidders = newRegionalBidders(client, cfg, name, infos[string(name)])
+			adapted.regions = regions
+			allBidders[name] = adapted
+		}
+	}
+
+	// Apply any middleware used for global Bidder logic.
+	for name, bidder := range allBidders {
+		allBidders[name] = ensureValidBids(bidder)
+	}
+
+	return allBidders
+}
+
+// withCoreAdapterConfig returns a copy of cfg in which the core bidder's adapter config is replaced by an alias' one.
+func withCoreAdapterConfig(cfg *config.Configuration, core openrtb_ext.BidderName, aliasCfg config.Adapter) *config.Configuration {
+	cfgCopy := *cfg
+	cfgCopy.Adapters = make(map[string]config.Adapter, len(cfg.Adapters))
+	for name, adapterCfg := range cfg.Adapters {
+		cfgCopy.Adapters[name] = adapterCfg
+	}
+	// Some adapters look up their config by the exact bidder name, and others by the lowercased one
+	cfgCopy.Adapters[string(core)] = aliasCfg
+	cfgCopy.Adapters[strings.ToLower(string(core))] = aliasCfg
+	return &cfgCopy
+}
+
+// NewCoreBidder returns the Bidder of a core bidder, set up with the host's config. It's meant for the tools which
+// run a single bidder. It returns nil for the unknown bidders, and the ones which are still legacy Adapters.
+func NewCoreBidder(client *http.Client, cfg *config.Configuration, name openrtb_ext.BidderName) adapters.Bidder {
+	ortbBidders, _ := newCoreBidders(client, cfg)
+	return ortbBidders[name]
+}
+
+// newCoreBidders builds every bidder which is written in code, using the adapter configs in cfg.
+func newCoreBidders(client *http.Client, cfg *config.Configuration) (map[openrtb_ext.BidderName]adapters.Bidder, map[openrtb_ext.BidderName]adapters.Adapter) {
 	ortbBidders := map[openrtb_ext.BidderName]adapters.Bidder{
 		openrtb_ext.Bidder33Across:     ttx.New33AcrossBidder(cfg.Adapters[string(openrtb_ext.Bidder33Across)].Endpoint),
 		openrtb_ext.BidderAdform:       adform.NewAdformBidder(client, cfg.Adapters[string(openrtb_ext.BidderAdform)].Endpoint),
@@ -197,29 +285,7 @@ func newAdapterMap(client *http.Client, cfg *config.Configuration, infos adapter
 		openrtb_ext.BidderPulsepoint: pulsepoint.NewPulsePointAdapter(adapters.DefaultHTTPAdapterConfig, cfg.Adapters[string(openrtb_ext.BidderPulsepoint)].Endpoint),
 	}
 
-	allBidders := make(map[openrtb_ext.BidderName]adaptedBidder, len(ortbBidders)+len(legacyBidders))
-
-	// Wrap legacy and openrtb Bidders behind a common interface, so that the Exchange doesn't need to concern
-	// itself with the differences.
-	for name, bidder := range legacyBidders {
-		// Clean out any disabled bidders
-		if infos[string(name)].Status == adapters.StatusActive {
-			allBidders[name] = adaptLegacyAdapter(bidder)
-		}
-	}
-	for name, bidder := range ortbBidders {
-		// Clean out any disabled bidders
-		if infos[string(name)].Status == adapters.StatusActive {
-			allBidders[name] = adaptBidder(adapters.EnforceBidderInfo(bidder, infos[string(name)]), client, cfg, me, name)
-		}
-	}
-
-	// Apply any middleware used for global Bidder logic.
-	for name, bidder := range allBidders {
-		allBidders[name] = ensureValidBids(bidder)
-	}
-
-	return allBidders
+	return ortbBidders, legacyBidders
 }
 
 // DisableBidders get all bidders but disabled ones
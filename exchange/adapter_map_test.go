@@ -8,11 +8,12 @@ import (
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	metricsConfig "github.com/prebid/prebid-server/pbsmetrics/config"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewAdapterMap(t *testing.T) {
 	cfg := &config.Configuration{Adapters: blankAdapterConfig(openrtb_ext.BidderList())}
-	adapterMap := newAdapterMap(nil, cfg, adapters.ParseBidderInfos(cfg.Adapters, "../static/bidder-info", openrtb_ext.BidderList()), &metricsConfig.DummyMetricsEngine{})
+	adapterMap := newAdapterMap(nil, cfg, adapters.ParseBidderInfos(cfg.Adapters, "../static/bidder-info", openrtb_ext.BidderList()), &metricsConfig.DummyMetricsEngine{}, nil)
 	for _, bidderName := range openrtb_ext.BidderMap {
 		if bidder, ok := adapterMap[bidderName]; bidder == nil || !ok {
 			t.Errorf("adapterMap missing expected Bidder: %s", string(bidderName))
@@ -39,7 +40,7 @@ func TestNewAdapterMapDisabledAdapters(t *testing.T) {
 			}
 		}
 	}
-	adapterMap := newAdapterMap(nil, &config.Configuration{Adapters: cfgAdapters}, adapters.ParseBidderInfos(cfgAdapters, "../static/bidder-info", bidderList), &metricsConfig.DummyMetricsEngine{})
+	adapterMap := newAdapterMap(nil, &config.Configuration{Adapters: cfgAdapters}, adapters.ParseBidderInfos(cfgAdapters, "../static/bidder-info", bidderList), &metricsConfig.DummyMetricsEngine{}, nil)
 	for _, bidderName := range openrtb_ext.BidderMap {
 		if bidder, ok := adapterMap[bidderName]; bidder == nil || !ok {
 			if inList(bidderList, bidderName) {
@@ -69,3 +70,30 @@ func blankAdapterConfig(bidderList []openrtb_ext.BidderName) map[string]config.A
 	}
 	return adapters
 }
+
+func TestNewAdapterMapConfigBidders(t *testing.T) {
+	openRTB := &adapters.OpenRTBInfo{Endpoint: "https://{{.Host}}/openrtb"}
+	infos := adapters.BidderInfos{
+		"configpartner":  adapters.BidderInfo{Status: adapters.StatusActive, Capabilities: &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{}}, OpenRTB: openRTB},
+		"configdisabled": adapters.BidderInfo{Status: adapters.StatusDisabled, Capabilities: &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{}}, OpenRTB: openRTB},
+	}
+	adapterMap := newAdapterMap(nil, &config.Configuration{}, infos, &metricsConfig.DummyMetricsEngine{}, nil)
+
+	assert.Contains(t, adapterMap, openrtb_ext.BidderName("configpartner"))
+	assert.NotContains(t, adapterMap, openrtb_ext.BidderName("configdisabled"))
+}
+
+func TestNewAdapterMapHostAliases(t *testing.T) {
+	cfg := &config.Configuration{Adapters: blankAdapterConfig(openrtb_ext.BidderList())}
+	cfg.Adapters["whitelabel"] = config.Adapter{AliasOf: string(openrtb_ext.BidderAppnexus), Endpoint: "http://whitelabel.example.com"}
+	cfg.Adapters["legacylabel"] = config.Adapter{AliasOf: string(openrtb_ext.BidderIx), Endpoint: "http://legacylabel.example.com"}
+	infos := adapters.ParseBidderInfos(cfg.Adapters, "../static/bidder-info", openrtb_ext.BidderList())
+	infos["whitelabel"] = adapters.BidderInfo{Status: adapters.StatusActive, AliasOf: "appnexus", Capabilities: infos["appnexus"].Capabilities}
+	infos["legacylabel"] = adapters.BidderInfo{Status: adapters.StatusActive, AliasOf: "ix", Capabilities: infos["ix"].Capabilities}
+
+	adapterMap := newAdapterMap(nil, cfg, infos, &metricsConfig.DummyMetricsEngine{}, nil)
+
+	assert.Contains(t, adapterMap, openrtb_ext.BidderName("whitelabel"))
+	assert.Contains(t, adapterMap, openrtb_ext.BidderName("legacylabel"))
+	assert.Contains(t, adapterMap, openrtb_ext.BidderAppnexus, "The core bidder should keep its own adapter")
+}
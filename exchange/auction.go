@@ -6,7 +6,9 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/prebid_cache_client"
 )
@@ -125,11 +128,103 @@ func newAuction(seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, numImps int
 	}
 }
 
-func (a *auction) setRoundedPrices(priceGranularity openrtb_ext.PriceGranularity) {
+// auctionPriceMacro is replaced with the clearing price in the markup and win notice URL of second price winners
+const auctionPriceMacro = "${AUCTION_PRICE}"
+
+// setClearingPrices prices the winning bid of every imp for a second price auction (at=2). The winner pays
+// the second highest bid on the imp plus the increment, but never less than the imp's floor or more than its
+// own bid. Deals clear at their own price.
+//
+// The ${AUCTION_PRICE} macros in the winners' adm and nurl are replaced with the clearing price, so that the
+// cached creatives carry it too.
+func (a *auction) setClearingPrices(bidRequest *openrtb.BidRequest, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, conversions currencies.Conversions, increment float64) {
+	floors := make(map[string]openrtb.Imp, len(bidRequest.Imp))
+	for _, imp := range bidRequest.Imp {
+		floors[imp.ID] = imp
+	}
+
+	bidCurrencies := make(map[*pbsOrtbBid]string)
+	bidsByImp := make(map[string][]*pbsOrtbBid, len(a.winningBids))
+	for _, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.bids {
+			bidCurrencies[bid] = seatBid.currency
+			bidsByImp[bid.bid.ImpID] = append(bidsByImp[bid.bid.ImpID], bid)
+		}
+	}
+
+	a.clearingPrices = make(map[*pbsOrtbBid]float64, len(a.winningBids))
+	for impID, winner := range a.winningBids {
+		clearingPrice := winner.bid.Price
+		if winner.dealPriority == 0 && winner.bid.DealID == "" {
+			currency := bidCurrencies[winner]
+
+			secondPrice := 0.0
+			for _, bid := range bidsByImp[impID] {
+				if bid == winner {
+					continue
+				}
+				price, err := convertPrice(conversions, bid.bid.Price, bidCurrencies[bid], currency)
+				if err == nil && price > secondPrice {
+					secondPrice = price
+				}
+			}
+
+			floor := 0.0
+			if imp := floors[impID]; imp.BidFloor > 0 {
+				floorCurrency := imp.BidFloorCur
+				if floorCurrency == "" {
+					floorCurrency = "USD"
+				}
+				if price, err := convertPrice(conversions, imp.BidFloor, floorCurrency, currency); err == nil {
+					floor = price
+				}
+			}
+
+			// A lone bid clears at the floor, or at its own price if there is none
+			if secondPrice > 0 {
+				clearingPrice = math.Max(secondPrice+increment, floor)
+			} else if floor > 0 {
+				clearingPrice = floor
+			}
+			clearingPrice = math.Min(math.Round(clearingPrice*10000)/10000, winner.bid.Price)
+		}
+
+		a.clearingPrices[winner] = clearingPrice
+		priceString := strconv.FormatFloat(clearingPrice, 'f', -1, 64)
+		winner.bid.AdM = strings.Replace(winner.bid.AdM, auctionPriceMacro, priceString, -1)
+		winner.bid.NURL = strings.Replace(winner.bid.NURL, auctionPriceMacro, priceString, -1)
+	}
+}
+
+func convertPrice(conversions currencies.Conversions, price float64, from string, to string) (float64, error) {
+	if from == "" {
+		from = "USD"
+	}
+	if to == "" {
+		to = "USD"
+	}
+	rate, err := conversions.GetRate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return price * rate, nil
+}
+
+// setRoundedPrices rounds the price of the top bid of each bidder on each imp with the granularity of its media type.
+// Winners of a second price auction are rounded from their clearing price instead.
+func (a *auction) setRoundedPrices(targData *targetData) {
 	roundedPrices := make(map[*pbsOrtbBid]string, 5*len(a.winningBids))
 	for _, topBidsPerImp := range a.winningBidsByBidder {
 		for _, topBidPerBidder := range topBidsPerImp {
-			roundedPrice, err := GetCpmStringValue(topBidPerBidder.bid.Price, priceGranularity)
+			price := topBidPerBidder.bid.Price
+			if clearingPrice, ok := a.clearingPrices[topBidPerBidder]; ok {
+				price = clearingPrice
+			}
+			priceGranularity := targData.priceGranularityFor(topBidPerBidder.bidType)
+			roundedPrice, err := GetCpmStringValue(price, priceGranularity)
 			if err != nil {
 				glog.Errorf(`Error rounding price according to granularity. This shouldn't happen unless /openrtb2 input validation is buggy. Granularity was "%v".`, priceGranularity)
 			}
@@ -342,8 +437,53 @@ type auction struct {
 	winningBidsByBidder map[string]map[openrtb_ext.BidderName]*pbsOrtbBid
 	// roundedPrices stores the price strings rounded for each bid according to the price granularity.
 	roundedPrices map[*pbsOrtbBid]string
+	// clearingPrices stores the price each imp's winner pays in a second price auction. It's nil in first price auctions.
+	clearingPrices map[*pbsOrtbBid]float64
 	// cacheIds stores the UUIDs from Prebid Cache for fetching the full bid JSON.
 	cacheIds map[*openrtb.Bid]string
 	// vastCacheIds stores UUIDS from Prebid cache for fetching the VAST markup to video bids.
 	vastCacheIds map[*openrtb.Bid]string
+	// dealTiers stores the deal tier of banner and native bids whose deal priority reaches the bidder's minDealTier.
+	dealTiers map[*pbsOrtbBid]string
+	// billingEvents stores the billing event URL of the winners whose billing notice is held by PBS.
+	billingEvents map[*pbsOrtbBid]string
+}
+
+// billingEventFor returns the billing event URL of a winner whose billing notice is held by PBS.
+func (a *auction) billingEventFor(bid *pbsOrtbBid) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	eventURL, ok := a.billingEvents[bid]
+	return eventURL, ok
+}
+
+// cacheFailed reports whether the bid should have been cached for the targeting keys, but wasn't. The clients
+// which asked for the cache can't serve it.
+func (a *auction) cacheFailed(bid *pbsOrtbBid, targData *targetData) bool {
+	if targData == nil || !(targData.includeBidderKeys || targData.includeWinners) {
+		return false
+	}
+	if _, cached := a.cacheIds[bid.bid]; targData.includeCacheBids && !cached {
+		return true
+	}
+	if _, cached := a.vastCacheIds[bid.bid]; targData.includeCacheVast && bid.bidType == openrtb_ext.BidTypeVideo && !cached {
+		return true
+	}
+	return false
+}
+
+// winningPrice returns the price paid by the winner of an imp, or 0 if the imp has no winner.
+func (a *auction) winningPrice(impID string) float64 {
+	if a == nil {
+		return 0
+	}
+	winner, ok := a.winningBids[impID]
+	if !ok {
+		return 0
+	}
+	if clearingPrice, ok := a.clearingPrices[winner]; ok {
+		return clearingPrice
+	}
+	return winner.bid.Price
 }
@@ -10,8 +10,10 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/prebid_cache_client"
 
@@ -42,6 +44,77 @@ func TestMakeVASTNurl(t *testing.T) {
 	assert.Equal(t, expect, vast)
 }
 
+func TestSetClearingPrices(t *testing.T) {
+	bidRequest := &openrtb.BidRequest{
+		AT: 2,
+		Imp: []openrtb.Imp{
+			{ID: "secondBid"},
+			{ID: "floorInOtherCurrency", BidFloor: 1, BidFloorCur: "EUR"},
+			{ID: "loneBid"},
+			{ID: "capped"},
+			{ID: "deal"},
+			{ID: "floorAboveSecondBid", BidFloor: 1.5},
+		},
+	}
+	winners := map[string]*pbsOrtbBid{
+		"secondBid":            {bid: &openrtb.Bid{ID: "w1", ImpID: "secondBid", Price: 5, NURL: "https://win.com?p=${AUCTION_PRICE}", AdM: "<img src='https://ad.com?p=${AUCTION_PRICE}'>"}},
+		"floorInOtherCurrency": {bid: &openrtb.Bid{ID: "w2", ImpID: "floorInOtherCurrency", Price: 2}},
+		"loneBid":              {bid: &openrtb.Bid{ID: "w3", ImpID: "loneBid", Price: 2}},
+		"capped":               {bid: &openrtb.Bid{ID: "w4", ImpID: "capped", Price: 4}},
+		"deal":                 {bid: &openrtb.Bid{ID: "w5", ImpID: "deal", Price: 6, DealID: "deal-1"}},
+		"floorAboveSecondBid":  {bid: &openrtb.Bid{ID: "w6", ImpID: "floorAboveSecondBid", Price: 2}},
+	}
+	seatBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		"appnexus": {
+			currency: "USD",
+			bids: []*pbsOrtbBid{
+				winners["secondBid"],
+				winners["floorInOtherCurrency"],
+				winners["loneBid"],
+				winners["capped"],
+				winners["deal"],
+				winners["floorAboveSecondBid"],
+			},
+		},
+		"rubicon": {
+			currency: "EUR",
+			bids: []*pbsOrtbBid{
+				{bid: &openrtb.Bid{ID: "l1", ImpID: "secondBid", Price: 2.5}},
+			},
+		},
+		"openx": {
+			currency: "USD",
+			bids: []*pbsOrtbBid{
+				{bid: &openrtb.Bid{ID: "l4", ImpID: "capped", Price: 3.995}},
+				{bid: &openrtb.Bid{ID: "l5", ImpID: "deal", Price: 1}},
+				{bid: &openrtb.Bid{ID: "l6", ImpID: "floorAboveSecondBid", Price: 1}},
+			},
+		},
+	}
+	conversions := currencies.NewRates(time.Now(), map[string]map[string]float64{
+		"EUR": {"USD": 1.2},
+	})
+
+	auc := newAuction(seatBids, len(bidRequest.Imp))
+	auc.setClearingPrices(bidRequest, seatBids, conversions, 0.01)
+	auc.setRoundedPrices(&targetData{priceGranularity: openrtb_ext.PriceGranularityFromString("high")})
+
+	expectedPrices := map[string]float64{
+		"secondBid":            3.01,
+		"floorInOtherCurrency": 1.2,
+		"loneBid":              2,
+		"capped":               4,
+		"deal":                 6,
+		"floorAboveSecondBid":  1.5,
+	}
+	for impID, expected := range expectedPrices {
+		assert.Equal(t, expected, auc.clearingPrices[winners[impID]], "Incorrect clearing price for imp %s", impID)
+	}
+	assert.Equal(t, "3.01", auc.roundedPrices[winners["secondBid"]], "hb_pb should use the clearing price")
+	assert.Equal(t, "https://win.com?p=3.01", winners["secondBid"].bid.NURL, "nurl macro")
+	assert.Equal(t, "<img src='https://ad.com?p=3.01'>", winners["secondBid"].bid.AdM, "adm macro")
+}
+
 func TestBuildCacheString(t *testing.T) {
 	testCases := []struct {
 		description      string
@@ -0,0 +1,109 @@
+package exchange
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// mergeBidAdjustments combines the account's bid adjustments with the request's. The request's adjustments
+// replace the account's for the same media type, bidder and deal. It returns nil if neither has any.
+func mergeBidAdjustments(account *openrtb_ext.ExtRequestPrebidBidAdjustments, request *openrtb_ext.ExtRequestPrebidBidAdjustments) *openrtb_ext.ExtRequestPrebidBidAdjustments {
+	if account == nil || len(account.MediaType) == 0 {
+		return request
+	}
+	if request == nil || len(request.MediaType) == 0 {
+		return account
+	}
+
+	merged := &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: make(map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment),
+	}
+	for _, source := range []*openrtb_ext.ExtRequestPrebidBidAdjustments{account, request} {
+		for mediaType, bidders := range source.MediaType {
+			if merged.MediaType[mediaType] == nil {
+				merged.MediaType[mediaType] = make(map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment)
+			}
+			for bidder, deals := range bidders {
+				if merged.MediaType[mediaType][bidder] == nil {
+					merged.MediaType[mediaType][bidder] = make(map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment)
+				}
+				for dealID, adjustments := range deals {
+					merged.MediaType[mediaType][bidder][dealID] = adjustments
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// getBidAdjustments finds the most specific adjustments for a bid. An exact media type beats an exact bidder,
+// which beats an exact deal ID.
+func getBidAdjustments(rules *openrtb_ext.ExtRequestPrebidBidAdjustments, bidType openrtb_ext.BidType, bidder openrtb_ext.BidderName, dealID string) []openrtb_ext.ExtRequestPrebidBidAdjustment {
+	if rules == nil {
+		return nil
+	}
+
+	dealIDs := []string{openrtb_ext.BidAdjustmentWildcard}
+	if dealID != "" {
+		dealIDs = []string{dealID, openrtb_ext.BidAdjustmentWildcard}
+	}
+
+	for _, mediaType := range []string{string(bidType), openrtb_ext.BidAdjustmentWildcard} {
+		bidders, ok := rules.MediaType[mediaType]
+		if !ok {
+			continue
+		}
+		for _, bidderName := range []string{string(bidder), openrtb_ext.BidAdjustmentWildcard} {
+			deals, ok := bidders[bidderName]
+			if !ok {
+				continue
+			}
+			for _, deal := range dealIDs {
+				if adjustments, ok := deals[deal]; ok {
+					return adjustments
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applyBidAdjustments adjusts a price in the given currency. The values of "cpm" and "static" adjustments are
+// converted to that currency first. A "cpm" adjustment never takes the price below 0.
+func applyBidAdjustments(price float64, currency string, adjustments []openrtb_ext.ExtRequestPrebidBidAdjustment, conversions currencies.Conversions) (float64, error) {
+	for _, adjustment := range adjustments {
+		switch adjustment.AdjType {
+		case openrtb_ext.BidAdjustmentTypeMultiplier:
+			price = price * adjustment.Value
+		case openrtb_ext.BidAdjustmentTypeCPM, openrtb_ext.BidAdjustmentTypeStatic:
+			value, err := convertPrice(conversions, adjustment.Value, adjustment.Currency, currency)
+			if err != nil {
+				return 0, fmt.Errorf("Unable to apply %s bid adjustment: %v", adjustment.AdjType, err)
+			}
+			if adjustment.AdjType == openrtb_ext.BidAdjustmentTypeCPM {
+				price = math.Max(price-value, 0)
+			} else {
+				price = value
+			}
+		default:
+			return 0, fmt.Errorf("Unknown bid adjustment type %q", adjustment.AdjType)
+		}
+	}
+	return price, nil
+}
+
+// adjustBidPrice applies the adjustments to the price of a bid in the given currency. When they can't be applied,
+// the price is left alone and the bid must be dropped.
+func adjustBidPrice(bid *openrtb.Bid, currency string, adjustments []openrtb_ext.ExtRequestPrebidBidAdjustment, conversions currencies.Conversions) error {
+	price, err := applyBidAdjustments(bid.Price, currency, adjustments, conversions)
+	if err != nil {
+		return &errortypes.BidAdjustmentFailed{Message: fmt.Sprintf("Bid \"%s\" was dropped: %v", bid.ID, err)}
+	}
+	bid.Price = price
+	return nil
+}
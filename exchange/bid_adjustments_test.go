@@ -0,0 +1,145 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeBidAdjustments(t *testing.T) {
+	multiplier := []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeMultiplier, Value: 0.9}}
+	cpm := []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 0.1}}
+	static := []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeStatic, Value: 2}}
+
+	account := &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment{
+			"banner": {"appnexus": {"*": multiplier, "deal1": cpm}},
+		},
+	}
+	request := &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment{
+			"banner": {"appnexus": {"*": static}},
+			"video":  {"*": {"*": cpm}},
+		},
+	}
+
+	assert.Nil(t, mergeBidAdjustments(nil, nil))
+	assert.Equal(t, account, mergeBidAdjustments(account, nil))
+	assert.Equal(t, request, mergeBidAdjustments(nil, request))
+
+	merged := mergeBidAdjustments(account, request)
+	assert.Equal(t, static, merged.MediaType["banner"]["appnexus"]["*"], "The request should override the account")
+	assert.Equal(t, cpm, merged.MediaType["banner"]["appnexus"]["deal1"], "Account rules the request doesn't set should be kept")
+	assert.Equal(t, cpm, merged.MediaType["video"]["*"]["*"])
+	assert.Equal(t, multiplier, account.MediaType["banner"]["appnexus"]["*"], "The account's rules shouldn't be modified")
+}
+
+func TestGetBidAdjustments(t *testing.T) {
+	adjustment := func(value float64) []openrtb_ext.ExtRequestPrebidBidAdjustment {
+		return []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeMultiplier, Value: value}}
+	}
+	rules := &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment{
+			"banner": {
+				"appnexus": {"deal1": adjustment(1), "*": adjustment(2)},
+				"*":        {"*": adjustment(3)},
+			},
+			"*": {
+				"rubicon": {"*": adjustment(4)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		description string
+		bidType     openrtb_ext.BidType
+		bidder      openrtb_ext.BidderName
+		dealID      string
+		expected    []openrtb_ext.ExtRequestPrebidBidAdjustment
+	}{
+		{"Exact match", openrtb_ext.BidTypeBanner, openrtb_ext.BidderAppnexus, "deal1", adjustment(1)},
+		{"Deal wildcard", openrtb_ext.BidTypeBanner, openrtb_ext.BidderAppnexus, "deal2", adjustment(2)},
+		{"No deal", openrtb_ext.BidTypeBanner, openrtb_ext.BidderAppnexus, "", adjustment(2)},
+		{"Bidder wildcard", openrtb_ext.BidTypeBanner, openrtb_ext.BidderRubicon, "", adjustment(3)},
+		{"Media type wildcard", openrtb_ext.BidTypeVideo, openrtb_ext.BidderRubicon, "", adjustment(4)},
+		{"No match", openrtb_ext.BidTypeVideo, openrtb_ext.BidderAppnexus, "", nil},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, getBidAdjustments(rules, test.bidType, test.bidder, test.dealID), test.description)
+	}
+	assert.Nil(t, getBidAdjustments(nil, openrtb_ext.BidTypeBanner, openrtb_ext.BidderAppnexus, ""))
+}
+
+func TestApplyBidAdjustments(t *testing.T) {
+	conversions := currencies.NewRates(time.Now(), map[string]map[string]float64{
+		"USD": {"EUR": 0.5},
+	})
+
+	testCases := []struct {
+		description   string
+		currency      string
+		adjustments   []openrtb_ext.ExtRequestPrebidBidAdjustment
+		expectedPrice float64
+		expectError   bool
+	}{
+		{
+			description:   "Multiplier",
+			adjustments:   []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeMultiplier, Value: 0.5}},
+			expectedPrice: 5,
+		},
+		{
+			description:   "CPM in the bid's currency",
+			currency:      "EUR",
+			adjustments:   []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 1, Currency: "EUR"}},
+			expectedPrice: 9,
+		},
+		{
+			description:   "CPM converted to the bid's currency",
+			currency:      "EUR",
+			adjustments:   []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 2}},
+			expectedPrice: 9,
+		},
+		{
+			description:   "CPM larger than the price",
+			adjustments:   []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 12}},
+			expectedPrice: 0,
+		},
+		{
+			description:   "Static",
+			adjustments:   []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeStatic, Value: 3}},
+			expectedPrice: 3,
+		},
+		{
+			description: "Applied in order",
+			adjustments: []openrtb_ext.ExtRequestPrebidBidAdjustment{
+				{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 2},
+				{AdjType: openrtb_ext.BidAdjustmentTypeMultiplier, Value: 0.5},
+			},
+			expectedPrice: 4,
+		},
+		{
+			description: "Unknown currency",
+			adjustments: []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 1, Currency: "JPY"}},
+			expectError: true,
+		},
+		{
+			description: "Unknown type",
+			adjustments: []openrtb_ext.ExtRequestPrebidBidAdjustment{{AdjType: "percent", Value: 1}},
+			expectError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		price, err := applyBidAdjustments(10, test.currency, test.adjustments, conversions)
+		if test.expectError {
+			assert.Error(t, err, test.description)
+			continue
+		}
+		assert.NoError(t, err, test.description)
+		assert.InDelta(t, test.expectedPrice, price, 0.0001, test.description)
+	}
+}
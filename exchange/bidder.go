@@ -2,6 +2,7 @@ package exchange
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -48,7 +49,7 @@ type adaptedBidder interface {
 	//
 	// Any errors will be user-facing in the API.
 	// Error messages should help publishers understand what might account for "bad" bids.
-	requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error)
+	requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, adjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error)
 }
 
 // pbsOrtbBid is a Bid returned by an adaptedBidder.
@@ -88,7 +89,7 @@ type pbsOrtbSeatBid struct {
 //
 // The name refers to the "Adapter" architecture pattern, and should not be confused with a Prebid "Adapter"
 // (which is being phased out and replaced by Bidder for OpenRTB auctions)
-func adaptBidder(bidder adapters.Bidder, client *http.Client, cfg *config.Configuration, me pbsmetrics.MetricsEngine, name openrtb_ext.BidderName) adaptedBidder {
+func adaptBidder(bidder adapters.Bidder, client *http.Client, cfg *config.Configuration, me pbsmetrics.MetricsEngine, name openrtb_ext.BidderName) *bidderAdapter {
 	return &bidderAdapter{
 		Bidder:     bidder,
 		BidderName: name,
@@ -98,6 +99,8 @@ func adaptBidder(bidder adapters.Bidder, client *http.Client, cfg *config.Config
 			Debug:              cfg.Debug,
 			DisableConnMetrics: cfg.Metrics.Disabled.AdapterConnectionMetrics,
 		},
+		breakers:            newCircuitBreakers(cfg.CircuitBreaker, name, me),
+		notificationTimeout: time.Duration(cfg.Auction.Notifications.TimeoutMillis) * time.Millisecond,
 	}
 }
 
@@ -107,6 +110,24 @@ type bidderAdapter struct {
 	Client     *http.Client
 	me         pbsmetrics.MetricsEngine
 	config     bidderAdapterConfig
+	// latency records the response times used for the bidder's timeout. It's nil unless bidder timeouts are enabled.
+	latency *latencyTracker
+	// breakers stop the requests to endpoint hosts which keep failing. It's nil unless circuit breakers are enabled.
+	breakers *circuitBreakers
+	// gzipRequests is true if the bidder asked for gzipped request bodies in its bidder-info file.
+	gzipRequests bool
+	// openRTB26 is true if the bidder speaks OpenRTB 2.6. Its adapter also gets the request converted to 2.6,
+	// in ExtraRequestInfo.OpenRTB26Request.
+	openRTB26 bool
+	// native11 is true if the bidder speaks Native 1.1. Its adapter gets the native requests in the 1.1 format,
+	// and its native markup is converted to 1.2.
+	native11 bool
+	// regionalBidders are the Bidders set up with the bidder's regional endpoints, by region. The regions pick
+	// the one used for an auction.
+	regionalBidders map[string]adapters.Bidder
+	regions         *regionSelector
+	// notificationTimeout is how long the win, loss and billing notices wait on the bidder.
+	notificationTimeout time.Duration
 }
 
 type bidderAdapterConfig struct {
@@ -114,8 +135,26 @@ type bidderAdapterConfig struct {
 	DisableConnMetrics bool
 }
 
-func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
-	reqData, errs := bidder.Bidder.MakeRequests(request, reqInfo)
+func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, adjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
+	// The adapter works on its own copy of the request if its native requests need converting. The exchange
+	// keeps the original one, which is needed to read the 1.2 markup.
+	bidderRequest := request
+	var errs []error
+	if bidder.native11 {
+		bidderRequest, errs = convertNativeRequestsTo11(request)
+	}
+	if bidder.openRTB26 {
+		request26, err := openrtb_ext.ConvertRequestTo26(bidderRequest)
+		if err != nil {
+			return nil, append(errs, &errortypes.BadInput{Message: fmt.Sprintf("Unable to convert the request to OpenRTB 2.6: %v", err)})
+		}
+		bidderReqInfo := *reqInfo
+		bidderReqInfo.OpenRTB26Request = request26
+		reqInfo = &bidderReqInfo
+	}
+	coreBidder := bidder.bidderFor(request)
+	reqData, moreErrs := coreBidder.MakeRequests(bidderRequest, reqInfo)
+	errs = append(errs, moreErrs...)
 
 	if len(reqData) == 0 {
 		// If the adapter failed to generate both requests and errors, this is an error.
@@ -155,9 +194,13 @@ func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.Bi
 		}
 
 		if httpInfo.err == nil {
-			bidResponse, moreErrs := bidder.Bidder.MakeBids(request, httpInfo.request, httpInfo.response)
+			bidResponse, moreErrs := coreBidder.MakeBids(bidderRequest, httpInfo.request, httpInfo.response)
 			errs = append(errs, moreErrs...)
 
+			if bidResponse != nil && bidder.native11 {
+				convertNativeMarkupTo12(bidResponse.Bids)
+			}
+
 			if bidResponse != nil {
 				// Setup default currency as `USD` is not set in bid request nor bid response
 				if bidResponse.Currency == "" {
@@ -167,16 +210,10 @@ func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.Bi
 					request.Cur = []string{defaultCurrency}
 				}
 
-				// Try to get a conversion rate
-				// Try to get the first currency from request.cur having a match in the rate converter,
-				// and use it as currency
-				var conversionRate float64
-				var err error
-				for _, bidReqCur := range request.Cur {
-					if conversionRate, err = conversions.GetRate(bidResponse.Currency, bidReqCur); err == nil {
-						seatBid.currency = bidReqCur
-						break
-					}
+				// The auction runs in the first currency from request.cur, so every bid is converted to it
+				conversionRate, err := conversions.GetRate(bidResponse.Currency, request.Cur[0])
+				if err == nil {
+					seatBid.currency = request.Cur[0]
 				}
 
 				// Only do this for request from mobile app
@@ -202,6 +239,14 @@ func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.Bi
 					// Conversion rate found, using it for conversion
 					for i := 0; i < len(bidResponse.Bids); i++ {
 						if bidResponse.Bids[i].Bid != nil {
+							// Bid adjustment rules work in the bidder's currency, before the conversion
+							adjustments := getBidAdjustments(adjustmentRules, bidResponse.Bids[i].BidType, name, bidResponse.Bids[i].Bid.DealID)
+							if len(adjustments) > 0 {
+								if adjustErr := adjustBidPrice(bidResponse.Bids[i].Bid, bidResponse.Currency, adjustments, conversions); adjustErr != nil {
+									errs = append(errs, adjustErr)
+									continue
+								}
+							}
 							bidResponse.Bids[i].Bid.Price = bidResponse.Bids[i].Bid.Price * bidAdjustment * conversionRate
 						}
 						seatBid.bids = append(seatBid.bids, &pbsOrtbBid{
@@ -224,6 +269,41 @@ func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.Bi
 	return seatBid, errs
 }
 
+// convertNativeRequestsTo11 returns a copy of the request in which the native requests of the imps are in the
+// Native 1.1 format.
+func convertNativeRequestsTo11(request *openrtb.BidRequest) (*openrtb.BidRequest, []error) {
+	var errs []error
+	requestCopy := *request
+	requestCopy.Imp = make([]openrtb.Imp, len(request.Imp))
+	for i, imp := range request.Imp {
+		if imp.Native != nil {
+			nativeCopy := *imp.Native
+			converted, err := openrtb_ext.ConvertNativeRequestTo11(nativeCopy.Request)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Failed to convert the native request of imp %s to 1.1: %v", imp.ID, err))
+			}
+			nativeCopy.Request = converted
+			nativeCopy.Ver = openrtb_ext.NativeVersion11
+			imp.Native = &nativeCopy
+		}
+		requestCopy.Imp[i] = imp
+	}
+	return &requestCopy, errs
+}
+
+// convertNativeMarkupTo12 converts the markup of the native bids to the Native 1.2 format. The markup which
+// can't be parsed is left alone, as some bidders return non-IAB compliant native markup.
+func convertNativeMarkupTo12(bids []*adapters.TypedBid) {
+	for _, typedBid := range bids {
+		if typedBid == nil || typedBid.Bid == nil || typedBid.BidType != openrtb_ext.BidTypeNative {
+			continue
+		}
+		if converted, err := openrtb_ext.ConvertNativeResponseTo12(typedBid.Bid.AdM); err == nil {
+			typedBid.Bid.AdM = converted
+		}
+	}
+}
+
 func addNativeTypes(bid *openrtb.Bid, request *openrtb.BidRequest) (*nativeResponse.Response, []error) {
 	var errs []error
 	var nativeMarkup *nativeResponse.Response
@@ -327,23 +407,59 @@ func (bidder *bidderAdapter) doRequest(ctx context.Context, req *adapters.Reques
 }
 
 func (bidder *bidderAdapter) doRequestImpl(ctx context.Context, req *adapters.RequestData, logger util.LogMsg) *httpCallInfo {
-	httpReq, err := http.NewRequest(req.Method, req.Uri, bytes.NewBuffer(req.Body))
+	body, headers := req.Body, req.Headers
+	if bidder.gzipRequests && len(req.Body) > 0 {
+		compressed, err := gzipBody(req.Body)
+		if err != nil {
+			return &httpCallInfo{
+				request: req,
+				err:     err,
+			}
+		}
+		body = compressed
+		// The headers are copied so that the debug output keeps showing what the adapter made
+		headers = make(http.Header, len(req.Headers)+1)
+		for name, values := range req.Headers {
+			headers[name] = values
+		}
+		headers.Set("Content-Encoding", adapters.CompressionGZIP)
+	}
+
+	httpReq, err := http.NewRequest(req.Method, req.Uri, bytes.NewBuffer(body))
 	if err != nil {
 		return &httpCallInfo{
 			request: req,
 			err:     err,
 		}
 	}
-	httpReq.Header = req.Headers
+	httpReq.Header = headers
+
+	breaker := bidder.breakers.forHost(httpReq.URL.Host)
+	if !breaker.allow() {
+		return &httpCallInfo{
+			request: req,
+			err: &errortypes.CircuitBreakerOpen{
+				Message: fmt.Sprintf("The request wasn't sent because %s has been failing. It will be retried once the endpoint recovers.", httpReq.URL.Host),
+			},
+		}
+	}
 
 	// If adapter connection metrics are not disabled, add the client trace
 	// to get complete connection info into our metrics
 	if !bidder.config.DisableConnMetrics {
 		ctx = bidder.addClientTrace(ctx)
 	}
+	bidder.me.RecordAdapterRequestSize(bidder.BidderName, len(body))
+	start := time.Now()
 	httpResp, err := ctxhttp.Do(ctx, bidder.Client, httpReq)
 	if err != nil {
+		if err == context.Canceled {
+			breaker.abandon()
+		} else {
+			breaker.record(true)
+		}
 		if err == context.DeadlineExceeded {
+			bidder.latency.record(time.Since(start))
 			err = &errortypes.Timeout{Message: err.Error()}
 			var corebidder adapters.Bidder = bidder.Bidder
 			// The bidder adapter normally stores an info-aware bidder (a bidder wrapper)
@@ -367,6 +483,7 @@ func (bidder *bidderAdapter) doRequestImpl(ctx context.Context, req *adapters.Re
 	}
 
 	respBody, err := ioutil.ReadAll(httpResp.Body)
+	breaker.record(err != nil || httpResp.StatusCode >= 500)
 	if err != nil {
 		return &httpCallInfo{
 			request: req,
@@ -374,6 +491,8 @@ func (bidder *bidderAdapter) doRequestImpl(ctx context.Context, req *adapters.Re
 		}
 	}
 	defer httpResp.Body.Close()
+	bidder.latency.record(time.Since(start))
+	bidder.me.RecordAdapterResponseSize(bidder.BidderName, len(respBody))
 
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 400 {
 		err = &errortypes.BadServerResponse{
@@ -469,3 +588,16 @@ func (bidder *bidderAdapter) addClientTrace(ctx context.Context) context.Context
 	}
 	return httptrace.WithClientTrace(ctx, trace)
 }
+
+// gzipBody compresses a request body for the bidders which asked for gzipped requests.
+func gzipBody(body []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
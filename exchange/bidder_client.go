@@ -0,0 +1,96 @@
+package exchange
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/ssl"
+)
+
+// newBidderClient returns the http client used for a bidder. The bidders which have TLS, HTTP/2 or http client
+// settings of their own get a client of their own, which starts from the shared client's transport. The others
+// share the client.
+func newBidderClient(client *http.Client, adapterCfg config.Adapter, pemCertsFile string) (*http.Client, error) {
+	if !adapterCfg.TLS.IsSet() && !adapterCfg.HTTP2 && adapterCfg.HTTPClient == (config.HTTPClient{}) {
+		return client, nil
+	}
+
+	var transport *http.Transport
+	if client != nil {
+		if shared, ok := client.Transport.(*http.Transport); ok {
+			transport = shared.Clone()
+		}
+	}
+	if transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	pool := adapterCfg.HTTPClient
+	if pool.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = pool.MaxConnsPerHost
+	}
+	if pool.MaxIdleConns > 0 {
+		transport.MaxIdleConns = pool.MaxIdleConns
+	}
+	if pool.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	}
+	if pool.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = time.Duration(pool.IdleConnTimeout) * time.Second
+	}
+
+	// The transports which have their own TLS config only negotiate HTTP/2 when they're forced to
+	if adapterCfg.HTTP2 {
+		transport.ForceAttemptHTTP2 = true
+	}
+
+	if adapterCfg.TLS.IsSet() {
+		tlsConfig, err := newBidderTLSConfig(transport.TLSClientConfig, adapterCfg.TLS, pemCertsFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	bidderClient := &http.Client{Transport: transport}
+	if client != nil {
+		bidderClient.Timeout = client.Timeout
+	}
+	return bidderClient, nil
+}
+
+// newBidderTLSConfig returns a copy of the shared TLS config, changed by the bidder's TLS settings.
+func newBidderTLSConfig(shared *tls.Config, tlsCfg config.AdapterTLS, pemCertsFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if shared != nil {
+		tlsConfig = shared.Clone()
+	}
+
+	if tlsCfg.CAFile != "" {
+		// The shared pool can't be copied, so the bidder's is built again from the same certificates. Like for
+		// the shared pool, a pem_certs_file which can't be read is left out.
+		rootCAs, _ := ssl.NewRootCAPool(pemCertsFile)
+		rootCAs, err := ssl.AppendPEMFileToRootCAPool(rootCAs, tlsCfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if tlsCfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if tlsCfg.MinVersion != "" {
+		tlsConfig.MinVersion = config.TLSVersions[tlsCfg.MinVersion]
+	}
+	if tlsCfg.ServerName != "" {
+		tlsConfig.ServerName = tlsCfg.ServerName
+	}
+	return tlsConfig, nil
+}
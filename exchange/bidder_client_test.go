@@ -0,0 +1,195 @@
+package exchange
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBidderClientShared(t *testing.T) {
+	client := &http.Client{}
+	bidderClient, err := newBidderClient(client, config.Adapter{Endpoint: "http://bidder.com"}, "")
+	assert.NoError(t, err)
+	assert.True(t, client == bidderClient, "Bidders without settings of their own should share the client")
+}
+
+func TestNewBidderClientPool(t *testing.T) {
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        50,
+			MaxIdleConnsPerHost: 5,
+			TLSClientConfig:     &tls.Config{ServerName: "shared"},
+		},
+	}
+	adapterCfg := config.Adapter{HTTPClient: config.HTTPClient{MaxIdleConnsPerHost: 20, MaxConnsPerHost: 30}}
+
+	bidderClient, err := newBidderClient(client, adapterCfg, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	transport := bidderClient.Transport.(*http.Transport)
+	assert.Equal(t, 20, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 30, transport.MaxConnsPerHost)
+	assert.Equal(t, 50, transport.MaxIdleConns, "Settings left at 0 should be those of the shared client")
+	assert.Equal(t, "shared", transport.TLSClientConfig.ServerName)
+	assert.Equal(t, time.Second, bidderClient.Timeout)
+	assert.Equal(t, 5, client.Transport.(*http.Transport).MaxIdleConnsPerHost, "The shared client should be left alone")
+}
+
+func TestNewBidderClientMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "biddertls")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	clientCert := writeTestCertificate(t, dir, "client")
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA := filepath.Join(dir, "server-ca.pem")
+	err = ioutil.WriteFile(serverCA, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	testCases := []struct {
+		description string
+		tlsCfg      config.AdapterTLS
+		expectError bool
+	}{
+		{
+			description: "The bidder's CA and client certificate should be used",
+			tlsCfg: config.AdapterTLS{
+				CAFile:     serverCA,
+				CertFile:   filepath.Join(dir, "client.pem"),
+				KeyFile:    filepath.Join(dir, "client-key.pem"),
+				MinVersion: "1.2",
+				ServerName: "example.com",
+			},
+		},
+		{
+			description: "The server should reject clients without certificate",
+			tlsCfg:      config.AdapterTLS{CAFile: serverCA},
+			expectError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		bidderClient, err := newBidderClient(&http.Client{}, config.Adapter{TLS: test.tlsCfg}, "")
+		if !assert.NoError(t, err, test.description) {
+			continue
+		}
+		resp, err := bidderClient.Get(server.URL)
+		if test.expectError {
+			assert.Error(t, err, test.description)
+		} else if assert.NoError(t, err, test.description) {
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode, test.description)
+			resp.Body.Close()
+		}
+	}
+
+	_, err = newBidderClient(&http.Client{}, config.Adapter{TLS: config.AdapterTLS{CertFile: serverCA, KeyFile: serverCA}}, "")
+	assert.Error(t, err, "Bad client certificates should be reported")
+	_, err = newBidderClient(&http.Client{}, config.Adapter{TLS: config.AdapterTLS{CAFile: filepath.Join(dir, "missing.pem")}}, "")
+	assert.Error(t, err, "Missing CA files should be reported")
+}
+
+func TestNewBidderClientHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+	shared := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}}
+
+	testCases := []struct {
+		description   string
+		adapterCfg    config.Adapter
+		expectedProto int
+	}{
+		{
+			description:   "HTTP/2 should be negotiated with the bidders which enable it",
+			adapterCfg:    config.Adapter{HTTP2: true},
+			expectedProto: 2,
+		},
+		{
+			description:   "The shared client's TLS config should keep the other bidders on HTTP/1.1",
+			adapterCfg:    config.Adapter{HTTPClient: config.HTTPClient{MaxIdleConns: 10}},
+			expectedProto: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		bidderClient, err := newBidderClient(shared, test.adapterCfg, "")
+		if !assert.NoError(t, err, test.description) {
+			continue
+		}
+		resp, err := bidderClient.Get(server.URL)
+		if assert.NoError(t, err, test.description) {
+			assert.Equal(t, test.expectedProto, resp.ProtoMajor, test.description)
+			resp.Body.Close()
+		}
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate and its key to {name}.pem and {name}-key.pem in dir.
+func writeTestCertificate(t *testing.T, dir string, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create a certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal a key: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write a certificate: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("Failed to write a key: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse a certificate: %v", err)
+	}
+	return cert
+}
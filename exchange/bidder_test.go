@@ -2,6 +2,7 @@ package exchange
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -19,6 +20,7 @@ import (
 	"github.com/prebid/prebid-server/adapters"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	metricsConf "github.com/prebid/prebid-server/pbsmetrics/config"
@@ -76,7 +78,7 @@ func TestSingleBidder(t *testing.T) {
 	}
 	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", bidAdjustment, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", bidAdjustment, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 
 	// Make sure the goodSingleBidder was called with the expected arguments.
 	if bidderImpl.httpResponse == nil {
@@ -122,6 +124,88 @@ func TestSingleBidder(t *testing.T) {
 	}
 }
 
+// TestBidAdjustmentRules makes sure bid adjustment rules are applied in the bidder's currency,
+// before the bid adjustment factor and the conversion to the request's currency.
+func TestBidAdjustmentRules(t *testing.T) {
+	server := httptest.NewServer(mockHandler(200, "getBody", "{\"bid\":false}"))
+	defer server.Close()
+
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Body:    []byte("{\"key\":\"val\"}"),
+			Headers: http.Header{},
+		},
+		bidResponse: &adapters.BidderResponse{
+			Currency: "EUR",
+			Bids: []*adapters.TypedBid{
+				{Bid: &openrtb.Bid{ID: "banner", Price: 3}, BidType: openrtb_ext.BidTypeBanner},
+				{Bid: &openrtb.Bid{ID: "video", Price: 3}, BidType: openrtb_ext.BidTypeVideo},
+			},
+		},
+	}
+	rules := &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment{
+			"banner": {"appnexus": {"*": {{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 1, Currency: "USD"}}}},
+		},
+	}
+	rates := currencies.NewRates(time.Now(), map[string]map[string]float64{
+		"EUR": {"USD": 2},
+	})
+
+	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1.5, rules, rates, &adapters.ExtraRequestInfo{})
+
+	assert.Empty(t, errs)
+	if assert.Len(t, seatBid.bids, 2) {
+		assert.InDelta(t, 7.5, seatBid.bids[0].bid.Price, 0.0001, "The banner bid should be adjusted by 0.50 EUR before conversion")
+		assert.InDelta(t, 9, seatBid.bids[1].bid.Price, 0.0001, "The video bid has no matching rules")
+	}
+}
+
+// TestBidAdjustmentRulesFailure makes sure the bids whose adjustments can't be applied are dropped with a warning,
+// rather than entering the auction at their unadjusted price.
+func TestBidAdjustmentRulesFailure(t *testing.T) {
+	server := httptest.NewServer(mockHandler(200, "getBody", "{\"bid\":false}"))
+	defer server.Close()
+
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Body:    []byte("{\"key\":\"val\"}"),
+			Headers: http.Header{},
+		},
+		bidResponse: &adapters.BidderResponse{
+			Currency: "USD",
+			Bids: []*adapters.TypedBid{
+				{Bid: &openrtb.Bid{ID: "banner", Price: 3}, BidType: openrtb_ext.BidTypeBanner},
+				{Bid: &openrtb.Bid{ID: "video", Price: 3}, BidType: openrtb_ext.BidTypeVideo},
+			},
+		},
+	}
+	rules := &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment{
+			"banner": {"appnexus": {"*": {{AdjType: openrtb_ext.BidAdjustmentTypeCPM, Value: 1, Currency: "GBP"}}}},
+		},
+	}
+	rates := currencies.NewRates(time.Now(), map[string]map[string]float64{
+		"EUR": {"USD": 2},
+	})
+
+	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1, rules, rates, &adapters.ExtraRequestInfo{})
+
+	if assert.Len(t, errs, 1) {
+		assert.IsType(t, &errortypes.BidAdjustmentFailed{}, errs[0])
+		assert.Equal(t, errortypes.SeverityWarning, errs[0].(errortypes.Coder).Severity())
+	}
+	if assert.Len(t, seatBid.bids, 1, "The banner bid should be dropped") {
+		assert.Equal(t, "video", seatBid.bids[0].bid.ID)
+	}
+}
+
 // TestMultiBidder makes sure all the requests get sent, and the responses processed.
 // Because this is done in parallel, it should be run under the race detector.
 func TestMultiBidder(t *testing.T) {
@@ -164,7 +248,7 @@ func TestMultiBidder(t *testing.T) {
 	}
 	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 
 	if seatBid == nil {
 		t.Fatalf("SeatBid should exist, because bids exist.")
@@ -538,6 +622,7 @@ func TestMultiCurrencies(t *testing.T) {
 			&openrtb.BidRequest{},
 			"test",
 			1,
+			nil,
 			currencyConverter.Rates(),
 			&adapters.ExtraRequestInfo{},
 		)
@@ -682,6 +767,7 @@ func TestMultiCurrencies_RateConverterNotSet(t *testing.T) {
 			&openrtb.BidRequest{},
 			"test",
 			1,
+			nil,
 			currencyConverter.Rates(),
 			&adapters.ExtraRequestInfo{},
 		)
@@ -747,8 +833,8 @@ func TestMultiCurrencies_RequestCurrencyPick(t *testing.T) {
 		{
 			bidRequestCurrencies:   []string{"CNY", "USD", "EUR", "JPY"},
 			bidResponsesCurrency:   "USD",
-			expectedPickedCurrency: "USD",
-			expectedError:          false,
+			expectedPickedCurrency: "",
+			expectedError:          true,
 			rates: currencies.Rates{
 				DataAsOf: time.Now(),
 				Conversions: map[string]map[string]float64{
@@ -763,7 +849,7 @@ func TestMultiCurrencies_RequestCurrencyPick(t *testing.T) {
 					},
 				},
 			},
-			description: "Case 3 - First allowed currencies in bid request is not known but the others are, second one is picked",
+			description: "Case 3 - First allowed currencies in bid request is not known but the others are, an error is returned",
 		},
 		{
 			bidRequestCurrencies:   []string{"CNY", "EUR", "JPY"},
@@ -779,13 +865,13 @@ func TestMultiCurrencies_RequestCurrencyPick(t *testing.T) {
 		{
 			bidRequestCurrencies:   []string{"CNY", "EUR", "JPY", "USD"},
 			bidResponsesCurrency:   "USD",
-			expectedPickedCurrency: "USD",
-			expectedError:          false,
+			expectedPickedCurrency: "",
+			expectedError:          true,
 			rates: currencies.Rates{
 				DataAsOf:    time.Now(),
 				Conversions: map[string]map[string]float64{},
 			},
-			description: "Case 5 - None allowed currencies in bid request are known but the default one (`USD`), no rates are set but default currency will be picked",
+			description: "Case 5 - Bids are only converted to the first allowed currency, even if a later one matches the bid currency",
 		},
 		{
 			bidRequestCurrencies:   nil,
@@ -854,6 +940,7 @@ func TestMultiCurrencies_RequestCurrencyPick(t *testing.T) {
 			},
 			"test",
 			1,
+			nil,
 			currencyConverter.Rates(),
 			&adapters.ExtraRequestInfo{},
 		)
@@ -1028,6 +1115,7 @@ func TestMobileNativeTypes(t *testing.T) {
 			tc.mockBidderRequest,
 			"test",
 			1.0,
+			nil,
 			currencyConverter.Rates(),
 			&adapters.ExtraRequestInfo{},
 		)
@@ -1043,7 +1131,7 @@ func TestMobileNativeTypes(t *testing.T) {
 func TestErrorReporting(t *testing.T) {
 	bidder := adaptBidder(&bidRejector{}, nil, &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	bids, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	bids, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 	if bids != nil {
 		t.Errorf("There should be no seatbid if no http requests are returned.")
 	}
@@ -1222,11 +1310,13 @@ func TestCallRecordAdapterConnections(t *testing.T) {
 	compareConnWaitTime := func(dur time.Duration) bool { return dur.Nanoseconds() > 0 }
 
 	metrics.On("RecordAdapterConnections", expectedAdapterName, false, mock.MatchedBy(compareConnWaitTime)).Once()
+	metrics.On("RecordAdapterRequestSize", expectedAdapterName, len(bidderImpl.httpRequest.Body)).Once()
+	metrics.On("RecordAdapterResponseSize", expectedAdapterName, len(respBody)).Once()
 
 	// Run requestBid using an http.Client with a mock handler
 	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, metrics, openrtb_ext.BidderAppnexus)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	_, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", bidAdjustment, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	_, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", bidAdjustment, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 
 	// Assert no errors
 	assert.Equal(t, 0, len(errs), "bidder.requestBid returned errors %v \n", errs)
@@ -1235,6 +1325,189 @@ func TestCallRecordAdapterConnections(t *testing.T) {
 	metrics.AssertExpectations(t)
 }
 
+func TestBidderRecordsLatency(t *testing.T) {
+	server := httptest.NewServer(mockHandler(200, "getBody", "{\"bid\":false}"))
+	defer server.Close()
+
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Body:    []byte("{\"key\":\"val\"}"),
+			Headers: http.Header{},
+		},
+		bidResponse: &adapters.BidderResponse{},
+	}
+	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+	bidder.latency = newLatencyTracker(10)
+
+	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
+	_, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	assert.Empty(t, errs)
+
+	latency, samples := bidder.latency.percentile(100)
+	assert.Equal(t, 1, samples)
+	assert.True(t, latency > 0, "The response time should be recorded")
+}
+
+func TestBidderGzipRequests(t *testing.T) {
+	var receivedEncoding string
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedEncoding = r.Header.Get("Content-Encoding")
+		if reader, err := gzip.NewReader(r.Body); err == nil {
+			receivedBody, _ = ioutil.ReadAll(reader)
+		}
+		w.Write([]byte("{\"bid\":false}"))
+	}))
+	defer server.Close()
+
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Body:    []byte("{\"key\":\"val\"}"),
+			Headers: http.Header{"Content-Type": []string{"application/json"}},
+		},
+		bidResponse: &adapters.BidderResponse{},
+	}
+	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+	bidder.gzipRequests = true
+
+	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
+	_, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	assert.Empty(t, errs)
+
+	assert.Equal(t, "gzip", receivedEncoding)
+	assert.Equal(t, "{\"key\":\"val\"}", string(receivedBody), "The bidder should receive the body the adapter made")
+	assert.Empty(t, bidderImpl.httpRequest.Headers.Get("Content-Encoding"), "The adapter's headers shouldn't be changed")
+}
+
+func TestBidderOpenRTB26(t *testing.T) {
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"seatbid":[{"bid":[{"id":"bid1","mtype":2,"dur":30}]}]}`))
+	}))
+	defer server.Close()
+
+	gdpr := int8(1)
+	for _, openRTB26 := range []bool{false, true} {
+		bidderImpl := &goodSingleBidder{
+			httpRequest: &adapters.RequestData{
+				Method:  "POST",
+				Uri:     server.URL,
+				Body:    []byte(`custom|format`),
+				Headers: http.Header{},
+			},
+			bidResponse: &adapters.BidderResponse{},
+		}
+		bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+		bidder.openRTB26 = openRTB26
+
+		request := &openrtb.BidRequest{ID: "req", Regs: &openrtb.Regs{Ext: json.RawMessage(`{"gdpr":1}`)}}
+		currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
+		_, errs := bidder.requestBid(context.Background(), request, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+		assert.Empty(t, errs)
+
+		assert.Equal(t, "custom|format", string(receivedBody), "The adapter's request body shouldn't be changed")
+		assert.JSONEq(t, `{"seatbid":[{"bid":[{"id":"bid1","mtype":2,"dur":30}]}]}`, string(bidderImpl.httpResponse.Body), "The bidder's response body shouldn't be changed")
+		assert.Same(t, request, bidderImpl.bidRequest, "The adapter should get the 2.5 request")
+		if !openRTB26 {
+			assert.Nil(t, bidderImpl.reqInfo.OpenRTB26Request, "2.5 bidders don't get a 2.6 request")
+		} else if assert.NotNil(t, bidderImpl.reqInfo.OpenRTB26Request, "2.6 bidders should get a 2.6 request") {
+			assert.Equal(t, &gdpr, bidderImpl.reqInfo.OpenRTB26Request.Regs.GDPR)
+			assert.Empty(t, bidderImpl.reqInfo.OpenRTB26Request.Regs.Ext)
+		}
+	}
+}
+
+func TestBidderNative11(t *testing.T) {
+	server := httptest.NewServer(mockHandler(200, "getBody", "responseJson"))
+	defer server.Close()
+
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Headers: http.Header{},
+		},
+		bidResponse: &adapters.BidderResponse{
+			Bids: []*adapters.TypedBid{{
+				Bid: &openrtb.Bid{
+					ID:    "bid1",
+					ImpID: "imp1",
+					AdM:   `{"native":{"ver":"1.1","assets":[{"id":1,"title":{"text":"Buy"}}],"link":{"url":"http://click"},"imptrackers":["http://imp"]}}`,
+				},
+				BidType: openrtb_ext.BidTypeNative,
+			}},
+		},
+	}
+	bidder := adaptBidder(bidderImpl, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+	bidder.native11 = true
+
+	nativeRequest := `{"ver":"1.2","assets":[{"id":1,"title":{"len":90}}],"eventtrackers":[{"event":1,"methods":[1]}]}`
+	request := &openrtb.BidRequest{
+		App: &openrtb.App{},
+		Imp: []openrtb.Imp{{ID: "imp1", Native: &openrtb.Native{Request: nativeRequest, Ver: "1.2"}}},
+	}
+	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
+	seatBid, errs := bidder.requestBid(context.Background(), request, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	assert.Empty(t, errs)
+
+	if assert.NotNil(t, bidderImpl.bidRequest) {
+		assert.JSONEq(t, `{"native":{"ver":"1.1","assets":[{"id":1,"title":{"len":90}}]}}`, bidderImpl.bidRequest.Imp[0].Native.Request, "The adapter should get a 1.1 native request")
+		assert.Equal(t, "1.1", bidderImpl.bidRequest.Imp[0].Native.Ver)
+	}
+	assert.Equal(t, nativeRequest, request.Imp[0].Native.Request, "The exchange's request should be left alone")
+	if assert.Len(t, seatBid.bids, 1) {
+		assert.JSONEq(t, `{"assets":[{"id":1,"title":{"text":"Buy"}}],"link":{"url":"http://click"},"eventtrackers":[{"event":1,"method":1,"url":"http://imp"}],"ver":"1.2"}`, seatBid.bids[0].bid.AdM, "The markup should be converted to 1.2")
+	}
+}
+
+func TestBidderCircuitBreaker(t *testing.T) {
+	requestsReceived := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsReceived++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Body:    []byte("{\"key\":\"val\"}"),
+			Headers: http.Header{},
+		},
+		bidResponse: &adapters.BidderResponse{},
+	}
+	cfg := &config.Configuration{
+		CircuitBreaker: config.CircuitBreaker{
+			Enabled:            true,
+			WindowSize:         10,
+			MinRequests:        2,
+			ErrorRatio:         1,
+			OpenIntervalMillis: 60000,
+		},
+	}
+	bidder := adaptBidder(bidderImpl, server.Client(), cfg, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
+
+	for i := 0; i < 2; i++ {
+		_, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+		if assert.Len(t, errs, 1) {
+			assert.Equal(t, errortypes.BadServerResponseErrorCode, errortypes.ReadCode(errs[0]))
+		}
+	}
+
+	_, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, errortypes.CircuitBreakerOpenErrorCode, errortypes.ReadCode(errs[0]), "The open circuit breaker should be reported")
+	}
+	assert.Equal(t, 2, requestsReceived, "No request should be sent while the circuit breaker is open")
+}
+
 type DNSDoneTripper struct{}
 
 func (DNSDoneTripper) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -1256,6 +1529,8 @@ func TestCallRecordRecordDNSTime(t *testing.T) {
 	// setup a mock metrics engine and its expectation
 	metricsMock := &pbsmetrics.MetricsEngineMock{}
 	metricsMock.Mock.On("RecordDNSTime", mock.Anything).Return()
+	metricsMock.Mock.On("RecordAdapterRequestSize", mock.Anything, mock.Anything).Return()
+	metricsMock.Mock.On("RecordAdapterResponseSize", mock.Anything, mock.Anything).Return()
 
 	// Instantiate the bidder that will send the request. We'll make sure to use an
 	// http.Client that runs our mock RoundTripper so DNSDone(httptrace.DNSDoneInfo{})
@@ -1379,6 +1654,7 @@ func wrapWithBidderInfo(bidder adapters.Bidder) adapters.Bidder {
 
 type goodSingleBidder struct {
 	bidRequest   *openrtb.BidRequest
+	reqInfo      *adapters.ExtraRequestInfo
 	httpRequest  *adapters.RequestData
 	httpResponse *adapters.ResponseData
 	bidResponse  *adapters.BidderResponse
@@ -1386,6 +1662,7 @@ type goodSingleBidder struct {
 
 func (bidder *goodSingleBidder) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
 	bidder.bidRequest = request
+	bidder.reqInfo = reqInfo
 	return []*adapters.RequestData{bidder.httpRequest}, nil
 }
 
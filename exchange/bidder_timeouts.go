@@ -0,0 +1,128 @@
+package exchange
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// latencyTracker keeps a rolling window of the most recent response times of a bidder. The requests which
+// time out are recorded with the time they were given, so that a bidder whose timeout was cut gets its full
+// budget back once the cut requests fill the window.
+// A nil tracker ignores the samples.
+type latencyTracker struct {
+	mutex   sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func newLatencyTracker(size int) *latencyTracker {
+	return &latencyTracker{
+		samples: make([]time.Duration, 0, size),
+	}
+}
+
+func (tracker *latencyTracker) record(latency time.Duration) {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if len(tracker.samples) < cap(tracker.samples) {
+		tracker.samples = append(tracker.samples, latency)
+		return
+	}
+	tracker.samples[tracker.next] = latency
+	tracker.next = (tracker.next + 1) % len(tracker.samples)
+}
+
+// percentile returns the latency which the given percent of the samples don't exceed,
+// along with the number of samples it was computed from.
+func (tracker *latencyTracker) percentile(percent float64) (time.Duration, int) {
+	if tracker == nil {
+		return 0, 0
+	}
+	tracker.mutex.Lock()
+	sorted := make([]time.Duration, len(tracker.samples))
+	copy(sorted, tracker.samples)
+	tracker.mutex.Unlock()
+
+	if len(sorted) == 0 {
+		return 0, 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(math.Ceil(percent/100*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index], len(sorted)
+}
+
+// bidderTimeouts works out how much of the time left in the auction each bidder gets.
+// A nil bidderTimeouts leaves the auction deadline and tmax untouched.
+type bidderTimeouts struct {
+	cfg           config.BidderTimeouts
+	networkBuffer time.Duration
+	// trackers is filled in while the adapter map is built, and only read afterwards.
+	trackers map[openrtb_ext.BidderName]*latencyTracker
+}
+
+func newBidderTimeouts(cfg config.BidderTimeouts) *bidderTimeouts {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &bidderTimeouts{
+		cfg:           cfg,
+		networkBuffer: time.Duration(cfg.NetworkBufferMillis) * time.Millisecond,
+		trackers:      make(map[openrtb_ext.BidderName]*latencyTracker),
+	}
+}
+
+// trackerFor creates the latency tracker of a bidder. It must not be called once auctions are running.
+func (timeouts *bidderTimeouts) trackerFor(bidder openrtb_ext.BidderName) *latencyTracker {
+	if timeouts == nil {
+		return nil
+	}
+	tracker, ok := timeouts.trackers[bidder]
+	if !ok {
+		tracker = newLatencyTracker(timeouts.cfg.SampleSize)
+		timeouts.trackers[bidder] = tracker
+	}
+	return tracker
+}
+
+// bidderContext sets the tmax sent to the bidder to its share of the time left in the auction, and returns a
+// context which gives up on the bidder once that time plus the network buffer has passed.
+func (timeouts *bidderTimeouts) bidderContext(ctx context.Context, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) (context.Context, context.CancelFunc) {
+	if timeouts == nil {
+		return ctx, func() {}
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}
+	}
+	budget := timeouts.budget(bidder, time.Until(deadline))
+	request.TMax = int64(budget / time.Millisecond)
+	return context.WithTimeout(ctx, budget+timeouts.networkBuffer)
+}
+
+// budget returns how long the bidder may take to answer, out of the time remaining in the auction.
+// Bidders keep the full budget unless their latency percentile is over it.
+func (timeouts *bidderTimeouts) budget(bidder openrtb_ext.BidderName, remaining time.Duration) time.Duration {
+	budget := remaining - timeouts.networkBuffer
+	if budget <= 0 {
+		return 0
+	}
+	latency, samples := timeouts.trackers[bidder].percentile(timeouts.cfg.LatencyPercentile)
+	if samples < timeouts.cfg.MinSamples || latency <= budget {
+		return budget
+	}
+	return budget * time.Duration(timeouts.cfg.SlowBidderPercent) / 100
+}
@@ -0,0 +1,158 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyTrackerRollingWindow(t *testing.T) {
+	tracker := newLatencyTracker(4)
+	for _, millis := range []int{100, 200, 300, 400} {
+		tracker.record(time.Duration(millis) * time.Millisecond)
+	}
+
+	latency, samples := tracker.percentile(50)
+	assert.Equal(t, 200*time.Millisecond, latency)
+	assert.Equal(t, 4, samples)
+
+	// The two oldest samples should be replaced
+	tracker.record(500 * time.Millisecond)
+	tracker.record(600 * time.Millisecond)
+
+	latency, samples = tracker.percentile(50)
+	assert.Equal(t, 400*time.Millisecond, latency)
+	assert.Equal(t, 4, samples)
+
+	latency, _ = tracker.percentile(100)
+	assert.Equal(t, 600*time.Millisecond, latency)
+}
+
+func TestLatencyTrackerNil(t *testing.T) {
+	var tracker *latencyTracker
+	tracker.record(time.Second)
+
+	latency, samples := tracker.percentile(90)
+	assert.Equal(t, time.Duration(0), latency)
+	assert.Equal(t, 0, samples)
+}
+
+func TestBidderTimeoutsBudget(t *testing.T) {
+	timeouts := newBidderTimeouts(config.BidderTimeouts{
+		Enabled:             true,
+		NetworkBufferMillis: 50,
+		LatencyPercentile:   90,
+		SampleSize:          10,
+		MinSamples:          5,
+		SlowBidderPercent:   50,
+	})
+	record := func(bidder openrtb_ext.BidderName, latency time.Duration, count int) {
+		tracker := timeouts.trackerFor(bidder)
+		for i := 0; i < count; i++ {
+			tracker.record(latency)
+		}
+	}
+	record("fast", 100*time.Millisecond, 10)
+	record("slow", 900*time.Millisecond, 10)
+	record("timingOut", time.Second, 10)
+	record("new", 900*time.Millisecond, 4)
+
+	testCases := []struct {
+		description    string
+		bidder         openrtb_ext.BidderName
+		remaining      time.Duration
+		expectedBudget time.Duration
+	}{
+		{"Fast bidders keep the full budget", "fast", time.Second, 950 * time.Millisecond},
+		{"Slow bidders are cut", "slow", 500 * time.Millisecond, 225 * time.Millisecond},
+		{"Slow bidders keep the full budget if they fit in it", "slow", time.Second, 950 * time.Millisecond},
+		{"Bidders which time out are cut", "timingOut", time.Second, 475 * time.Millisecond},
+		{"Bidders without enough samples keep the full budget", "new", 500 * time.Millisecond, 450 * time.Millisecond},
+		{"Unknown bidders keep the full budget", "unknown", 500 * time.Millisecond, 450 * time.Millisecond},
+		{"No time left", "fast", 40 * time.Millisecond, 0},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expectedBudget, timeouts.budget(test.bidder, test.remaining), test.description)
+	}
+}
+
+func TestBidderTimeoutsRecovery(t *testing.T) {
+	timeouts := newBidderTimeouts(config.BidderTimeouts{
+		Enabled:             true,
+		NetworkBufferMillis: 50,
+		LatencyPercentile:   90,
+		SampleSize:          10,
+		MinSamples:          5,
+		SlowBidderPercent:   50,
+	})
+	tracker := timeouts.trackerFor("appnexus")
+
+	// The requests time out with the full budget, and are recorded with the time they were given
+	for i := 0; i < 10; i++ {
+		tracker.record(time.Second)
+	}
+	assert.Equal(t, 475*time.Millisecond, timeouts.budget("appnexus", time.Second), "The bidder should be cut once it times out")
+
+	// The cut requests time out too, but in less than the full budget
+	for i := 0; i < 9; i++ {
+		tracker.record(525 * time.Millisecond)
+	}
+	assert.Equal(t, 950*time.Millisecond, timeouts.budget("appnexus", time.Second), "The bidder should get its full budget back")
+
+	for i := 0; i < 10; i++ {
+		tracker.record(200 * time.Millisecond)
+	}
+	assert.Equal(t, 950*time.Millisecond, timeouts.budget("appnexus", time.Second), "The bidder should keep its full budget once it's fast again")
+}
+
+func TestBidderContext(t *testing.T) {
+	timeouts := newBidderTimeouts(config.BidderTimeouts{
+		Enabled:             true,
+		NetworkBufferMillis: 100,
+		LatencyPercentile:   90,
+		SampleSize:          10,
+		MinSamples:          1,
+		SlowBidderPercent:   50,
+	})
+	timeouts.trackerFor("slow").record(2 * time.Second)
+
+	auctionCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	auctionDeadline, _ := auctionCtx.Deadline()
+
+	request := &openrtb.BidRequest{TMax: 1000}
+	bidderCtx, bidderCancel := timeouts.bidderContext(auctionCtx, "slow", request)
+	defer bidderCancel()
+
+	bidderDeadline, ok := bidderCtx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, bidderDeadline.Before(auctionDeadline), "The slow bidder's deadline should be before the auction's")
+	assert.InDelta(t, 450, request.TMax, 10, "The slow bidder should be sent half of the budget")
+
+	request = &openrtb.BidRequest{TMax: 1000}
+	bidderCtx, bidderCancel = timeouts.bidderContext(context.Background(), "slow", request)
+	defer bidderCancel()
+	assert.Equal(t, context.Background(), bidderCtx, "Auctions without a deadline shouldn't be changed")
+	assert.Equal(t, int64(1000), request.TMax)
+}
+
+func TestBidderContextDisabled(t *testing.T) {
+	timeouts := newBidderTimeouts(config.BidderTimeouts{Enabled: false})
+	assert.Nil(t, timeouts)
+	assert.Nil(t, timeouts.trackerFor("appnexus"))
+
+	auctionCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	request := &openrtb.BidRequest{TMax: 1000}
+
+	bidderCtx, bidderCancel := timeouts.bidderContext(auctionCtx, "appnexus", request)
+	defer bidderCancel()
+	assert.Equal(t, auctionCtx, bidderCtx)
+	assert.Equal(t, int64(1000), request.TMax)
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
 	"golang.org/x/text/currency"
 )
 
@@ -28,8 +32,8 @@ type validatedBidder struct {
 	bidder adaptedBidder
 }
 
-func (v *validatedBidder) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
-	seatBid, errs := v.bidder.requestBid(ctx, request, name, bidAdjustment, conversions, reqInfo)
+func (v *validatedBidder) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, adjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
+	seatBid, errs := v.bidder.requestBid(ctx, request, name, bidAdjustment, adjustmentRules, conversions, reqInfo)
 	if validationErrors := removeInvalidBids(request, seatBid); len(validationErrors) > 0 {
 		errs = append(errs, validationErrors...)
 	}
@@ -62,6 +66,258 @@ func removeInvalidBids(request *openrtb.BidRequest, seatBid *pbsOrtbSeatBid) []e
 	return errs
 }
 
+// validateBidsForAccount runs the validations which the account can configure on the bids of a single bidder,
+// and records a metric for every bid which fails one of them.
+func (e *exchange) validateBidsForAccount(request *openrtb.BidRequest, seatBid *pbsOrtbSeatBid, validations config.AccountBidValidations, labels pbsmetrics.AdapterLabels) []error {
+	var errs []error
+
+	blockedMode := validationModeOrDefault(validations.BlockedCreatives, config.ValidationEnforce)
+	blockErrs := removeBlockedBids(request, seatBid, blockedMode)
+	for range blockErrs {
+		e.me.RecordAdapterBlockedBid(labels, blockedMode == config.ValidationEnforce)
+	}
+	errs = append(errs, blockErrs...)
+
+	sizeMode := validationModeOrDefault(validations.BannerCreativeSize, config.ValidationSkip)
+	sizeErrs := removeBadSizeBanners(request, seatBid, sizeMode)
+	for range sizeErrs {
+		e.me.RecordAdapterBadCreativeSize(labels, sizeMode == config.ValidationEnforce)
+	}
+	errs = append(errs, sizeErrs...)
+
+	secureMode := validationModeOrDefault(validations.SecureMarkup, config.ValidationSkip)
+	secureErrs := removeInsecureBids(request, seatBid, secureMode)
+	for range secureErrs {
+		e.me.RecordAdapterInsecureMarkup(labels, secureMode == config.ValidationEnforce)
+	}
+	errs = append(errs, secureErrs...)
+
+	dealMode := validationModeOrDefault(validations.PrivateDeals, config.ValidationEnforce)
+	dealErrs := removeNonDealBids(request, seatBid, dealMode)
+	for range dealErrs {
+		e.me.RecordAdapterDealViolation(labels, dealMode == config.ValidationEnforce)
+	}
+	errs = append(errs, dealErrs...)
+
+	return errs
+}
+
+func validationModeOrDefault(mode config.ValidationMode, defaultMode config.ValidationMode) config.ValidationMode {
+	if mode == "" {
+		return defaultMode
+	}
+	return mode
+}
+
+// bidCheck describes why the bid fails a validation, or returns an empty string if it passes.
+type bidCheck func(bid *pbsOrtbBid) string
+
+// applyBidCheck runs the check on every bid of the seat. In enforce mode, the failing bids are removed. In warn mode
+// they are kept. In skip mode the check doesn't run.
+//
+// It returns one warning per failing bid, made by newWarning.
+func applyBidCheck(seatBid *pbsOrtbSeatBid, mode config.ValidationMode, check bidCheck, newWarning func(string) error) []error {
+	// Exit early if there is nothing to do.
+	if mode == config.ValidationSkip || seatBid == nil || len(seatBid.bids) == 0 {
+		return nil
+	}
+
+	errs := make([]error, 0)
+	validBids := make([]*pbsOrtbBid, 0, len(seatBid.bids))
+	for _, bid := range seatBid.bids {
+		reason := check(bid)
+		if reason == "" {
+			validBids = append(validBids, bid)
+			continue
+		}
+		if mode == config.ValidationWarn {
+			errs = append(errs, newWarning(fmt.Sprintf("Bid \"%s\" should have been rejected because %s", bid.bid.ID, reason)))
+			validBids = append(validBids, bid)
+			continue
+		}
+		errs = append(errs, newWarning(fmt.Sprintf("Bid \"%s\" was rejected because %s", bid.bid.ID, reason)))
+	}
+	seatBid.bids = validBids
+	return errs
+}
+
+// removeBlockedBids checks the bids against the block lists of the request which was sent to the bidder.
+// Bids with a blocked advertiser domain (badv), category (bcat) or creative attribute (battr) fail the check.
+func removeBlockedBids(request *openrtb.BidRequest, seatBid *pbsOrtbSeatBid, mode config.ValidationMode) []error {
+	blockedAttrs := make(map[string][]openrtb.CreativeAttribute, len(request.Imp))
+	for _, imp := range request.Imp {
+		var attrs []openrtb.CreativeAttribute
+		if imp.Banner != nil {
+			attrs = append(attrs, imp.Banner.BAttr...)
+		}
+		if imp.Video != nil {
+			attrs = append(attrs, imp.Video.BAttr...)
+		}
+		if imp.Audio != nil {
+			attrs = append(attrs, imp.Audio.BAttr...)
+		}
+		blockedAttrs[imp.ID] = attrs
+	}
+
+	return applyBidCheck(seatBid, mode,
+		func(bid *pbsOrtbBid) string {
+			return findBlockedField(bid.bid, request.BAdv, request.BCat, blockedAttrs[bid.bid.ImpID])
+		},
+		func(message string) error { return &errortypes.BlockedBid{Message: message} })
+}
+
+// findBlockedField describes the first field of the bid which is blocked by the request, or returns an empty string.
+// Categories block their subcategories, and advertiser domains block their subdomains.
+func findBlockedField(bid *openrtb.Bid, badv []string, bcat []string, battr []openrtb.CreativeAttribute) string {
+	for _, domain := range bid.ADomain {
+		domain = strings.ToLower(domain)
+		for _, blocked := range badv {
+			blocked = strings.ToLower(blocked)
+			if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+				return fmt.Sprintf("its advertiser domain '%s' is blocked by badv", domain)
+			}
+		}
+	}
+	for _, cat := range bid.Cat {
+		for _, blocked := range bcat {
+			if cat == blocked || strings.HasPrefix(cat, blocked+"-") {
+				return fmt.Sprintf("its category '%s' is blocked by bcat", cat)
+			}
+		}
+	}
+	for _, attr := range bid.Attr {
+		for _, blocked := range battr {
+			if attr == blocked {
+				return fmt.Sprintf("its creative attribute %d is blocked by battr", attr)
+			}
+		}
+	}
+	return ""
+}
+
+// removeBadSizeBanners checks that banner bids match one of the imp's sizes. Interstitial imps accept any creative
+// which fits in their largest size, or in the device screen if they don't have one. Bids which don't declare
+// their size can't be checked, so they pass.
+func removeBadSizeBanners(request *openrtb.BidRequest, seatBid *pbsOrtbSeatBid, mode config.ValidationMode) []error {
+	imps := make(map[string]*openrtb.Imp, len(request.Imp))
+	for i := range request.Imp {
+		imps[request.Imp[i].ID] = &request.Imp[i]
+	}
+
+	return applyBidCheck(seatBid, mode,
+		func(bid *pbsOrtbBid) string {
+			if bid.bidType != openrtb_ext.BidTypeBanner || (bid.bid.W == 0 && bid.bid.H == 0) {
+				return ""
+			}
+			imp, ok := imps[bid.bid.ImpID]
+			if !ok || imp.Banner == nil {
+				return ""
+			}
+			if bannerSizeAllowed(imp, request.Device, bid.bid.W, bid.bid.H) {
+				return ""
+			}
+			return fmt.Sprintf("its size %dx%d doesn't match any of the sizes of imp \"%s\"", bid.bid.W, bid.bid.H, imp.ID)
+		},
+		func(message string) error { return &errortypes.InvalidCreative{Message: message} })
+}
+
+func bannerSizeAllowed(imp *openrtb.Imp, device *openrtb.Device, w uint64, h uint64) bool {
+	formats := imp.Banner.Format
+	if imp.Banner.W != nil && imp.Banner.H != nil {
+		formats = append([]openrtb.Format{{W: *imp.Banner.W, H: *imp.Banner.H}}, formats...)
+	}
+
+	if imp.Instl == 1 {
+		var maxW, maxH uint64
+		for _, format := range formats {
+			if format.W > maxW {
+				maxW = format.W
+			}
+			if format.H > maxH {
+				maxH = format.H
+			}
+		}
+		// Same as processInterstitials: 1x1 means "use the device size"
+		if maxW < 2 && maxH < 2 && device != nil {
+			maxW, maxH = device.W, device.H
+		}
+		return maxW == 0 || maxH == 0 || (w <= maxW && h <= maxH)
+	}
+
+	if len(formats) == 0 {
+		return true
+	}
+	for _, format := range formats {
+		if format.W == w && format.H == h {
+			return true
+		}
+	}
+	return false
+}
+
+// removeInsecureBids checks that bids on secure imps don't load any resources over http.
+func removeInsecureBids(request *openrtb.BidRequest, seatBid *pbsOrtbSeatBid, mode config.ValidationMode) []error {
+	secureImps := make(map[string]bool, len(request.Imp))
+	for _, imp := range request.Imp {
+		secureImps[imp.ID] = imp.Secure != nil && *imp.Secure == 1
+	}
+
+	return applyBidCheck(seatBid, mode,
+		func(bid *pbsOrtbBid) string {
+			if !secureImps[bid.bid.ImpID] || !hasInsecureMarkup(bid.bid.AdM) {
+				return ""
+			}
+			return fmt.Sprintf("its adm loads insecure resources on secure imp \"%s\"", bid.bid.ImpID)
+		},
+		func(message string) error { return &errortypes.InvalidCreative{Message: message} })
+}
+
+// removeNonDealBids checks that bids on private auction imps are for one of the imp's deals.
+// If the imp doesn't list its deals, any bid with a dealid passes.
+func removeNonDealBids(request *openrtb.BidRequest, seatBid *pbsOrtbSeatBid, mode config.ValidationMode) []error {
+	privateImps := make(map[string]*openrtb.PMP, len(request.Imp))
+	for _, imp := range request.Imp {
+		if imp.PMP != nil && imp.PMP.PrivateAuction == 1 {
+			privateImps[imp.ID] = imp.PMP
+		}
+	}
+	if len(privateImps) == 0 {
+		return nil
+	}
+
+	return applyBidCheck(seatBid, mode,
+		func(bid *pbsOrtbBid) string {
+			pmp, ok := privateImps[bid.bid.ImpID]
+			if !ok {
+				return ""
+			}
+			if bid.bid.DealID == "" {
+				return fmt.Sprintf("it has no dealid on private auction imp \"%s\"", bid.bid.ImpID)
+			}
+			if len(pmp.Deals) == 0 {
+				return ""
+			}
+			for _, deal := range pmp.Deals {
+				if deal.ID == bid.bid.DealID {
+					return ""
+				}
+			}
+			return fmt.Sprintf("its dealid \"%s\" isn't one of the deals of private auction imp \"%s\"", bid.bid.DealID, bid.bid.ImpID)
+		},
+		func(message string) error { return &errortypes.BlockedBid{Message: message} })
+}
+
+// insecureResource matches the http URLs which get loaded when the markup renders: src, srcset and poster
+// attributes, the href of <link> elements, CSS url()s, and the VAST elements holding media files, impression and
+// tracking URLs. The other http URLs load nothing: the xmlns of SVG and VAST, the landing pages in the href of
+// anchors and in the VAST ClickThrough, and the click trackers, which are only fired on clicks.
+var insecureResource = regexp.MustCompile(`(?i)(?:\b(?:src|srcset|poster)\s*=\s*["']?|<link\b[^>]*?\bhref\s*=\s*["']?|url\(\s*["']?|<(?:mediafile|impression|tracking|error|staticresource|iframeresource|vastadtaguri)\b[^>]*>\s*(?:<!\[cdata\[)?)\s*http://`)
+
+// hasInsecureMarkup looks for the http resources of the markup
+func hasInsecureMarkup(adm string) bool {
+	return insecureResource.MatchString(adm)
+}
+
 // validateCurrency will run currency validation checks and return true if it passes, false otherwise.
 func validateCurrency(requestAllowedCurrencies []string, bidCurrency string) error {
 	// Default currency is `USD` by design.
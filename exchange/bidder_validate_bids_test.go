@@ -6,8 +6,11 @@ import (
 
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,7 +45,7 @@ func TestAllValidBids(t *testing.T) {
 			},
 		},
 	})
-	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1.0, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1.0, nil, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
 	assert.Len(t, seatBid.bids, 3)
 	assert.Len(t, errs, 0)
 }
@@ -83,7 +86,7 @@ func TestAllBadBids(t *testing.T) {
 			},
 		},
 	})
-	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1.0, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1.0, nil, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
 	assert.Len(t, seatBid.bids, 0)
 	assert.Len(t, errs, 5)
 }
@@ -126,7 +129,7 @@ func TestMixedBids(t *testing.T) {
 			},
 		},
 	})
-	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1.0, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, 1.0, nil, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
 	assert.Len(t, seatBid.bids, 2)
 	assert.Len(t, errs, 3)
 }
@@ -246,7 +249,7 @@ func TestCurrencyBids(t *testing.T) {
 			Cur: tc.brqCur,
 		}
 
-		seatBid, errs := bidder.requestBid(context.Background(), request, openrtb_ext.BidderAppnexus, 1.0, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
+		seatBid, errs := bidder.requestBid(context.Background(), request, openrtb_ext.BidderAppnexus, 1.0, nil, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{})
 		assert.Len(t, seatBid.bids, expectedValidBids)
 		assert.Len(t, errs, expectedErrs)
 	}
@@ -257,6 +260,303 @@ type mockAdaptedBidder struct {
 	errorResponse []error
 }
 
-func (b *mockAdaptedBidder) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
+func (b *mockAdaptedBidder) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, adjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
 	return b.bidResponse, b.errorResponse
 }
+
+func TestBlockedBids(t *testing.T) {
+	request := &openrtb.BidRequest{
+		BAdv: []string{"Ford.com"},
+		BCat: []string{"IAB25", "IAB7-39"},
+		Imp: []openrtb.Imp{
+			{ID: "bannerImp", Banner: &openrtb.Banner{BAttr: []openrtb.CreativeAttribute{openrtb.CreativeAttributeAudioAdAutoPlay}}},
+			{ID: "videoImp", Video: &openrtb.Video{BAttr: []openrtb.CreativeAttribute{openrtb.CreativeAttributeSurveys}}},
+		},
+	}
+
+	testCases := []struct {
+		description    string
+		mode           config.ValidationMode
+		expectedBids   []string
+		expectedCode   int
+		expectedErrors []string
+	}{
+		{
+			description:  "Blocked bids are rejected by default",
+			expectedBids: []string{"clean", "otherImpAttr"},
+			expectedCode: errortypes.BlockedBidWarningCode,
+			expectedErrors: []string{
+				`Bid "subdomain" was rejected because its advertiser domain 'www.ford.com' is blocked by badv`,
+				`Bid "subcategory" was rejected because its category 'IAB25-3' is blocked by bcat`,
+				`Bid "category" was rejected because its category 'IAB7-39' is blocked by bcat`,
+				`Bid "attr" was rejected because its creative attribute 1 is blocked by battr`,
+			},
+		},
+		{
+			description:  "Blocked bids are rejected in enforce mode",
+			mode:         config.ValidationEnforce,
+			expectedBids: []string{"clean", "otherImpAttr"},
+			expectedCode: errortypes.BlockedBidWarningCode,
+			expectedErrors: []string{
+				`Bid "subdomain" was rejected because its advertiser domain 'www.ford.com' is blocked by badv`,
+				`Bid "subcategory" was rejected because its category 'IAB25-3' is blocked by bcat`,
+				`Bid "category" was rejected because its category 'IAB7-39' is blocked by bcat`,
+				`Bid "attr" was rejected because its creative attribute 1 is blocked by battr`,
+			},
+		},
+		{
+			description:  "Blocked bids are kept in warn mode",
+			mode:         config.ValidationWarn,
+			expectedBids: []string{"clean", "subdomain", "subcategory", "category", "attr", "otherImpAttr"},
+			expectedCode: errortypes.BlockedBidWarningCode,
+			expectedErrors: []string{
+				`Bid "subdomain" should have been rejected because its advertiser domain 'www.ford.com' is blocked by badv`,
+				`Bid "subcategory" should have been rejected because its category 'IAB25-3' is blocked by bcat`,
+				`Bid "category" should have been rejected because its category 'IAB7-39' is blocked by bcat`,
+				`Bid "attr" should have been rejected because its creative attribute 1 is blocked by battr`,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		seatBid := &pbsOrtbSeatBid{
+			bids: []*pbsOrtbBid{
+				{bid: &openrtb.Bid{ID: "clean", ImpID: "bannerImp", ADomain: []string{"notford.com"}, Cat: []string{"IAB7-3"}}},
+				{bid: &openrtb.Bid{ID: "subdomain", ImpID: "bannerImp", ADomain: []string{"www.ford.com"}}},
+				{bid: &openrtb.Bid{ID: "subcategory", ImpID: "bannerImp", Cat: []string{"IAB1", "IAB25-3"}}},
+				{bid: &openrtb.Bid{ID: "category", ImpID: "videoImp", Cat: []string{"IAB7-39"}}},
+				{bid: &openrtb.Bid{ID: "attr", ImpID: "bannerImp", Attr: []openrtb.CreativeAttribute{openrtb.CreativeAttributeAudioAdAutoPlay}}},
+				{bid: &openrtb.Bid{ID: "otherImpAttr", ImpID: "videoImp", Attr: []openrtb.CreativeAttribute{openrtb.CreativeAttributeAudioAdAutoPlay}}},
+			},
+		}
+
+		errs := removeBlockedBids(request, seatBid, test.mode)
+
+		bidIDs := make([]string, 0, len(seatBid.bids))
+		for _, bid := range seatBid.bids {
+			bidIDs = append(bidIDs, bid.bid.ID)
+		}
+		assert.Equal(t, test.expectedBids, bidIDs, test.description)
+
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			assert.Equal(t, test.expectedCode, errortypes.ReadCode(err), test.description)
+			messages = append(messages, err.Error())
+		}
+		assert.Equal(t, test.expectedErrors, messages, test.description)
+	}
+}
+
+func TestBadSizeBanners(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Device: &openrtb.Device{W: 320, H: 480},
+		Imp: []openrtb.Imp{
+			{ID: "formats", Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 300, H: 250}, {W: 728, H: 90}}}},
+			{ID: "interstitial", Instl: 1, Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 1, H: 1}}}},
+			{ID: "video", Video: &openrtb.Video{}},
+		},
+	}
+
+	seatBid := &pbsOrtbSeatBid{
+		bids: []*pbsOrtbBid{
+			{bid: &openrtb.Bid{ID: "matching", ImpID: "formats", W: 728, H: 90}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "mismatched", ImpID: "formats", W: 300, H: 600}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "unsized", ImpID: "formats"}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "fitsScreen", ImpID: "interstitial", W: 320, H: 480}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "biggerThanScreen", ImpID: "interstitial", W: 768, H: 1024}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "notBanner", ImpID: "video", W: 640, H: 480}, bidType: openrtb_ext.BidTypeVideo},
+		},
+	}
+
+	errs := removeBadSizeBanners(request, seatBid, config.ValidationEnforce)
+
+	bidIDs := make([]string, 0, len(seatBid.bids))
+	for _, bid := range seatBid.bids {
+		bidIDs = append(bidIDs, bid.bid.ID)
+	}
+	assert.Equal(t, []string{"matching", "unsized", "fitsScreen", "notBanner"}, bidIDs)
+	assert.Equal(t, []error{
+		&errortypes.InvalidCreative{Message: `Bid "mismatched" was rejected because its size 300x600 doesn't match any of the sizes of imp "formats"`},
+		&errortypes.InvalidCreative{Message: `Bid "biggerThanScreen" was rejected because its size 768x1024 doesn't match any of the sizes of imp "interstitial"`},
+	}, errs)
+}
+
+func TestInsecureBids(t *testing.T) {
+	secure := int8(1)
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "secure", Secure: &secure},
+			{ID: "insecure"},
+		},
+	}
+
+	testCases := []struct {
+		description    string
+		mode           config.ValidationMode
+		expectedBids   []string
+		expectedErrors []error
+	}{
+		{
+			description:  "Insecure markup is rejected in enforce mode",
+			mode:         config.ValidationEnforce,
+			expectedBids: []string{"https", "encoded", "namespaces", "landingPage", "vastClicks", "insecureImp"},
+			expectedErrors: []error{
+				&errortypes.InvalidCreative{Message: `Bid "http" was rejected because its adm loads insecure resources on secure imp "secure"`},
+				&errortypes.InvalidCreative{Message: `Bid "css" was rejected because its adm loads insecure resources on secure imp "secure"`},
+				&errortypes.InvalidCreative{Message: `Bid "stylesheet" was rejected because its adm loads insecure resources on secure imp "secure"`},
+				&errortypes.InvalidCreative{Message: `Bid "vast" was rejected because its adm loads insecure resources on secure imp "secure"`},
+			},
+		},
+		{
+			description:  "Insecure markup is kept in warn mode",
+			mode:         config.ValidationWarn,
+			expectedBids: []string{"https", "http", "css", "stylesheet", "vast", "encoded", "namespaces", "landingPage", "vastClicks", "insecureImp"},
+			expectedErrors: []error{
+				&errortypes.InvalidCreative{Message: `Bid "http" should have been rejected because its adm loads insecure resources on secure imp "secure"`},
+				&errortypes.InvalidCreative{Message: `Bid "css" should have been rejected because its adm loads insecure resources on secure imp "secure"`},
+				&errortypes.InvalidCreative{Message: `Bid "stylesheet" should have been rejected because its adm loads insecure resources on secure imp "secure"`},
+				&errortypes.InvalidCreative{Message: `Bid "vast" should have been rejected because its adm loads insecure resources on secure imp "secure"`},
+			},
+		},
+		{
+			description:  "Insecure markup is ignored in skip mode",
+			mode:         config.ValidationSkip,
+			expectedBids: []string{"https", "http", "css", "stylesheet", "vast", "encoded", "namespaces", "landingPage", "vastClicks", "insecureImp"},
+		},
+	}
+
+	for _, test := range testCases {
+		seatBid := &pbsOrtbSeatBid{
+			bids: []*pbsOrtbBid{
+				{bid: &openrtb.Bid{ID: "https", ImpID: "secure", AdM: `<img src="https://ads.com/ad.png">`}},
+				{bid: &openrtb.Bid{ID: "http", ImpID: "secure", AdM: `<script src="HTTP://ads.com/ad.js"></script>`}},
+				{bid: &openrtb.Bid{ID: "css", ImpID: "secure", AdM: `<div style="background: url( 'http://ads.com/bg.png')">`}},
+				{bid: &openrtb.Bid{ID: "stylesheet", ImpID: "secure", AdM: `<link rel="stylesheet" href="http://ads.com/ad.css">`}},
+				{bid: &openrtb.Bid{ID: "vast", ImpID: "secure", AdM: `<VAST version="3.0"><Ad><InLine><Impression><![CDATA[ http://ads.com/imp ]]></Impression></InLine></Ad></VAST>`}},
+				{bid: &openrtb.Bid{ID: "encoded", ImpID: "secure", AdM: `<a href="https://click.com?r=http%3A%2F%2Fads.com">`}},
+				{bid: &openrtb.Bid{ID: "namespaces", ImpID: "secure", AdM: `<svg xmlns="http://www.w3.org/2000/svg"><image href="https://ads.com/ad.png"/></svg>` +
+					`<VAST xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><MediaFile type="video/mp4">https://ads.com/ad.mp4</MediaFile></VAST> Visit http://ads.com`}},
+				{bid: &openrtb.Bid{ID: "landingPage", ImpID: "secure", AdM: `<a href="http://landing.com"><img src="https://ads.com/ad.png"></a>`}},
+				{bid: &openrtb.Bid{ID: "vastClicks", ImpID: "secure", AdM: `<VAST version="3.0"><Ad><InLine><Creatives><Creative><Linear><VideoClicks>` +
+					`<ClickThrough>http://landing.com</ClickThrough><ClickTracking><![CDATA[http://ads.com/click]]></ClickTracking>` +
+					`</VideoClicks></Linear></Creative></Creatives></InLine></Ad></VAST>`}},
+				{bid: &openrtb.Bid{ID: "insecureImp", ImpID: "insecure", AdM: `<img src="http://ads.com/ad.png">`}},
+			},
+		}
+
+		errs := removeInsecureBids(request, seatBid, test.mode)
+
+		bidIDs := make([]string, 0, len(seatBid.bids))
+		for _, bid := range seatBid.bids {
+			bidIDs = append(bidIDs, bid.bid.ID)
+		}
+		assert.Equal(t, test.expectedBids, bidIDs, test.description)
+		assert.Equal(t, test.expectedErrors, errs, test.description)
+	}
+}
+
+func TestNonDealBids(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "private", PMP: &openrtb.PMP{PrivateAuction: 1, Deals: []openrtb.Deal{{ID: "deal1"}, {ID: "deal2"}}}},
+			{ID: "anyDeal", PMP: &openrtb.PMP{PrivateAuction: 1}},
+			{ID: "open", PMP: &openrtb.PMP{Deals: []openrtb.Deal{{ID: "deal1"}}}},
+		},
+	}
+
+	testCases := []struct {
+		description    string
+		mode           config.ValidationMode
+		expectedBids   []string
+		expectedErrors []error
+	}{
+		{
+			description:  "Non deal bids are rejected in enforce mode",
+			mode:         config.ValidationEnforce,
+			expectedBids: []string{"deal", "anyDeal", "open"},
+			expectedErrors: []error{
+				&errortypes.BlockedBid{Message: `Bid "noDeal" was rejected because it has no dealid on private auction imp "private"`},
+				&errortypes.BlockedBid{Message: `Bid "otherDeal" was rejected because its dealid "deal3" isn't one of the deals of private auction imp "private"`},
+				&errortypes.BlockedBid{Message: `Bid "anyNoDeal" was rejected because it has no dealid on private auction imp "anyDeal"`},
+			},
+		},
+		{
+			description:  "Non deal bids are kept in warn mode",
+			mode:         config.ValidationWarn,
+			expectedBids: []string{"deal", "noDeal", "otherDeal", "anyDeal", "anyNoDeal", "open"},
+			expectedErrors: []error{
+				&errortypes.BlockedBid{Message: `Bid "noDeal" should have been rejected because it has no dealid on private auction imp "private"`},
+				&errortypes.BlockedBid{Message: `Bid "otherDeal" should have been rejected because its dealid "deal3" isn't one of the deals of private auction imp "private"`},
+				&errortypes.BlockedBid{Message: `Bid "anyNoDeal" should have been rejected because it has no dealid on private auction imp "anyDeal"`},
+			},
+		},
+		{
+			description:  "Non deal bids are ignored in skip mode",
+			mode:         config.ValidationSkip,
+			expectedBids: []string{"deal", "noDeal", "otherDeal", "anyDeal", "anyNoDeal", "open"},
+		},
+	}
+
+	for _, test := range testCases {
+		seatBid := &pbsOrtbSeatBid{
+			bids: []*pbsOrtbBid{
+				{bid: &openrtb.Bid{ID: "deal", ImpID: "private", DealID: "deal2"}},
+				{bid: &openrtb.Bid{ID: "noDeal", ImpID: "private"}},
+				{bid: &openrtb.Bid{ID: "otherDeal", ImpID: "private", DealID: "deal3"}},
+				{bid: &openrtb.Bid{ID: "anyDeal", ImpID: "anyDeal", DealID: "deal3"}},
+				{bid: &openrtb.Bid{ID: "anyNoDeal", ImpID: "anyDeal"}},
+				{bid: &openrtb.Bid{ID: "open", ImpID: "open"}},
+			},
+		}
+
+		errs := removeNonDealBids(request, seatBid, test.mode)
+
+		bidIDs := make([]string, 0, len(seatBid.bids))
+		for _, bid := range seatBid.bids {
+			bidIDs = append(bidIDs, bid.bid.ID)
+		}
+		assert.Equal(t, test.expectedBids, bidIDs, test.description)
+		assert.Equal(t, test.expectedErrors, errs, test.description)
+	}
+}
+
+func TestValidateBidsForAccountMetrics(t *testing.T) {
+	secure := int8(1)
+	request := &openrtb.BidRequest{
+		BAdv: []string{"ford.com"},
+		Imp: []openrtb.Imp{{
+			ID:     "imp",
+			Secure: &secure,
+			Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 300, H: 250}}},
+			PMP:    &openrtb.PMP{PrivateAuction: 1, Deals: []openrtb.Deal{{ID: "deal1"}}},
+		}},
+	}
+	seatBid := &pbsOrtbSeatBid{
+		bids: []*pbsOrtbBid{
+			{bid: &openrtb.Bid{ID: "blocked", ImpID: "imp", ADomain: []string{"ford.com"}}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "badSize", ImpID: "imp", W: 728, H: 90}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "insecure", ImpID: "imp", AdM: `<img src="http://ads.com/ad.png">`, DealID: "deal1"}, bidType: openrtb_ext.BidTypeBanner},
+			{bid: &openrtb.Bid{ID: "openMarket", ImpID: "imp"}, bidType: openrtb_ext.BidTypeBanner},
+		},
+	}
+	labels := pbsmetrics.AdapterLabels{Adapter: openrtb_ext.BidderAppnexus}
+	validations := config.AccountBidValidations{
+		BannerCreativeSize: config.ValidationEnforce,
+		SecureMarkup:       config.ValidationWarn,
+	}
+
+	metricsMock := &pbsmetrics.MetricsEngineMock{}
+	metricsMock.On("RecordAdapterBlockedBid", labels, true).Once()
+	metricsMock.On("RecordAdapterBadCreativeSize", labels, true).Once()
+	metricsMock.On("RecordAdapterInsecureMarkup", labels, false).Once()
+	metricsMock.On("RecordAdapterDealViolation", labels, true).Once()
+	e := &exchange{me: metricsMock}
+
+	errs := e.validateBidsForAccount(request, seatBid, validations, labels)
+
+	metricsMock.AssertExpectations(t)
+	assert.Len(t, errs, 4)
+	if assert.Len(t, seatBid.bids, 1) {
+		assert.Equal(t, "insecure", seatBid.bids[0].bid.ID)
+	}
+}
@@ -0,0 +1,165 @@
+package exchange
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreakers holds the circuit breakers of a bidder, one for each of its endpoint hosts.
+// A nil circuitBreakers never stops any request.
+type circuitBreakers struct {
+	cfg    config.CircuitBreaker
+	bidder openrtb_ext.BidderName
+	me     pbsmetrics.MetricsEngine
+	now    func() time.Time
+
+	mutex  sync.Mutex
+	byHost map[string]*circuitBreaker
+}
+
+func newCircuitBreakers(cfg config.CircuitBreaker, bidder openrtb_ext.BidderName, me pbsmetrics.MetricsEngine) *circuitBreakers {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &circuitBreakers{
+		cfg:    cfg,
+		bidder: bidder,
+		me:     me,
+		now:    time.Now,
+		byHost: make(map[string]*circuitBreaker),
+	}
+}
+
+// forHost returns the circuit breaker of the endpoint host, creating it on the first request.
+func (breakers *circuitBreakers) forHost(host string) *circuitBreaker {
+	if breakers == nil {
+		return nil
+	}
+	breakers.mutex.Lock()
+	defer breakers.mutex.Unlock()
+
+	breaker, ok := breakers.byHost[host]
+	if !ok {
+		breaker = &circuitBreaker{
+			cfg:     breakers.cfg,
+			now:     breakers.now,
+			results: make([]bool, 0, breakers.cfg.WindowSize),
+			onChange: func(open bool) {
+				breakers.me.RecordAdapterCircuitBreaker(breakers.bidder, open)
+			},
+		}
+		breakers.byHost[host] = breaker
+	}
+	return breaker
+}
+
+// circuitBreaker tracks the recent requests made to an endpoint host. Every request it allows must be
+// followed by a call to record or abandon. A nil circuitBreaker allows every request.
+type circuitBreaker struct {
+	cfg      config.CircuitBreaker
+	now      func() time.Time
+	onChange func(open bool)
+
+	mutex    sync.Mutex
+	state    circuitState
+	openedAt time.Time
+	// results is a rolling window of the most recent requests, where true means the request failed.
+	results  []bool
+	next     int
+	failures int
+}
+
+// allow tells whether a request can be sent. Once the circuit breaker has been open for long enough,
+// it lets a single request through to probe the endpoint.
+func (breaker *circuitBreaker) allow() bool {
+	if breaker == nil {
+		return true
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	switch breaker.state {
+	case circuitClosed:
+		return true
+	case circuitOpen:
+		if breaker.now().Sub(breaker.openedAt) >= time.Duration(breaker.cfg.OpenIntervalMillis)*time.Millisecond {
+			breaker.state = circuitHalfOpen
+			return true
+		}
+	}
+	return false
+}
+
+// record adds the outcome of an allowed request.
+func (breaker *circuitBreaker) record(failed bool) {
+	if breaker == nil {
+		return
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	switch breaker.state {
+	case circuitHalfOpen:
+		if failed {
+			breaker.open()
+			return
+		}
+		breaker.state = circuitClosed
+		breaker.onChange(false)
+	case circuitClosed:
+		breaker.addResult(failed)
+		if len(breaker.results) >= breaker.cfg.MinRequests && float64(breaker.failures) >= breaker.cfg.ErrorRatio*float64(len(breaker.results)) {
+			breaker.open()
+			breaker.onChange(true)
+		}
+	}
+}
+
+// abandon is used for requests which were cancelled before the endpoint could answer. They say nothing about
+// the endpoint, so a probe is simply retried with the next request.
+func (breaker *circuitBreaker) abandon() {
+	if breaker == nil {
+		return
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.state == circuitHalfOpen {
+		breaker.state = circuitOpen
+	}
+}
+
+func (breaker *circuitBreaker) open() {
+	breaker.state = circuitOpen
+	breaker.openedAt = breaker.now()
+	breaker.results = breaker.results[:0]
+	breaker.next = 0
+	breaker.failures = 0
+}
+
+func (breaker *circuitBreaker) addResult(failed bool) {
+	if len(breaker.results) < cap(breaker.results) {
+		breaker.results = append(breaker.results, failed)
+	} else {
+		if breaker.results[breaker.next] {
+			breaker.failures--
+		}
+		breaker.results[breaker.next] = failed
+		breaker.next = (breaker.next + 1) % len(breaker.results)
+	}
+	if failed {
+		breaker.failures++
+	}
+}
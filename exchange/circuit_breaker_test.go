@@ -0,0 +1,108 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCircuitBreakers(me pbsmetrics.MetricsEngine, now *time.Time) *circuitBreakers {
+	breakers := newCircuitBreakers(config.CircuitBreaker{
+		Enabled:            true,
+		WindowSize:         4,
+		MinRequests:        2,
+		ErrorRatio:         0.6,
+		OpenIntervalMillis: 1000,
+	}, openrtb_ext.BidderAppnexus, me)
+	breakers.now = func() time.Time { return *now }
+	return breakers
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	metricsMock := &pbsmetrics.MetricsEngineMock{}
+	metricsMock.On("RecordAdapterCircuitBreaker", openrtb_ext.BidderAppnexus, true).Once()
+	metricsMock.On("RecordAdapterCircuitBreaker", openrtb_ext.BidderAppnexus, false).Once()
+	breaker := newTestCircuitBreakers(metricsMock, &now).forHost("ib.adnxs.com")
+
+	assert.True(t, breaker.allow())
+	breaker.record(true)
+	assert.True(t, breaker.allow(), "The circuit breaker shouldn't open before min_requests")
+	breaker.record(false)
+	assert.True(t, breaker.allow(), "One failure out of two requests is under the ratio")
+	breaker.record(true)
+	assert.False(t, breaker.allow(), "Two failures out of three requests should open the circuit breaker")
+
+	now = now.Add(999 * time.Millisecond)
+	assert.False(t, breaker.allow(), "The circuit breaker should stay open for the interval")
+
+	now = now.Add(time.Millisecond)
+	assert.True(t, breaker.allow(), "A probe should be let through after the interval")
+	assert.False(t, breaker.allow(), "Only one probe should be let through at a time")
+	breaker.record(true)
+	assert.False(t, breaker.allow(), "A failed probe should open the circuit breaker again")
+
+	now = now.Add(time.Second)
+	assert.True(t, breaker.allow())
+	breaker.record(false)
+	assert.True(t, breaker.allow(), "A successful probe should close the circuit breaker")
+	breaker.record(true)
+	assert.True(t, breaker.allow(), "The window should start over once closed")
+
+	metricsMock.AssertExpectations(t)
+}
+
+func TestCircuitBreakerRollingWindow(t *testing.T) {
+	now := time.Now()
+	breaker := newTestCircuitBreakers(&pbsmetrics.MetricsEngineMock{}, &now).forHost("ib.adnxs.com")
+
+	// 1 failure out of 4, then the failure rolls out of the window
+	for _, failed := range []bool{true, false, false, false, false} {
+		breaker.record(failed)
+	}
+	assert.Equal(t, 0, breaker.failures)
+	assert.Len(t, breaker.results, 4)
+	assert.True(t, breaker.allow())
+}
+
+func TestCircuitBreakerAbandonedProbe(t *testing.T) {
+	now := time.Now()
+	metricsMock := &pbsmetrics.MetricsEngineMock{}
+	metricsMock.On("RecordAdapterCircuitBreaker", openrtb_ext.BidderAppnexus, true).Once()
+	breaker := newTestCircuitBreakers(metricsMock, &now).forHost("ib.adnxs.com")
+	breaker.record(true)
+	breaker.record(true)
+
+	now = now.Add(time.Second)
+	assert.True(t, breaker.allow())
+	breaker.abandon()
+	assert.True(t, breaker.allow(), "A cancelled probe should be retried with the next request")
+}
+
+func TestCircuitBreakersPerHost(t *testing.T) {
+	now := time.Now()
+	metricsMock := &pbsmetrics.MetricsEngineMock{}
+	metricsMock.On("RecordAdapterCircuitBreaker", openrtb_ext.BidderAppnexus, true).Once()
+	breakers := newTestCircuitBreakers(metricsMock, &now)
+
+	failing := breakers.forHost("us-east.adnxs.com")
+	failing.record(true)
+	failing.record(true)
+
+	assert.False(t, breakers.forHost("us-east.adnxs.com").allow())
+	assert.True(t, breakers.forHost("eu-west.adnxs.com").allow(), "Other hosts shouldn't be affected")
+}
+
+func TestCircuitBreakersDisabled(t *testing.T) {
+	breakers := newCircuitBreakers(config.CircuitBreaker{Enabled: false}, openrtb_ext.BidderAppnexus, &pbsmetrics.MetricsEngineMock{})
+	assert.Nil(t, breakers)
+
+	breaker := breakers.forHost("ib.adnxs.com")
+	breaker.record(true)
+	breaker.abandon()
+	assert.True(t, breaker.allow())
+}
@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+type exclusionCandidate struct {
+	bid      *pbsOrtbBid
+	impOrder int
+	keys     []string
+}
+
+// applyCompetitiveExclusion makes sure that no two imps on the page are won by bids which share an advertiser
+// domain or an IAB category. Winners are chosen imp by imp according to the tie break rule, and every bid which
+// conflicts with the winner of another imp is removed, so that it can't win through the bidder specific keys either.
+//
+// It returns a rejection message for every bid which was removed, along with the bids themselves so that
+// their bidders can be told why they lost.
+func applyCompetitiveExclusion(bidRequest *openrtb.BidRequest, exclusion *openrtb_ext.ExtRequestPrebidCompetitiveExclusion, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid) ([]string, []bidLoss) {
+	var rejections []string
+	var losses []bidLoss
+
+	byADomain, byCat := true, true
+	if len(exclusion.Fields) > 0 {
+		byADomain, byCat = false, false
+		for _, field := range exclusion.Fields {
+			switch field {
+			case openrtb_ext.CompetitiveExclusionFieldADomain:
+				byADomain = true
+			case openrtb_ext.CompetitiveExclusionFieldCat:
+				byCat = true
+			}
+		}
+	}
+
+	impOrder := make(map[string]int, len(bidRequest.Imp))
+	for i, imp := range bidRequest.Imp {
+		impOrder[imp.ID] = i
+	}
+
+	candidates := make([]*exclusionCandidate, 0)
+	candidatesByBid := make(map[*pbsOrtbBid]*exclusionCandidate)
+	for _, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.bids {
+			candidate := &exclusionCandidate{
+				bid:      bid,
+				impOrder: impOrder[bid.bid.ImpID],
+			}
+			if byADomain {
+				for _, domain := range bid.bid.ADomain {
+					candidate.keys = append(candidate.keys, "adomain:"+strings.ToLower(domain))
+				}
+			}
+			if byCat {
+				for _, cat := range bid.bid.Cat {
+					candidate.keys = append(candidate.keys, "cat:"+cat)
+				}
+			}
+			candidates = append(candidates, candidate)
+			candidatesByBid[bid] = candidate
+		}
+	}
+
+	if exclusion.TieBreak == openrtb_ext.CompetitiveExclusionTieBreakImpOrder {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].impOrder != candidates[j].impOrder {
+				return candidates[i].impOrder < candidates[j].impOrder
+			}
+			return candidates[i].bid.bid.Price > candidates[j].bid.bid.Price
+		})
+	} else {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].bid.bid.Price != candidates[j].bid.bid.Price {
+				return candidates[i].bid.bid.Price > candidates[j].bid.bid.Price
+			}
+			return candidates[i].impOrder < candidates[j].impOrder
+		})
+	}
+
+	// keyOwners maps each exclusion key to the imp whose winner claimed it
+	keyOwners := make(map[string]string)
+	winners := make(map[string]bool)
+	for _, candidate := range candidates {
+		impID := candidate.bid.bid.ImpID
+		if winners[impID] || conflictsWithOtherImp(candidate, keyOwners) {
+			continue
+		}
+		winners[impID] = true
+		for _, key := range candidate.keys {
+			keyOwners[key] = impID
+		}
+	}
+
+	for seat, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		bids := make([]*pbsOrtbBid, 0, len(seatBid.bids))
+		for _, bid := range seatBid.bids {
+			if key, conflicts := conflictingKey(candidatesByBid[bid], keyOwners); conflicts {
+				rejections = updateRejections(rejections, bid.bid.ID, "Bid was excluded by competitive exclusion")
+				reason := openrtb_ext.LossReasonAdvertiserExclusions
+				if strings.HasPrefix(key, "cat:") {
+					reason = openrtb_ext.LossReasonCategoryExclusions
+				}
+				losses = append(losses, bidLoss{seat: seat, bid: bid, reason: reason})
+				continue
+			}
+			bids = append(bids, bid)
+		}
+		if len(bids) == 0 {
+			bids = nil
+		}
+		seatBid.bids = bids
+	}
+
+	return rejections, losses
+}
+
+func conflictsWithOtherImp(candidate *exclusionCandidate, keyOwners map[string]string) bool {
+	_, conflicts := conflictingKey(candidate, keyOwners)
+	return conflicts
+}
+
+// conflictingKey returns the first of the candidate's keys claimed by the winner of another imp
+func conflictingKey(candidate *exclusionCandidate, keyOwners map[string]string) (string, bool) {
+	for _, key := range candidate.keys {
+		if owner, ok := keyOwners[key]; ok && owner != candidate.bid.bid.ImpID {
+			return key, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyCompetitiveExclusion(t *testing.T) {
+	bidRequest := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "imp1"}, {ID: "imp2"}, {ID: "imp3"}},
+	}
+
+	testCases := []struct {
+		description        string
+		exclusion          openrtb_ext.ExtRequestPrebidCompetitiveExclusion
+		expectedBids       []string
+		expectedRejections []string
+		expectedLosses     map[string]openrtb_ext.LossReason
+	}{
+		{
+			description:  "Highest bids claim their advertiser and category by default",
+			exclusion:    openrtb_ext.ExtRequestPrebidCompetitiveExclusion{},
+			expectedBids: []string{"audi_imp3", "ford_imp2_high", "toyota_imp3"},
+			expectedRejections: []string{
+				"bid rejected [bid ID: ford_imp1_low] reason: Bid was excluded by competitive exclusion",
+				"bid rejected [bid ID: nike_imp1] reason: Bid was excluded by competitive exclusion",
+			},
+			expectedLosses: map[string]openrtb_ext.LossReason{
+				"ford_imp1_low": openrtb_ext.LossReasonAdvertiserExclusions,
+				"nike_imp1":     openrtb_ext.LossReasonCategoryExclusions,
+			},
+		},
+		{
+			description:  "Earlier imps claim their advertiser first with imporder",
+			exclusion:    openrtb_ext.ExtRequestPrebidCompetitiveExclusion{TieBreak: openrtb_ext.CompetitiveExclusionTieBreakImpOrder},
+			expectedBids: []string{"audi_imp3", "ford_imp1_low", "toyota_imp3"},
+			expectedRejections: []string{
+				"bid rejected [bid ID: ford_imp2_high] reason: Bid was excluded by competitive exclusion",
+				"bid rejected [bid ID: nike_imp1] reason: Bid was excluded by competitive exclusion",
+			},
+			expectedLosses: map[string]openrtb_ext.LossReason{
+				"ford_imp2_high": openrtb_ext.LossReasonAdvertiserExclusions,
+				"nike_imp1":      openrtb_ext.LossReasonCategoryExclusions,
+			},
+		},
+		{
+			description:  "Categories are ignored when only adomain is excluded",
+			exclusion:    openrtb_ext.ExtRequestPrebidCompetitiveExclusion{Fields: []string{openrtb_ext.CompetitiveExclusionFieldADomain}},
+			expectedBids: []string{"audi_imp3", "ford_imp2_high", "nike_imp1", "toyota_imp3"},
+			expectedRejections: []string{
+				"bid rejected [bid ID: ford_imp1_low] reason: Bid was excluded by competitive exclusion",
+			},
+			expectedLosses: map[string]openrtb_ext.LossReason{
+				"ford_imp1_low": openrtb_ext.LossReasonAdvertiserExclusions,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		seatBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+			"appnexus": {
+				bids: []*pbsOrtbBid{
+					{bid: &openrtb.Bid{ID: "nike_imp1", ImpID: "imp1", Price: 1, ADomain: []string{"nike.com"}, Cat: []string{"IAB2"}}},
+					{bid: &openrtb.Bid{ID: "ford_imp2_high", ImpID: "imp2", Price: 5, ADomain: []string{"FORD.com"}}},
+					{bid: &openrtb.Bid{ID: "audi_imp3", ImpID: "imp3", Price: 1, ADomain: []string{"audi.com"}, Cat: []string{"IAB2"}}},
+				},
+			},
+			"rubicon": {
+				bids: []*pbsOrtbBid{
+					{bid: &openrtb.Bid{ID: "ford_imp1_low", ImpID: "imp1", Price: 2, ADomain: []string{"ford.com"}}},
+					{bid: &openrtb.Bid{ID: "toyota_imp3", ImpID: "imp3", Price: 4, ADomain: []string{"toyota.com"}, Cat: []string{"IAB2"}}},
+				},
+			},
+		}
+
+		rejections, excluded := applyCompetitiveExclusion(bidRequest, &test.exclusion, seatBids)
+
+		bids := make([]string, 0)
+		for _, seatBid := range seatBids {
+			for _, bid := range seatBid.bids {
+				bids = append(bids, bid.bid.ID)
+			}
+		}
+		sort.Strings(bids)
+		sort.Strings(rejections)
+		assert.Equal(t, test.expectedBids, bids, test.description)
+		assert.Equal(t, test.expectedRejections, rejections, test.description)
+
+		losses := make(map[string]openrtb_ext.LossReason, len(excluded))
+		for _, loss := range excluded {
+			losses[loss.bid.bid.ID] = loss.reason
+		}
+		assert.Equal(t, test.expectedLosses, losses, test.description)
+	}
+}
@@ -50,6 +50,19 @@ type IdFetcher interface {
 	GetId(bidder openrtb_ext.BidderName) (string, bool)
 }
 
+// sharedCookieIdFetcher fetches the IDs of the aliases which share a cookie from their core bidders.
+type sharedCookieIdFetcher struct {
+	IdFetcher
+	sharedCookies map[openrtb_ext.BidderName]openrtb_ext.BidderName
+}
+
+func (f *sharedCookieIdFetcher) GetId(bidder openrtb_ext.BidderName) (string, bool) {
+	if core, ok := f.sharedCookies[bidder]; ok {
+		return f.IdFetcher.GetId(core)
+	}
+	return f.IdFetcher.GetId(bidder)
+}
+
 type exchange struct {
 	adapterMap          map[openrtb_ext.BidderName]adaptedBidder
 	me                  pbsmetrics.MetricsEngine
@@ -60,12 +73,32 @@ type exchange struct {
 	UsersyncIfAmbiguous bool
 	privacyConfig       config.Privacy
 	eeaCountries        map[string]struct{}
+	// secondPriceIncrement is added to the second highest bid to price the winners of second price auctions
+	secondPriceIncrement float64
+	// bidderTimeouts gives each bidder its own deadline. It's nil unless bidder timeouts are enabled.
+	bidderTimeouts *bidderTimeouts
+	throttler      *bidderThrottler
+	// sharedCookies maps the host's aliases which have no usersync_url of their own to their core bidders,
+	// whose cookie they use.
+	sharedCookies map[openrtb_ext.BidderName]openrtb_ext.BidderName
+	// billingNotices holds the winners' billing notices for the accounts which let PBS send them
+	billingNotices *billingNotices
+	// noticeSender sends the win, loss and billing notices to the bidders
+	noticeSender *noticeSender
+	// connectionWarmers open the bidders' connections ahead of the auctions once WarmUpConnections is called
+	connectionWarmers []*connectionWarmer
 }
 
 // Container to pass out response ext data from the GetAllBids goroutines back into the main thread
 type seatResponseExtra struct {
 	ResponseTimeMillis int
-	Errors             []openrtb_ext.ExtBidderError
+	// TimeoutMillis is the tmax sent to the bidder. It's only set when bidder timeouts are enabled.
+	TimeoutMillis int64
+	// Throttled tells why no request was sent to the bidder. It's empty for the bidders which were called.
+	Throttled pbsmetrics.ThrottleReason
+	Errors    []openrtb_ext.ExtBidderError
+	// Warnings report the bidder's bids which failed the bid validations. They don't fail the bidder.
+	Warnings []openrtb_ext.ExtBidderError
 	// httpCalls is the list of debugging info. It should only be populated if the request.test == 1.
 	// This will become response.ext.debug.httpcalls.{bidder} on the final Response.
 	HttpCalls []*openrtb_ext.ExtHttpCall
@@ -85,13 +118,25 @@ func NewExchange(client *http.Client, cache prebid_cache_client.Client, cfg *con
 	for _, c := range cfg.GDPR.EEACountries {
 		e.eeaCountries[c] = s
 	}
-	e.adapterMap = newAdapterMap(client, cfg, infos, metricsEngine)
+	e.bidderTimeouts = newBidderTimeouts(cfg.Auction.BidderTimeouts)
+	e.adapterMap = newAdapterMap(client, cfg, infos, metricsEngine, e.bidderTimeouts)
+	e.connectionWarmers = newConnectionWarmers(e.adapterMap, cfg.Adapters)
+	e.throttler = newBidderThrottler(cfg.Adapters)
+	e.sharedCookies = make(map[openrtb_ext.BidderName]openrtb_ext.BidderName)
+	for name, adapterCfg := range cfg.Adapters {
+		if adapterCfg.AliasOf != "" && adapterCfg.UserSyncURL == "" {
+			e.sharedCookies[openrtb_ext.BidderName(name)] = openrtb_ext.BidderName(adapterCfg.AliasOf)
+		}
+	}
 	e.cache = cache
 	e.cacheTime = time.Duration(cfg.CacheURL.ExpectedTimeMillis) * time.Millisecond
 	e.me = metricsEngine
 	e.gDPR = gDPR
 	e.currencyConverter = currencyConverter
 	e.UsersyncIfAmbiguous = cfg.GDPR.UsersyncIfAmbiguous
+	e.secondPriceIncrement = cfg.Auction.SecondPriceIncrement
+	e.billingNotices = newBillingNotices(cfg)
+	e.noticeSender = newNoticeSender(cfg.Auction.Notifications.MaxConcurrent)
 	e.privacyConfig = config.Privacy{
 		CCPA: cfg.CCPA,
 		GDPR: cfg.GDPR,
@@ -108,7 +153,7 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 	}
 
 	cacheInstructions := getExtCacheInstructions(requestExt)
-	targData := getExtTargetData(requestExt, &cacheInstructions)
+	targData, targetingErrs := getExtTargetData(requestExt, &cacheInstructions, account)
 	if targData != nil {
 		_, targData.cacheHost, targData.cachePath = e.cache.GetExtCacheData()
 	}
@@ -118,7 +163,13 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 		ctx = e.makeDebugContext(ctx, debugInfo)
 	}
 
+	// The auction runs in the first currency of request.cur. The account picks it when the request doesn't.
+	if len(bidRequest.Cur) == 0 && account.DefaultCurrency != "" {
+		bidRequest.Cur = []string{account.DefaultCurrency}
+	}
+
 	bidAdjustmentFactors := getExtBidAdjustmentFactors(requestExt)
+	bidAdjustmentRules := getExtBidAdjustments(requestExt, account)
 
 	for _, impInRequest := range bidRequest.Imp {
 		var impLabels pbsmetrics.ImpLabels = pbsmetrics.ImpLabels{
@@ -150,7 +201,11 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 	}
 	// Slice of BidRequests, each a copy of the original cleaned to only contain bidder data for the named bidder
 	blabels := make(map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels)
+	if len(e.sharedCookies) > 0 {
+		usersyncs = &sharedCookieIdFetcher{IdFetcher: usersyncs, sharedCookies: e.sharedCookies}
+	}
 	cleanRequests, aliases, privacyLabels, errs := cleanOpenRTBRequests(ctx, bidRequest, requestExt, usersyncs, blabels, labels, e.gDPR, usersyncIfAmbiguous, e.privacyConfig)
+	errs = append(errs, targetingErrs...)
 
 	e.me.RecordRequestPrivacy(privacyLabels)
 
@@ -163,11 +218,12 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 	defer cancel()
 
 	// Get currency rates conversions for the auction
-	conversions := e.currencyConverter.Rates()
+	conversions := e.getAuctionCurrencyRates(requestExt.Prebid.Currency)
 
-	adapterBids, adapterExtra, anyBidsReturned := e.getAllBids(auctionCtx, cleanRequests, aliases, bidAdjustmentFactors, blabels, conversions)
+	adapterBids, adapterExtra, anyBidsReturned := e.getAllBids(auctionCtx, cleanRequests, aliases, bidAdjustmentFactors, bidAdjustmentRules, blabels, conversions, account)
 
 	var auc *auction = nil
+	var removedBids []bidLoss
 	var bidResponseExt *openrtb_ext.ExtBidResponse = nil
 	if anyBidsReturned {
 
@@ -176,6 +232,7 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 		if requestExt.Prebid.Targeting != nil && requestExt.Prebid.Targeting.IncludeBrandCategory != nil {
 			var err error
 			var rejections []string
+			mappedBids := copyBids(adapterBids)
 			bidCategory, adapterBids, rejections, err = applyCategoryMapping(ctx, requestExt, adapterBids, *categoriesFetcher, targData)
 			if err != nil {
 				return nil, fmt.Errorf("Error in category mapping : %s", err.Error())
@@ -183,12 +240,29 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 			for _, message := range rejections {
 				errs = append(errs, errors.New(message))
 			}
+			removedBids = findRemovedBids(mappedBids, adapterBids, openrtb_ext.LossReasonCategoryExclusions)
+		}
+
+		if requestExt.Prebid.CompetitiveExclusion != nil {
+			var rejections []string
+			var excludedBids []bidLoss
+			rejections, excludedBids = applyCompetitiveExclusion(bidRequest, requestExt.Prebid.CompetitiveExclusion, adapterBids)
+			removedBids = append(removedBids, excludedBids...)
+			for _, message := range rejections {
+				errs = append(errs, errors.New(message))
+			}
 		}
 
 		auc = newAuction(adapterBids, len(bidRequest.Imp))
 
+		if bidRequest.AT == 2 {
+			auc.setClearingPrices(bidRequest, adapterBids, conversions, e.secondPriceIncrement)
+		}
+
+		winNotices := e.takeWinNotices(bidRequest, adapterBids, auc, account)
+
 		if targData != nil {
-			auc.setRoundedPrices(targData.priceGranularity)
+			auc.setRoundedPrices(targData)
 
 			if requestExt.Prebid.SupportDeals {
 				dealErrs := applyDealSupport(bidRequest, auc, bidCategory)
@@ -218,6 +292,8 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 			}
 		}
 
+		e.sendBidNotices(bidRequest, adapterBids, winNotices, removedBids, auc, conversions, targData, aliases, account)
+
 	}
 
 	if !anyBidsReturned {
@@ -267,6 +343,7 @@ func applyDealSupport(bidRequest *openrtb.BidRequest, auc *auction, bidCategory
 			if topBidPerBidder.dealPriority > 0 {
 				if validateAndNormalizeDealTier(impDeal[bidderString]) {
 					updateHbPbCatDur(topBidPerBidder, impDeal[bidderString].Info, bidCategory)
+					updateDealTier(auc, topBidPerBidder, impDeal[bidderString].Info)
 				} else {
 					errs = append(errs, fmt.Errorf("dealTier configuration invalid for bidder '%s', imp ID '%s'", bidderString, impID))
 				}
@@ -317,11 +394,40 @@ func updateHbPbCatDur(bid *pbsOrtbBid, dealTierInfo *DealTierInfo, bidCategory m
 	}
 }
 
+// updateDealTier records the deal tier of banner and native bids, which don't have an hb_pb_cat_dur key to carry it
+func updateDealTier(auc *auction, bid *pbsOrtbBid, dealTierInfo *DealTierInfo) {
+	if bid.bidType != openrtb_ext.BidTypeBanner && bid.bidType != openrtb_ext.BidTypeNative {
+		return
+	}
+	if bid.dealPriority >= dealTierInfo.MinDealTier {
+		if auc.dealTiers == nil {
+			auc.dealTiers = make(map[*pbsOrtbBid]string)
+		}
+		auc.dealTiers[bid] = fmt.Sprintf("%s%d", dealTierInfo.Prefix, bid.dealPriority)
+	}
+}
+
 func (e *exchange) makeDebugContext(ctx context.Context, debugInfo bool) (debugCtx context.Context) {
 	debugCtx = context.WithValue(ctx, DebugContextKey, debugInfo)
 	return
 }
 
+// getAuctionCurrencyRates returns the rates for an auction. Rates from the request are consulted first and,
+// unless the request says otherwise, the host's rates are used for the pairs they don't have.
+func (e *exchange) getAuctionCurrencyRates(customRates *openrtb_ext.ExtRequestCurrency) currencies.Conversions {
+	if customRates == nil {
+		return e.currencyConverter.Rates()
+	}
+	requestRates := currencies.NewRates(time.Time{}, customRates.ConversionRates)
+	if customRates.UsePBSRates != nil && !*customRates.UsePBSRates {
+		return requestRates
+	}
+	if len(customRates.ConversionRates) == 0 {
+		return e.currencyConverter.Rates()
+	}
+	return currencies.NewAggregateConversions(requestRates, e.currencyConverter.Rates())
+}
+
 func (e *exchange) makeAuctionContext(ctx context.Context, needsCache bool) (auctionCtx context.Context, cancel context.CancelFunc) {
 	auctionCtx = ctx
 	cancel = func() {}
@@ -334,16 +440,23 @@ func (e *exchange) makeAuctionContext(ctx context.Context, needsCache bool) (auc
 }
 
 // This piece sends all the requests to the bidder adapters and gathers the results.
-func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, bidAdjustments map[string]float64, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, conversions currencies.Conversions) (map[openrtb_ext.BidderName]*pbsOrtbSeatBid, map[openrtb_ext.BidderName]*seatResponseExtra, bool) {
+func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, bidAdjustments map[string]float64, bidAdjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, conversions currencies.Conversions, account *config.Account) (map[openrtb_ext.BidderName]*pbsOrtbSeatBid, map[openrtb_ext.BidderName]*seatResponseExtra, bool) {
 	// Set up pointers to the bid results
 	adapterBids := make(map[openrtb_ext.BidderName]*pbsOrtbSeatBid, len(cleanRequests))
 	adapterExtra := make(map[openrtb_ext.BidderName]*seatResponseExtra, len(cleanRequests))
 	chBids := make(chan *bidResponseWrapper, len(cleanRequests))
 	bidsFound := false
+	biddersCalled := 0
 
 	for bidderName, req := range cleanRequests {
 		// Here we actually call the adapters and collect the bids.
 		coreBidder := resolveBidder(string(bidderName), aliases)
+		if reason := e.throttler.throttle(coreBidder, req, account); reason != "" {
+			e.me.RecordAdapterThrottled(coreBidder, reason)
+			adapterExtra[bidderName] = &seatResponseExtra{Throttled: reason}
+			continue
+		}
+		biddersCalled++
 		bidderRunner := e.recoverSafely(cleanRequests, func(aName openrtb_ext.BidderName, coreBidder openrtb_ext.BidderName, request *openrtb.BidRequest, bidlabels *pbsmetrics.AdapterLabels, conversions currencies.Conversions) {
 			// Passing in aName so a doesn't change out from under the go routine
 			if bidlabels.Adapter == "" {
@@ -364,7 +477,12 @@ func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext
 			}
 			var reqInfo adapters.ExtraRequestInfo
 			reqInfo.PbsEntryPoint = bidlabels.RType
-			bids, err := e.adapterMap[coreBidder].requestBid(ctx, request, aName, adjustmentFactor, conversions, &reqInfo)
+			bidderCtx, cancel := e.bidderTimeouts.bidderContext(ctx, coreBidder, request)
+			defer cancel()
+			bids, err := e.adapterMap[coreBidder].requestBid(bidderCtx, request, aName, adjustmentFactor, bidAdjustmentRules, conversions, &reqInfo)
+			if validationErrs := e.validateBidsForAccount(request, bids, account.BidValidations, *bidlabels); len(validationErrs) > 0 {
+				err = append(err, validationErrs...)
+			}
 
 			// Add in time reporting
 			elapsed := time.Since(start)
@@ -372,17 +490,22 @@ func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext
 			// Structure to record extra tracking data generated during bidding
 			ae := new(seatResponseExtra)
 			ae.ResponseTimeMillis = int(elapsed / time.Millisecond)
+			if e.bidderTimeouts != nil {
+				ae.TimeoutMillis = request.TMax
+			}
 			if bids != nil {
 				ae.HttpCalls = bids.httpCalls
 			}
 
 			// Timing statistics
 			e.me.RecordAdapterTime(*bidlabels, time.Since(start))
+			// The bids caught by the bid validations don't fail the bidder
+			err, validationWarnings := splitBidValidationWarnings(err)
 			serr := errsToBidderErrors(err)
 			bidlabels.AdapterBids = bidsToMetric(brw.adapterBids)
 			bidlabels.AdapterErrors = errorsToMetric(err)
-			// Append any bid validation errors to the error list
 			ae.Errors = serr
+			ae.Warnings = errsToBidderErrors(validationWarnings)
 			brw.adapterExtra = ae
 			if bids != nil {
 				for _, bid := range bids.bids {
@@ -396,7 +519,7 @@ func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext
 		go bidderRunner(bidderName, coreBidder, req, blabels[coreBidder], conversions)
 	}
 	// Wait for the bidders to do their thing
-	for i := 0; i < len(cleanRequests); i++ {
+	for i := 0; i < biddersCalled; i++ {
 		brw := <-chBids
 
 		//if bidder returned no bids back - remove bidder from further processing
@@ -450,6 +573,22 @@ func bidsToMetric(bids *pbsOrtbSeatBid) pbsmetrics.AdapterBid {
 	return pbsmetrics.AdapterBidPresent
 }
 
+// splitBidValidationWarnings separates the warnings about the bids which failed the bid validations from
+// the bidder's other errors. They're reported in bidresponse.ext.warnings instead of bidresponse.ext.errors,
+// and left out of the adapter error metrics.
+func splitBidValidationWarnings(errs []error) ([]error, []error) {
+	var bidderErrs, warnings []error
+	for _, err := range errs {
+		switch err.(type) {
+		case *errortypes.BlockedBid, *errortypes.InvalidCreative:
+			warnings = append(warnings, err)
+		default:
+			bidderErrs = append(bidderErrs, err)
+		}
+	}
+	return bidderErrs, warnings
+}
+
 func errorsToMetric(errs []error) map[pbsmetrics.AdapterError]struct{} {
 	if len(errs) == 0 {
 		return nil
@@ -464,7 +603,7 @@ func errorsToMetric(errs []error) map[pbsmetrics.AdapterError]struct{} {
 			ret[pbsmetrics.AdapterErrorBadInput] = s
 		case errortypes.BadServerResponseErrorCode:
 			ret[pbsmetrics.AdapterErrorBadServerResponse] = s
-		case errortypes.FailedToRequestBidsErrorCode:
+		case errortypes.FailedToRequestBidsErrorCode, errortypes.CircuitBreakerOpenErrorCode:
 			ret[pbsmetrics.AdapterErrorFailedToRequestBids] = s
 		default:
 			ret[pbsmetrics.AdapterErrorUnknown] = s
@@ -606,7 +745,7 @@ func applyCategoryMapping(ctx context.Context, requestExt *openrtb_ext.ExtReques
 
 			// TODO: consider should we remove bids with zero duration here?
 
-			pb, _ = GetCpmStringValue(bid.bid.Price, targData.priceGranularity)
+			pb, _ = GetCpmStringValue(bid.bid.Price, targData.priceGranularityFor(bid.bidType))
 
 			newDur := duration
 			if len(requestExt.Prebid.Targeting.DurationRangeSec) > 0 {
@@ -726,6 +865,7 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*pb
 		Errors:               make(map[openrtb_ext.BidderName][]openrtb_ext.ExtBidderError, len(adapterBids)),
 		ResponseTimeMillis:   make(map[openrtb_ext.BidderName]int, len(adapterBids)),
 		RequestTimeoutMillis: req.TMax,
+		Prebid:               &openrtb_ext.ExtResponsePrebid{Currency: getAuctionCurrency(req)},
 	}
 	if debugInfo {
 		bidResponseExt.Debug = &openrtb_ext.ExtResponseDebug{
@@ -735,6 +875,15 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*pb
 	}
 
 	for bidderName, responseExtra := range adapterExtra {
+		if responseExtra.Throttled != "" {
+			if debugInfo {
+				if bidResponseExt.Debug.Throttled == nil {
+					bidResponseExt.Debug.Throttled = make(map[openrtb_ext.BidderName]string)
+				}
+				bidResponseExt.Debug.Throttled[bidderName] = string(responseExtra.Throttled)
+			}
+			continue
+		}
 
 		if debugInfo {
 			bidResponseExt.Debug.HttpCalls[bidderName] = responseExtra.HttpCalls
@@ -746,7 +895,19 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*pb
 		if len(errList) > 0 {
 			bidResponseExt.Errors[openrtb_ext.PrebidExtKey] = errsToBidderErrors(errList)
 		}
+		if len(responseExtra.Warnings) > 0 {
+			if bidResponseExt.Warnings == nil {
+				bidResponseExt.Warnings = make(map[openrtb_ext.BidderName][]openrtb_ext.ExtBidderError, len(adapterExtra))
+			}
+			bidResponseExt.Warnings[bidderName] = responseExtra.Warnings
+		}
 		bidResponseExt.ResponseTimeMillis[bidderName] = responseExtra.ResponseTimeMillis
+		if responseExtra.TimeoutMillis > 0 {
+			if bidResponseExt.BidderTimeoutMillis == nil {
+				bidResponseExt.BidderTimeoutMillis = make(map[openrtb_ext.BidderName]int64, len(adapterExtra))
+			}
+			bidResponseExt.BidderTimeoutMillis[bidderName] = responseExtra.TimeoutMillis
+		}
 		// Defering the filling of bidResponseExt.Usersync[bidderName] until later
 
 	}
@@ -794,11 +955,15 @@ func (e *exchange) makeBid(Bids []*pbsOrtbBid, auc *auction, returnCreative bool
 		bidExt := &openrtb_ext.ExtBid{
 			Bidder: thisBid.bid.Ext,
 			Prebid: &openrtb_ext.ExtBidPrebid{
-				Targeting: thisBid.bidTargets,
-				Type:      thisBid.bidType,
-				Video:     thisBid.bidVideo,
+				DealPriority: thisBid.dealPriority,
+				Targeting:    thisBid.bidTargets,
+				Type:         thisBid.bidType,
+				Video:        thisBid.bidVideo,
 			},
 		}
+		if billingEvent, ok := auc.billingEventFor(thisBid); ok {
+			bidExt.Prebid.Events = &openrtb_ext.ExtBidPrebidEvents{Billing: billingEvent}
+		}
 		if cacheInfo, found := e.getBidCacheInfo(thisBid, auc); found {
 			bidExt.Prebid.Cache = &openrtb_ext.ExtBidPrebidCache{
 				Bids: &cacheInfo,
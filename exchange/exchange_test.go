@@ -18,6 +18,7 @@ import (
 	"github.com/prebid/prebid-server/adapters"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
@@ -901,7 +902,7 @@ func TestBidResponseCurrency(t *testing.T) {
 				ID:      "some-request-id",
 				SeatBid: sampleSeatBid,
 				Cur:     "USD",
-				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500}
+				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500,"prebid":{"currency":"USD"}}
 `),
 			},
 		},
@@ -917,7 +918,7 @@ func TestBidResponseCurrency(t *testing.T) {
 				ID:      "some-request-id",
 				SeatBid: emptySeatBid,
 				Cur:     "",
-				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500}
+				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500,"prebid":{"currency":"USD"}}
 `),
 			},
 		},
@@ -933,7 +934,7 @@ func TestBidResponseCurrency(t *testing.T) {
 				ID:      "some-request-id",
 				SeatBid: sampleSeatBid,
 				Cur:     "",
-				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500}
+				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500,"prebid":{"currency":"USD"}}
 `),
 			},
 		},
@@ -949,7 +950,7 @@ func TestBidResponseCurrency(t *testing.T) {
 				ID:      "some-request-id",
 				SeatBid: emptySeatBid,
 				Cur:     "",
-				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500}
+				Ext: json.RawMessage(`{"responsetimemillis":{"appnexus":5},"tmaxrequest":500,"prebid":{"currency":"USD"}}
 `),
 			},
 		},
@@ -1196,6 +1197,48 @@ func TestPanicRecoveryHighLevel(t *testing.T) {
 
 }
 
+func TestBidderWarnings(t *testing.T) {
+	cfg := &config.Configuration{}
+	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
+	e := NewExchange(&http.Client{}, &mockCache{}, cfg, &metricsConf.DummyMetricsEngine{}, adapters.ParseBidderInfos(cfg.Adapters, "../static/bidder-info", openrtb_ext.BidderList()), gdpr.AlwaysAllow{}, currencyConverter).(*exchange)
+	e.adapterMap = map[openrtb_ext.BidderName]adaptedBidder{
+		openrtb_ext.BidderAppnexus: &mockAdaptedBidder{
+			bidResponse: &pbsOrtbSeatBid{
+				bids:     []*pbsOrtbBid{{bid: &openrtb.Bid{ID: "bid1", ImpID: "imp1", Price: 1}, bidType: openrtb_ext.BidTypeBanner}},
+				currency: "USD",
+			},
+			errorResponse: []error{
+				&errortypes.BlockedBid{Message: "bid2 was blocked"},
+				&errortypes.BadServerResponse{Message: "bid3 is malformed"},
+				&errortypes.Warning{Message: "bid4 was ignored"},
+			},
+		},
+	}
+
+	request := &openrtb.BidRequest{
+		ID:   "req1",
+		Site: &openrtb.Site{},
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}, Ext: json.RawMessage(`{"appnexus":{"placementId":1}}`)}},
+	}
+	categoriesFetcher, err := newCategoryFetcher("./test/category-mapping")
+	if err != nil {
+		t.Fatalf("Failed to create a category Fetcher: %v", err)
+	}
+	bidResponse, err := e.HoldAuction(context.Background(), request, &emptyUsersync{}, pbsmetrics.Labels{}, &config.Account{}, &categoriesFetcher, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var bidResponseExt openrtb_ext.ExtBidResponse
+	if assert.NoError(t, json.Unmarshal(bidResponse.Ext, &bidResponseExt)) {
+		assert.Equal(t, []openrtb_ext.ExtBidderError{
+			{Code: errortypes.BadServerResponseErrorCode, Message: "bid3 is malformed"},
+			{Code: errortypes.UnknownWarningCode, Message: "bid4 was ignored"},
+		}, bidResponseExt.Errors[openrtb_ext.BidderAppnexus], "Only the bid validations' warnings should move out of the errors")
+		assert.Equal(t, []openrtb_ext.ExtBidderError{{Code: errortypes.BlockedBidWarningCode, Message: "bid2 was blocked"}}, bidResponseExt.Warnings[openrtb_ext.BidderAppnexus], "Dropped bids shouldn't fail the bidder")
+	}
+}
+
 func TestTimeoutComputation(t *testing.T) {
 	cacheTimeMillis := 10
 	ex := exchange{
@@ -1305,7 +1348,7 @@ func runSpec(t *testing.T, filename string, spec *exchangeSpec) {
 		*debugLog = *spec.DebugLog
 		debugLog.Regexp = regexp.MustCompile(`[<>]`)
 	}
-	bid, err := ex.HoldAuction(context.Background(), &spec.IncomingRequest.OrtbRequest, mockIdFetcher(spec.IncomingRequest.Usersyncs), pbsmetrics.Labels{}, &config.Account{}, &categoriesFetcher, debugLog)
+	bid, err := ex.HoldAuction(context.Background(), &spec.IncomingRequest.OrtbRequest, mockIdFetcher(spec.IncomingRequest.Usersyncs), pbsmetrics.Labels{}, &config.Account{DefaultCurrency: spec.DefaultCurrency}, &categoriesFetcher, debugLog)
 	responseTimes := extractResponseTimes(t, filename, bid)
 	for _, bidderName := range biddersInAuction {
 		if _, ok := responseTimes[bidderName]; !ok {
@@ -2343,6 +2386,31 @@ func TestUpdateHbPbCatDur(t *testing.T) {
 	}
 }
 
+func TestUpdateDealTier(t *testing.T) {
+	dealTier := &DealTierInfo{Prefix: "tier", MinDealTier: 5}
+
+	testCases := []struct {
+		description      string
+		bidType          openrtb_ext.BidType
+		dealPriority     int
+		expectedDealTier string
+	}{
+		{"Banner bids get a deal tier", openrtb_ext.BidTypeBanner, 5, "tier5"},
+		{"Native bids get a deal tier", openrtb_ext.BidTypeNative, 7, "tier7"},
+		{"Priorities under the min don't get a deal tier", openrtb_ext.BidTypeBanner, 4, ""},
+		{"Video bids use hb_pb_cat_dur instead", openrtb_ext.BidTypeVideo, 5, ""},
+	}
+
+	for _, test := range testCases {
+		bid := &pbsOrtbBid{bid: &openrtb.Bid{ID: "123456"}, bidType: test.bidType, dealPriority: test.dealPriority}
+		auc := &auction{}
+
+		updateDealTier(auc, bid, dealTier)
+
+		assert.Equal(t, test.expectedDealTier, auc.dealTiers[bid], test.description)
+	}
+}
+
 type exchangeSpec struct {
 	IncomingRequest   exchangeRequest        `json:"incomingRequest"`
 	OutgoingRequests  map[string]*bidderSpec `json:"outgoingRequests"`
@@ -2351,6 +2419,7 @@ type exchangeSpec struct {
 	EnforceLMT        bool                   `json:"enforceLmt"`
 	AssumeGDPRApplies bool                   `json:"assume_gdpr_applies"`
 	DebugLog          *DebugLog              `json:"debuglog,omitempty"`
+	DefaultCurrency   string                 `json:"accountDefaultCurrency,omitempty"`
 }
 
 type exchangeRequest struct {
@@ -2411,7 +2480,7 @@ type validatingBidder struct {
 	mockResponses map[string]bidderResponse
 }
 
-func (b *validatingBidder) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (seatBid *pbsOrtbSeatBid, errs []error) {
+func (b *validatingBidder) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, adjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (seatBid *pbsOrtbSeatBid, errs []error) {
 	if expectedRequest, ok := b.expectations[string(name)]; ok {
 		if expectedRequest != nil {
 			if expectedRequest.BidAdjustment != bidAdjustment {
@@ -2582,6 +2651,60 @@ func (e *mockUsersync) GetId(bidder openrtb_ext.BidderName) (id string, exists b
 
 type panicingAdapter struct{}
 
-func (panicingAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (posb *pbsOrtbSeatBid, errs []error) {
+func (panicingAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, adjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (posb *pbsOrtbSeatBid, errs []error) {
 	panic("Panic! Panic! The world is ending!")
 }
+
+func TestGetAuctionCurrencyRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"dataAsOf":"2018-09-12","conversions":{"USD":{"GBP":0.8,"PLN":4}}}`))
+	}))
+	defer server.Close()
+	currencyConverter := currencies.NewRateConverter(&http.Client{}, server.URL, time.Duration(0))
+	currencyConverter.Run()
+	e := &exchange{currencyConverter: currencyConverter}
+
+	usePBSRates := true
+	noPBSRates := false
+	customRates := map[string]map[string]float64{"USD": {"PLN": 4.5}}
+
+	testCases := []struct {
+		description  string
+		currency     *openrtb_ext.ExtRequestCurrency
+		from         string
+		to           string
+		expectedRate float64
+		expectError  bool
+	}{
+		{"No custom rates", nil, "USD", "PLN", 4, false},
+		{"Custom rates are used first", &openrtb_ext.ExtRequestCurrency{ConversionRates: customRates}, "USD", "PLN", 4.5, false},
+		{"Server rates are used by default", &openrtb_ext.ExtRequestCurrency{ConversionRates: customRates}, "USD", "GBP", 0.8, false},
+		{"Server rates are used on request", &openrtb_ext.ExtRequestCurrency{ConversionRates: customRates, UsePBSRates: &usePBSRates}, "USD", "GBP", 0.8, false},
+		{"Server rates aren't used on request", &openrtb_ext.ExtRequestCurrency{ConversionRates: customRates, UsePBSRates: &noPBSRates}, "USD", "GBP", 0, true},
+		{"Custom rates use intermediate currencies", &openrtb_ext.ExtRequestCurrency{ConversionRates: map[string]map[string]float64{"USD": {"PLN": 4, "SEK": 10}}, UsePBSRates: &noPBSRates}, "PLN", "SEK", 2.5, false},
+	}
+
+	for _, test := range testCases {
+		rate, err := e.getAuctionCurrencyRates(test.currency).GetRate(test.from, test.to)
+		if test.expectError {
+			assert.Error(t, err, test.description)
+			continue
+		}
+		assert.NoError(t, err, test.description)
+		assert.InDelta(t, test.expectedRate, rate, 0.0001, test.description)
+	}
+}
+
+func TestSharedCookieIdFetcher(t *testing.T) {
+	fetcher := &sharedCookieIdFetcher{
+		IdFetcher:     &mockUsersync{syncs: map[string]string{"appnexus": "core-id", "ownlabel": "own-id"}},
+		sharedCookies: map[openrtb_ext.BidderName]openrtb_ext.BidderName{"sharedlabel": openrtb_ext.BidderAppnexus},
+	}
+
+	id, ok := fetcher.GetId("sharedlabel")
+	assert.True(t, ok)
+	assert.Equal(t, "core-id", id)
+	id, ok = fetcher.GetId("ownlabel")
+	assert.True(t, ok)
+	assert.Equal(t, "own-id", id)
+}
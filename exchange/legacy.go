@@ -33,7 +33,7 @@ type adaptedAdapter struct {
 //
 // This is not ideal. OpenRTB provides a superset of the legacy data structures.
 // For requests which use those features, the best we can do is respond with "no bid".
-func (bidder *adaptedAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
+func (bidder *adaptedAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, adjustmentRules *openrtb_ext.ExtRequestPrebidBidAdjustments, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
 	legacyRequest, legacyBidder, errs := bidder.toLegacyAdapterInputs(request, name)
 	if legacyRequest == nil || legacyBidder == nil {
 		return nil, errs
@@ -49,6 +49,21 @@ func (bidder *adaptedAdapter) requestBid(ctx context.Context, request *openrtb.B
 	}
 
 	finalResponse, moreErrs := toNewResponse(legacyBids, legacyBidder, name)
+	if adjustmentRules != nil {
+		adjustedBids := make([]*pbsOrtbBid, 0, len(finalResponse.bids))
+		for _, bid := range finalResponse.bids {
+			adjustments := getBidAdjustments(adjustmentRules, bid.bidType, name, bid.bid.DealID)
+			// Legacy bids are always in USD
+			if len(adjustments) > 0 {
+				if err := adjustBidPrice(bid.bid, "USD", adjustments, conversions); err != nil {
+					moreErrs = append(moreErrs, err)
+					continue
+				}
+			}
+			adjustedBids = append(adjustedBids, bid)
+		}
+		finalResponse.bids = adjustedBids
+	}
 	return finalResponse, append(errs, moreErrs...)
 }
 
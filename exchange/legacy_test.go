@@ -14,6 +14,7 @@ import (
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/adapters"
 	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbs"
 	"github.com/prebid/prebid-server/usersync"
@@ -61,7 +62,7 @@ func TestSiteVideo(t *testing.T) {
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	_, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	_, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 	if len(errs) > 0 {
 		t.Errorf("Unexpected error requesting bids: %v", errs)
 	}
@@ -95,7 +96,7 @@ func TestAppBanner(t *testing.T) {
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	_, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	_, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 	if len(errs) > 0 {
 		t.Errorf("Unexpected error requesting bids: %v", errs)
 	}
@@ -141,7 +142,7 @@ func TestBidTransforms(t *testing.T) {
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	seatBid, errs := exchangeBidder.requestBid(context.Background(), newAppOrtbRequest(), openrtb_ext.BidderRubicon, bidAdjustment, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := exchangeBidder.requestBid(context.Background(), newAppOrtbRequest(), openrtb_ext.BidderRubicon, bidAdjustment, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 	if len(errs) != 1 {
 		t.Fatalf("Bad error count. Expected 1, got %d", len(errs))
 	}
@@ -185,6 +186,36 @@ func TestBidTransforms(t *testing.T) {
 	}
 }
 
+func TestLegacyBidAdjustmentRulesFailure(t *testing.T) {
+	mockAdapter := mockLegacyAdapter{
+		returnedBids: pbs.PBSBidSlice{
+			&pbs.PBSBid{BidID: "banner", CreativeMediaType: "banner", Price: 1},
+			&pbs.PBSBid{BidID: "video", CreativeMediaType: "video", Price: 1},
+		},
+	}
+	rules := &openrtb_ext.ExtRequestPrebidBidAdjustments{
+		MediaType: map[string]map[string]map[string][]openrtb_ext.ExtRequestPrebidBidAdjustment{
+			"banner": {"*": {"*": {{AdjType: openrtb_ext.BidAdjustmentTypeStatic, Value: 2, Currency: "GBP"}}}},
+		},
+	}
+
+	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
+	rates := currencies.NewRates(time.Now(), map[string]map[string]float64{})
+	seatBid, errs := exchangeBidder.requestBid(context.Background(), newAppOrtbRequest(), openrtb_ext.BidderRubicon, 1, rules, rates, &adapters.ExtraRequestInfo{})
+	if len(errs) != 1 {
+		t.Fatalf("Bad error count. Expected 1, got %d", len(errs))
+	}
+	if _, ok := errs[0].(*errortypes.BidAdjustmentFailed); !ok {
+		t.Errorf("Bad error type. Expected *errortypes.BidAdjustmentFailed, got %T", errs[0])
+	}
+	if len(seatBid.bids) != 1 {
+		t.Fatalf("Bad bid count. Expected 1, got %d", len(seatBid.bids))
+	}
+	if seatBid.bids[0].bid.ID != "video" {
+		t.Errorf("The banner bid should be dropped. Got %s", seatBid.bids[0].bid.ID)
+	}
+}
+
 func TestInsecureImps(t *testing.T) {
 	insecure := int8(0)
 	bidReq := &openrtb.BidRequest{
@@ -290,7 +321,7 @@ func TestErrorResponse(t *testing.T) {
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	_, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	_, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 	if len(errs) != 1 {
 		t.Fatalf("Bad error count. Expected 1, got %d", len(errs))
 	}
@@ -329,7 +360,7 @@ func TestWithTargeting(t *testing.T) {
 	}
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
 	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
-	bid, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderFacebook, 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	bid, errs := exchangeBidder.requestBid(context.Background(), ortbRequest, openrtb_ext.BidderFacebook, 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
 	if len(errs) != 0 {
 		t.Fatalf("This should not produce errors. Got %v", errs)
 	}
@@ -0,0 +1,387 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/coocood/freecache"
+	"github.com/golang/glog"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// BillingNotifier sends the billing notices held by PBS when the client's billing events arrive.
+type BillingNotifier interface {
+	// NotifyBilling fires the billing notice of a winning bid. It returns ErrBillingNoticeNotFound if the
+	// notice doesn't exist, has expired, or was already sent.
+	NotifyBilling(accountID string, auctionID string, bidder string, bidID string) error
+}
+
+// ErrBillingNoticeNotFound is returned by NotifyBilling for the bids which have no pending billing notice.
+var ErrBillingNoticeNotFound = errors.New("no billing notice is pending for this bid")
+
+// bidNotifier is implemented by the adaptedBidders which can tell their bidder about the outcome of its bids.
+type bidNotifier interface {
+	// notify sends the notice to the bidder. It blocks until the bidder answers or the notification times out.
+	notify(notice *adapters.BidNotice)
+}
+
+// bidLoss is a bid which lost the auction, along with the reason why.
+type bidLoss struct {
+	seat   openrtb_ext.BidderName
+	bid    *pbsOrtbBid
+	reason openrtb_ext.LossReason
+}
+
+// seatNotice is a notice for the bidder behind one of the auction's seats.
+type seatNotice struct {
+	seat   openrtb_ext.BidderName
+	notice *adapters.BidNotice
+}
+
+func (bidder *bidderAdapter) notify(notice *adapters.BidNotice) {
+	var corebidder adapters.Bidder = bidder.Bidder
+	// The bidder adapter normally stores an info-aware bidder (a bidder wrapper)
+	// rather than the actual bidder. So we need to unpack that first.
+	if b, ok := corebidder.(*adapters.InfoAwareBidder); ok {
+		corebidder = b.Bidder
+	}
+
+	var req *adapters.RequestData
+	if nb, ok := corebidder.(adapters.NotifyingBidder); ok {
+		var errs []error
+		req, errs = nb.MakeNotification(notice)
+		if len(errs) > 0 {
+			glog.Warningf("%s notice for %s: failed to make the request: %v", notice.Type, bidder.BidderName, errs[0])
+			return
+		}
+	} else {
+		req = adapters.MakeNotification(notice)
+	}
+	if req == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bidder.notificationTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequest(req.Method, req.Uri, bytes.NewBuffer(req.Body))
+	if err != nil {
+		glog.Warningf("%s notice for %s: failed to make the request: %v", notice.Type, bidder.BidderName, err)
+		return
+	}
+	httpReq.Header = req.Headers
+	httpResp, err := ctxhttp.Do(ctx, bidder.Client, httpReq)
+	if err != nil {
+		glog.V(2).Infof("%s notice for %s: %v", notice.Type, bidder.BidderName, err)
+		return
+	}
+	ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 400 {
+		glog.V(2).Infof("%s notice for %s: status %d", notice.Type, bidder.BidderName, httpResp.StatusCode)
+	}
+}
+
+func (v *validatedBidder) notify(notice *adapters.BidNotice) {
+	if notifier, ok := v.bidder.(bidNotifier); ok {
+		notifier.notify(notice)
+	}
+}
+
+// makeLossNotices builds a loss notice for every bid which didn't win its imp. The bids left in the response
+// lost to a higher bid, or were below the imp's floor. The bids dropped from the response carry the reason
+// they were removed for.
+//
+// The notices carry the price of the imp's winner, which bidders use to shade their bids.
+func makeLossNotices(bidRequest *openrtb.BidRequest, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, removed []bidLoss, auc *auction, conversions currencies.Conversions) []seatNotice {
+	floors := make(map[string]openrtb.Imp, len(bidRequest.Imp))
+	for _, imp := range bidRequest.Imp {
+		floors[imp.ID] = imp
+	}
+	losses := append([]bidLoss(nil), removed...)
+	for seat, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.bids {
+			if auc.winningBids[bid.bid.ImpID] == bid {
+				continue
+			}
+			reason := openrtb_ext.LossReasonLostToHigherBid
+			if imp := floors[bid.bid.ImpID]; imp.BidFloor > 0 {
+				if floor, err := convertPrice(conversions, imp.BidFloor, imp.BidFloorCur, seatBid.currency); err == nil && bid.bid.Price < floor {
+					reason = openrtb_ext.LossReasonBelowAuctionFloor
+				}
+			}
+			losses = append(losses, bidLoss{seat: seat, bid: bid, reason: reason})
+		}
+	}
+
+	currency := getAuctionCurrency(bidRequest)
+	notices := make([]seatNotice, 0, len(losses))
+	for _, loss := range losses {
+		bid := *loss.bid.bid
+		notices = append(notices, seatNotice{
+			seat: loss.seat,
+			notice: &adapters.BidNotice{
+				Type:       adapters.NoticeLoss,
+				Bid:        &bid,
+				AuctionID:  bidRequest.ID,
+				Seat:       loss.seat.String(),
+				Price:      auc.winningPrice(bid.ImpID),
+				Currency:   currency,
+				LossReason: loss.reason,
+			},
+		})
+	}
+	return notices
+}
+
+// findRemovedBids lists the bids of before which are no longer in seatBids, as losses for the reason
+func findRemovedBids(before map[openrtb_ext.BidderName][]*pbsOrtbBid, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, reason openrtb_ext.LossReason) []bidLoss {
+	var removed []bidLoss
+	for seat, bids := range before {
+		kept := make(map[*pbsOrtbBid]bool)
+		if seatBid := seatBids[seat]; seatBid != nil {
+			for _, bid := range seatBid.bids {
+				kept[bid] = true
+			}
+		}
+		for _, bid := range bids {
+			if !kept[bid] {
+				removed = append(removed, bidLoss{seat: seat, bid: bid, reason: reason})
+			}
+		}
+	}
+	return removed
+}
+
+// copyBids lists the bids of every seat, so that the bids removed later on can be found
+func copyBids(seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid) map[openrtb_ext.BidderName][]*pbsOrtbBid {
+	bids := make(map[openrtb_ext.BidderName][]*pbsOrtbBid, len(seatBids))
+	for seat, seatBid := range seatBids {
+		if seatBid != nil {
+			bids[seat] = append([]*pbsOrtbBid(nil), seatBid.bids...)
+		}
+	}
+	return bids
+}
+
+// makeWinNotices builds the notices of noticeType for the winner of every imp. The notice URL taken over by
+// PBS is removed from the winning bids, so that the client doesn't fire it too.
+func makeWinNotices(bidRequest *openrtb.BidRequest, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, auc *auction, noticeType adapters.NoticeType) []seatNotice {
+	if auc == nil {
+		return nil
+	}
+	currency := getAuctionCurrency(bidRequest)
+	var notices []seatNotice
+	for seat, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.bids {
+			if auc.winningBids[bid.bid.ImpID] != bid {
+				continue
+			}
+			bidCopy := *bid.bid
+			notices = append(notices, seatNotice{
+				seat: seat,
+				notice: &adapters.BidNotice{
+					Type:      noticeType,
+					Bid:       &bidCopy,
+					AuctionID: bidRequest.ID,
+					Seat:      seat.String(),
+					Price:     auc.winningPrice(bid.bid.ImpID),
+					Currency:  currency,
+				},
+			})
+			if noticeType == adapters.NoticeWin {
+				bid.bid.NURL = ""
+			} else if noticeType == adapters.NoticeBilling {
+				bid.bid.BURL = ""
+			}
+		}
+	}
+	return notices
+}
+
+// winNotices are the win and billing notices taken over by PBS for an auction's winners.
+type winNotices struct {
+	wins     []seatNotice
+	billings []seatNotice
+}
+
+// takeWinNotices takes the notice URLs of the winners which PBS notifies itself. It must run before the bids
+// are cached, so that the cached bids don't carry them either.
+func (e *exchange) takeWinNotices(bidRequest *openrtb.BidRequest, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, auc *auction, account *config.Account) *winNotices {
+	notices := &winNotices{}
+	if account.Notifications.ServerSideWins && bidRequest.App != nil {
+		notices.wins = makeWinNotices(bidRequest, seatBids, auc, adapters.NoticeWin)
+	}
+	if account.Notifications.ServerSideBilling && e.billingNotices != nil {
+		notices.billings = makeWinNotices(bidRequest, seatBids, auc, adapters.NoticeBilling)
+	}
+	return notices
+}
+
+// sendBidNotices fires the win and loss notices of an auction once its bids are cached. The winners which
+// failed to cache can't be served, so they get their notice URLs back instead. The billing notices are held
+// until their events arrive, and the winning bids are given their billing event URL.
+func (e *exchange) sendBidNotices(bidRequest *openrtb.BidRequest, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, taken *winNotices, removed []bidLoss, auc *auction, conversions currencies.Conversions, targData *targetData, aliases map[string]string, account *config.Account) {
+	var notices []seatNotice
+	if account.Notifications.LossNotices {
+		notices = makeLossNotices(bidRequest, seatBids, removed, auc, conversions)
+	}
+	for _, seatNotice := range taken.wins {
+		if winner := auc.winningBids[seatNotice.notice.Bid.ImpID]; auc.cacheFailed(winner, targData) {
+			winner.bid.NURL = seatNotice.notice.Bid.NURL
+			continue
+		}
+		notices = append(notices, seatNotice)
+	}
+	for _, seatNotice := range notices {
+		if notifier, ok := e.adapterMap[resolveBidder(seatNotice.seat.String(), aliases)].(bidNotifier); ok {
+			e.noticeSender.send(notifier, seatNotice.notice)
+		}
+	}
+
+	for _, seatNotice := range taken.billings {
+		winner := auc.winningBids[seatNotice.notice.Bid.ImpID]
+		if auc.cacheFailed(winner, targData) {
+			winner.bid.BURL = seatNotice.notice.Bid.BURL
+			continue
+		}
+		pending := pendingBillingNotice{
+			Bidder: string(resolveBidder(seatNotice.seat.String(), aliases)),
+			Notice: seatNotice.notice,
+		}
+		if eventURL, err := e.billingNotices.hold(account.ID, pending); err == nil {
+			if auc.billingEvents == nil {
+				auc.billingEvents = make(map[*pbsOrtbBid]string)
+			}
+			auc.billingEvents[winner] = eventURL
+		} else {
+			winner.bid.BURL = seatNotice.notice.Bid.BURL
+			glog.Errorf("Failed to hold the billing notice of bid %s from %s: %v", seatNotice.notice.Bid.ID, seatNotice.seat, err)
+		}
+	}
+}
+
+func (e *exchange) NotifyBilling(accountID string, auctionID string, bidder string, bidID string) error {
+	if e.billingNotices == nil {
+		return ErrBillingNoticeNotFound
+	}
+	pending, err := e.billingNotices.take(accountID, auctionID, bidder, bidID)
+	if err != nil {
+		return err
+	}
+	if notifier, ok := e.adapterMap[openrtb_ext.BidderName(pending.Bidder)].(bidNotifier); ok {
+		e.noticeSender.send(notifier, pending.Notice)
+	}
+	return nil
+}
+
+const defaultMaxConcurrentNotices = 100
+
+// noticeSender sends the notices in the background, with at most a fixed number of them in flight. The
+// notices sent while it's full are dropped, so that slow bidders can't pile up goroutines and connections.
+type noticeSender struct {
+	inFlight chan struct{}
+}
+
+func newNoticeSender(maxConcurrent int) *noticeSender {
+	if maxConcurrent == 0 {
+		maxConcurrent = defaultMaxConcurrentNotices
+	}
+	return &noticeSender{
+		inFlight: make(chan struct{}, maxConcurrent),
+	}
+}
+
+func (sender *noticeSender) send(notifier bidNotifier, notice *adapters.BidNotice) {
+	select {
+	case sender.inFlight <- struct{}{}:
+		go func() {
+			defer func() { <-sender.inFlight }()
+			notifier.notify(notice)
+		}()
+	default:
+		glog.Warningf("Dropped the %s notice of bid %s from %s: too many notices are in flight", notice.Type, notice.Bid.ID, notice.Seat)
+	}
+}
+
+// pendingBillingNotice is a billing notice waiting for its event.
+type pendingBillingNotice struct {
+	// Bidder is the adapter which sends the notice. It differs from the notice's seat for the request's aliases.
+	Bidder string              `json:"bidder"`
+	Notice *adapters.BidNotice `json:"notice"`
+}
+
+// billingNotices holds the billing notices until the client's billing event arrives. The notices are kept in
+// the memory of the PBS instance which ran the auction, so the events should be routed back to it.
+type billingNotices struct {
+	cache       *freecache.Cache
+	ttlSeconds  int
+	externalURL string
+}
+
+func newBillingNotices(cfg *config.Configuration) *billingNotices {
+	return &billingNotices{
+		cache:       freecache.NewCache(cfg.Auction.Notifications.BillingCacheSize),
+		ttlSeconds:  cfg.Auction.Notifications.BillingTTLSeconds,
+		externalURL: strings.TrimSuffix(cfg.ExternalURL, "/"),
+	}
+}
+
+// hold stores a billing notice, and returns the URL of the event which fires it.
+func (n *billingNotices) hold(accountID string, pending pendingBillingNotice) (string, error) {
+	value, err := json.Marshal(pending)
+	if err != nil {
+		return "", err
+	}
+	key := billingNoticeKey(accountID, pending.Notice.AuctionID, pending.Notice.Seat, pending.Notice.Bid.ID)
+	if err := n.cache.Set(key, value, n.ttlSeconds); err != nil {
+		return "", err
+	}
+	query := url.Values{
+		"t":      []string{string(adapters.NoticeBilling)},
+		"a":      []string{accountID},
+		"aid":    []string{pending.Notice.AuctionID},
+		"bidder": []string{pending.Notice.Seat},
+		"b":      []string{pending.Notice.Bid.ID},
+	}
+	return n.externalURL + "/event?" + query.Encode(), nil
+}
+
+// take removes a billing notice, so that it's only sent once.
+func (n *billingNotices) take(accountID string, auctionID string, bidder string, bidID string) (*pendingBillingNotice, error) {
+	key := billingNoticeKey(accountID, auctionID, bidder, bidID)
+	value, err := n.cache.Get(key)
+	if err != nil {
+		return nil, ErrBillingNoticeNotFound
+	}
+	if !n.cache.Del(key) {
+		// Another event took it in the meantime
+		return nil, ErrBillingNoticeNotFound
+	}
+	var pending pendingBillingNotice
+	if err := json.Unmarshal(value, &pending); err != nil {
+		return nil, err
+	}
+	return &pending, nil
+}
+
+// billingNoticeKey identifies a notice by its auction too, since the bidders' bid IDs are only unique within one
+func billingNoticeKey(accountID string, auctionID string, bidder string, bidID string) []byte {
+	return []byte(accountID + "|" + auctionID + "|" + bidder + "|" + bidID)
+}
@@ -0,0 +1,300 @@
+package exchange
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeLossNotices(t *testing.T) {
+	bidRequest := &openrtb.BidRequest{
+		ID:  "auction1",
+		Cur: []string{"USD"},
+		Imp: []openrtb.Imp{{ID: "imp1", BidFloor: 1.5, BidFloorCur: "EUR"}, {ID: "imp2"}},
+	}
+	winner := &pbsOrtbBid{bid: &openrtb.Bid{ID: "winner", ImpID: "imp1", Price: 3}}
+	seatBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		"appnexus": {currency: "USD", bids: []*pbsOrtbBid{
+			winner,
+			{bid: &openrtb.Bid{ID: "outbid", ImpID: "imp1", Price: 2}},
+			{bid: &openrtb.Bid{ID: "below_floor", ImpID: "imp1", Price: 1.6}},
+		}},
+	}
+	removed := []bidLoss{
+		{
+			seat:   "rubicon",
+			bid:    &pbsOrtbBid{bid: &openrtb.Bid{ID: "excluded_imp1", ImpID: "imp1", Price: 4}},
+			reason: openrtb_ext.LossReasonAdvertiserExclusions,
+		},
+		{
+			seat:   "rubicon",
+			bid:    &pbsOrtbBid{bid: &openrtb.Bid{ID: "excluded_imp2", ImpID: "imp2", Price: 4}},
+			reason: openrtb_ext.LossReasonCategoryExclusions,
+		},
+	}
+	auc := &auction{
+		winningBids:    map[string]*pbsOrtbBid{"imp1": winner},
+		clearingPrices: map[*pbsOrtbBid]float64{winner: 2.01},
+	}
+	conversions := currencies.NewRates(time.Now(), map[string]map[string]float64{"EUR": {"USD": 1.2}})
+
+	notices := makeLossNotices(bidRequest, seatBids, removed, auc, conversions)
+
+	reasons := make(map[string]openrtb_ext.LossReason, len(notices))
+	for _, seatNotice := range notices {
+		assert.Equal(t, adapters.NoticeLoss, seatNotice.notice.Type)
+		assert.Equal(t, "auction1", seatNotice.notice.AuctionID)
+		assert.Equal(t, seatNotice.seat.String(), seatNotice.notice.Seat)
+		reasons[seatNotice.notice.Bid.ID] = seatNotice.notice.LossReason
+		if seatNotice.notice.Bid.ImpID == "imp1" {
+			assert.Equal(t, 2.01, seatNotice.notice.Price, "Losses carry the winner's clearing price")
+		} else {
+			assert.Equal(t, 0.0, seatNotice.notice.Price, "Imps without a winner have no price")
+		}
+	}
+	assert.Equal(t, map[string]openrtb_ext.LossReason{
+		"outbid":        openrtb_ext.LossReasonLostToHigherBid,
+		"below_floor":   openrtb_ext.LossReasonBelowAuctionFloor,
+		"excluded_imp1": openrtb_ext.LossReasonAdvertiserExclusions,
+		"excluded_imp2": openrtb_ext.LossReasonCategoryExclusions,
+	}, reasons, "Every bid but the winners should get a loss notice")
+	assert.Len(t, removed, 2, "The removed bids shouldn't be modified")
+}
+
+func TestFindRemovedBids(t *testing.T) {
+	kept := &pbsOrtbBid{bid: &openrtb.Bid{ID: "kept"}}
+	dropped := &pbsOrtbBid{bid: &openrtb.Bid{ID: "dropped"}}
+	emptied := &pbsOrtbBid{bid: &openrtb.Bid{ID: "emptied"}}
+	seatBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		"appnexus": {bids: []*pbsOrtbBid{kept, dropped}},
+		"rubicon":  {bids: []*pbsOrtbBid{emptied}},
+	}
+	before := copyBids(seatBids)
+
+	seatBids["appnexus"].bids = seatBids["appnexus"].bids[:1]
+	seatBids["rubicon"].bids = nil
+
+	removed := findRemovedBids(before, seatBids, openrtb_ext.LossReasonCategoryExclusions)
+	ids := make(map[string]openrtb_ext.BidderName, len(removed))
+	for _, loss := range removed {
+		assert.Equal(t, openrtb_ext.LossReasonCategoryExclusions, loss.reason)
+		ids[loss.bid.bid.ID] = loss.seat
+	}
+	assert.Equal(t, map[string]openrtb_ext.BidderName{"dropped": "appnexus", "emptied": "rubicon"}, ids)
+}
+
+func TestMakeWinNotices(t *testing.T) {
+	bidRequest := &openrtb.BidRequest{ID: "auction1", Imp: []openrtb.Imp{{ID: "imp1"}}}
+	winner := &pbsOrtbBid{bid: &openrtb.Bid{ID: "winner", ImpID: "imp1", Price: 3, NURL: "http://win", BURL: "http://bill"}}
+	loser := &pbsOrtbBid{bid: &openrtb.Bid{ID: "loser", ImpID: "imp1", Price: 2, NURL: "http://win", BURL: "http://bill"}}
+	seatBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		"appnexus": {bids: []*pbsOrtbBid{winner, loser}},
+	}
+	auc := &auction{winningBids: map[string]*pbsOrtbBid{"imp1": winner}}
+
+	notices := makeWinNotices(bidRequest, seatBids, auc, adapters.NoticeWin)
+
+	if assert.Len(t, notices, 1) {
+		assert.Equal(t, "http://win", notices[0].notice.Bid.NURL, "The notice keeps the nurl")
+		assert.Equal(t, 3.0, notices[0].notice.Price)
+	}
+	assert.Empty(t, winner.bid.NURL, "The winner's nurl is taken over by PBS")
+	assert.Equal(t, "http://bill", winner.bid.BURL, "The burl is left to the client")
+	assert.Equal(t, "http://win", loser.bid.NURL, "Losers are untouched")
+}
+
+func TestSendBidNotices(t *testing.T) {
+	bidRequest := &openrtb.BidRequest{ID: "auction1", App: &openrtb.App{}, Imp: []openrtb.Imp{{ID: "imp1"}, {ID: "imp2"}}}
+	cached := &pbsOrtbBid{bid: &openrtb.Bid{ID: "cached", ImpID: "imp1", Price: 3, NURL: "http://win/cached"}, bidType: openrtb_ext.BidTypeBanner}
+	uncached := &pbsOrtbBid{bid: &openrtb.Bid{ID: "uncached", ImpID: "imp2", Price: 3, NURL: "http://win/uncached"}, bidType: openrtb_ext.BidTypeBanner}
+	topLoser := &pbsOrtbBid{bid: &openrtb.Bid{ID: "loser", ImpID: "imp1", Price: 2}, bidType: openrtb_ext.BidTypeBanner}
+	seatBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		"appnexus": {bids: []*pbsOrtbBid{cached, uncached}},
+		"rubicon":  {bids: []*pbsOrtbBid{topLoser}},
+	}
+	removed := []bidLoss{{
+		seat:   "rubicon",
+		bid:    &pbsOrtbBid{bid: &openrtb.Bid{ID: "excluded", ImpID: "imp1", Price: 4}},
+		reason: openrtb_ext.LossReasonCategoryExclusions,
+	}}
+	targData := &targetData{includeBidderKeys: true, includeCacheBids: true}
+
+	testCases := []struct {
+		description     string
+		notifications   config.AccountNotifications
+		expectedNotices []string
+	}{
+		{
+			description:     "Loss notices should only be sent to the accounts which ask for them",
+			notifications:   config.AccountNotifications{LossNotices: true},
+			expectedNotices: []string{"loss excluded", "loss loser"},
+		},
+		{
+			description:     "Win notices should only be sent for the winners which were cached",
+			notifications:   config.AccountNotifications{ServerSideWins: true},
+			expectedNotices: []string{"win cached"},
+		},
+		{
+			description: "No notices should be sent by default",
+		},
+	}
+
+	for _, test := range testCases {
+		cached.bid.NURL = "http://win/cached"
+		uncached.bid.NURL = "http://win/uncached"
+		notifier := &recordingNotifier{notices: make(chan *adapters.BidNotice, 10)}
+		e := &exchange{
+			adapterMap:   map[openrtb_ext.BidderName]adaptedBidder{"appnexus": notifier, "rubicon": notifier},
+			noticeSender: newNoticeSender(10),
+		}
+		auc := newAuction(seatBids, len(bidRequest.Imp))
+		account := &config.Account{Notifications: test.notifications}
+
+		taken := e.takeWinNotices(bidRequest, seatBids, auc, account)
+		auc.cacheIds = map[*openrtb.Bid]string{cached.bid: "uuid"}
+		e.sendBidNotices(bidRequest, seatBids, taken, removed, auc, nil, targData, nil, account)
+
+		var notices []string
+		for len(notices) < len(test.expectedNotices) {
+			notice := <-notifier.notices
+			notices = append(notices, string(notice.Type)+" "+notice.Bid.ID)
+		}
+		sort.Strings(notices)
+		assert.Equal(t, test.expectedNotices, notices, test.description)
+		assert.Empty(t, notifier.notices, test.description)
+		assert.Equal(t, "http://win/uncached", uncached.bid.NURL, "The winners which failed to cache should keep their nurl: "+test.description)
+	}
+}
+
+func TestNoticeSenderLimit(t *testing.T) {
+	release := make(chan struct{})
+	notifier := &recordingNotifier{notices: make(chan *adapters.BidNotice, 10), release: release}
+	sender := newNoticeSender(2)
+
+	for i := 0; i < 3; i++ {
+		sender.send(notifier, &adapters.BidNotice{Type: adapters.NoticeLoss, Bid: &openrtb.Bid{}})
+	}
+	<-notifier.notices
+	<-notifier.notices
+	close(release)
+	assert.Empty(t, notifier.notices, "The notices sent while the sender is full should be dropped")
+	assert.Equal(t, defaultMaxConcurrentNotices, cap(newNoticeSender(0).inFlight))
+}
+
+func TestBillingNotices(t *testing.T) {
+	cfg := &config.Configuration{ExternalURL: "http://pbs.com/"}
+	cfg.Auction.Notifications.BillingCacheSize = 1024 * 1024
+	notices := newBillingNotices(cfg)
+	pending := pendingBillingNotice{
+		Bidder: "appnexus",
+		Notice: &adapters.BidNotice{Type: adapters.NoticeBilling, AuctionID: "auction1", Seat: "districtm", Bid: &openrtb.Bid{ID: "bid&1", BURL: "http://bill"}},
+	}
+
+	eventURL, err := notices.hold("acct", pending)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://pbs.com/event?a=acct&aid=auction1&b=bid%261&bidder=districtm&t=billing", eventURL)
+
+	_, err = notices.take("acct", "auction1", "appnexus", "bid&1")
+	assert.Equal(t, ErrBillingNoticeNotFound, err, "Notices are held under their seat")
+
+	_, err = notices.take("acct", "auction2", "districtm", "bid&1")
+	assert.Equal(t, ErrBillingNoticeNotFound, err, "Notices are held under their auction")
+
+	taken, err := notices.take("acct", "auction1", "districtm", "bid&1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "appnexus", taken.Bidder)
+		assert.Equal(t, "http://bill", taken.Notice.Bid.BURL)
+	}
+
+	_, err = notices.take("acct", "auction1", "districtm", "bid&1")
+	assert.Equal(t, ErrBillingNoticeNotFound, err, "Notices are only sent once")
+}
+
+func TestBidderNotify(t *testing.T) {
+	requests := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL.RequestURI()
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		description string
+		bidder      adapters.Bidder
+		expectedURI string
+	}{
+		{
+			description: "Bidders are sent to their lurl by default",
+			bidder:      &goodSingleBidder{},
+			expectedURI: "/loss?reason=102",
+		},
+		{
+			description: "NotifyingBidders make their own requests",
+			bidder:      &bidNoticeBidder{uri: server.URL + "/custom"},
+			expectedURI: "/custom",
+		},
+		{
+			description: "NotifyingBidders can skip notices",
+			bidder:      &bidNoticeBidder{},
+		},
+	}
+
+	for _, test := range testCases {
+		bidder := &bidderAdapter{
+			Bidder:              adapters.EnforceBidderInfo(test.bidder, adapters.BidderInfo{Capabilities: &adapters.CapabilitiesInfo{}}),
+			BidderName:          "appnexus",
+			Client:              server.Client(),
+			notificationTimeout: time.Second,
+		}
+		bidder.notify(&adapters.BidNotice{
+			Type:       adapters.NoticeLoss,
+			Bid:        &openrtb.Bid{LURL: server.URL + "/loss?reason=${AUCTION_LOSS}"},
+			LossReason: openrtb_ext.LossReasonLostToHigherBid,
+		})
+
+		var uris []string
+		for len(requests) > 0 {
+			uris = append(uris, <-requests)
+		}
+		if test.expectedURI == "" {
+			assert.Empty(t, uris, test.description)
+		} else {
+			assert.Equal(t, []string{test.expectedURI}, uris, test.description)
+		}
+	}
+}
+
+type bidNoticeBidder struct {
+	goodSingleBidder
+	uri string
+}
+
+func (bidder *bidNoticeBidder) MakeNotification(notice *adapters.BidNotice) (*adapters.RequestData, []error) {
+	if bidder.uri == "" {
+		return nil, nil
+	}
+	return &adapters.RequestData{Method: "POST", Uri: bidder.uri}, nil
+}
+
+// recordingNotifier is an adaptedBidder which records the notices it's sent. They block until release is closed,
+// if it's set.
+type recordingNotifier struct {
+	mockAdaptedBidder
+	notices chan *adapters.BidNotice
+	release chan struct{}
+}
+
+func (notifier *recordingNotifier) notify(notice *adapters.BidNotice) {
+	notifier.notices <- notice
+	if notifier.release != nil {
+		<-notifier.release
+	}
+}
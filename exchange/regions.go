@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/adapters/generic"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// regionSelector picks the regions whose endpoints serve an auction
+type regionSelector struct {
+	// countries maps the upper-cased country codes to their region
+	countries  map[string]string
+	datacenter string
+}
+
+func newRegionSelector(cfg config.Regions) *regionSelector {
+	selector := &regionSelector{
+		countries:  make(map[string]string),
+		datacenter: cfg.Datacenter,
+	}
+	for region, countries := range cfg.Countries {
+		for _, country := range countries {
+			selector.countries[strings.ToUpper(country)] = region
+		}
+	}
+	return selector
+}
+
+// regions returns the regions which suit the request, the best one first: the region of the device's country,
+// then the host's datacenter.
+func (selector *regionSelector) regions(request *openrtb.BidRequest) []string {
+	regions := make([]string, 0, 2)
+	if request.Device != nil && request.Device.Geo != nil {
+		if region, ok := selector.countries[strings.ToUpper(request.Device.Geo.Country)]; ok {
+			regions = append(regions, region)
+		}
+	}
+	if selector.datacenter != "" {
+		regions = append(regions, selector.datacenter)
+	}
+	return regions
+}
+
+// bidderFor returns the Bidder set up with the bidder's endpoint for the request's region. It's the default
+// Bidder when the bidder has no endpoint for the regions suiting the request.
+func (bidder *bidderAdapter) bidderFor(request *openrtb.BidRequest) adapters.Bidder {
+	if len(bidder.regionalBidders) == 0 {
+		return bidder.Bidder
+	}
+	for _, region := range bidder.regions.regions(request) {
+		if regionalBidder, ok := bidder.regionalBidders[region]; ok {
+			return regionalBidder
+		}
+	}
+	return bidder.Bidder
+}
+
+// newRegionalBidders builds a Bidder for each of the bidder's regional endpoints. Like the default Bidder, the
+// bidders declared in static/bidder-info run on the generic adapter, and the host's aliases on their core bidder's
+// code.
+func newRegionalBidders(client *http.Client, cfg *config.Configuration, name openrtb_ext.BidderName, info adapters.BidderInfo) map[string]adapters.Bidder {
+	adapterCfg := cfg.Adapters[strings.ToLower(string(name))]
+	if len(adapterCfg.Endpoints) == 0 {
+		return nil
+	}
+	core := name
+	if adapterCfg.AliasOf != "" {
+		core = openrtb_ext.BidderName(adapterCfg.AliasOf)
+	}
+
+	bidders := make(map[string]adapters.Bidder, len(adapterCfg.Endpoints))
+	for region, endpoint := range adapterCfg.Endpoints {
+		var bidder adapters.Bidder
+		if info.OpenRTB != nil {
+			bidder = generic.NewConfigBidder(endpoint, info.OpenRTB)
+		} else {
+			regionalCfg := adapterCfg
+			regionalCfg.Endpoint = endpoint
+			ortbBidders, _ := newCoreBidders(client, withCoreAdapterConfig(cfg, core, regionalCfg))
+			if bidder = ortbBidders[core]; bidder == nil {
+				continue
+			}
+		}
+		bidders[region] = adapters.EnforceBidderInfo(bidder, info)
+	}
+	return bidders
+}
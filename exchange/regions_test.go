@@ -0,0 +1,141 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	metricsConfig "github.com/prebid/prebid-server/pbsmetrics/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionSelector(t *testing.T) {
+	selector := newRegionSelector(config.Regions{
+		Datacenter: "us",
+		Countries:  map[string][]string{"eu": {"DEU", "fra"}},
+	})
+
+	testCases := []struct {
+		description string
+		device      *openrtb.Device
+		expected    []string
+	}{
+		{
+			description: "A device in a region should get it before the datacenter",
+			device:      &openrtb.Device{Geo: &openrtb.Geo{Country: "FRA"}},
+			expected:    []string{"eu", "us"},
+		},
+		{
+			description: "A device outside of the regions should get the datacenter",
+			device:      &openrtb.Device{Geo: &openrtb.Geo{Country: "USA"}},
+			expected:    []string{"us"},
+		},
+		{
+			description: "A device without geo should get the datacenter",
+			device:      &openrtb.Device{},
+			expected:    []string{"us"},
+		},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, selector.regions(&openrtb.BidRequest{Device: test.device}), test.description)
+	}
+	assert.Empty(t, newRegionSelector(config.Regions{}).regions(&openrtb.BidRequest{}), "There should be no regions without config")
+}
+
+func TestBidderRegionalEndpoint(t *testing.T) {
+	server := httptest.NewServer(mockHandler(200, "getBody", "{}"))
+	defer server.Close()
+
+	newBidder := func() *goodSingleBidder {
+		return &goodSingleBidder{
+			httpRequest: &adapters.RequestData{Method: "POST", Uri: server.URL, Headers: http.Header{}},
+			bidResponse: &adapters.BidderResponse{},
+		}
+	}
+	defaultBidder, euBidder, usBidder := newBidder(), newBidder(), newBidder()
+	bidder := adaptBidder(defaultBidder, server.Client(), &config.Configuration{}, &metricsConfig.DummyMetricsEngine{}, openrtb_ext.BidderAppnexus)
+	bidder.regionalBidders = map[string]adapters.Bidder{"eu": euBidder, "us": usBidder}
+	bidder.regions = newRegionSelector(config.Regions{Datacenter: "us", Countries: map[string][]string{"eu": {"DEU"}, "apac": {"JPN"}}})
+
+	testCases := []struct {
+		description string
+		country     string
+		expected    *goodSingleBidder
+	}{
+		{description: "The bidder's endpoint for the device's region should be used", country: "DEU", expected: euBidder},
+		{description: "The bidder's endpoint for the datacenter should be used when it has none for the device's region", country: "JPN", expected: usBidder},
+	}
+
+	currencyConverter := currencies.NewRateConverter(&http.Client{}, "", time.Duration(0))
+	for _, test := range testCases {
+		defaultBidder.bidRequest, euBidder.bidRequest, usBidder.bidRequest = nil, nil, nil
+		request := &openrtb.BidRequest{
+			Imp:    []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+			Device: &openrtb.Device{Geo: &openrtb.Geo{Country: test.country}},
+		}
+		_, errs := bidder.requestBid(context.Background(), request, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+		assert.Empty(t, errs, test.description)
+		assert.NotNil(t, test.expected.bidRequest, test.description)
+		assert.Nil(t, defaultBidder.bidRequest, test.description)
+	}
+
+	bidder.regions = newRegionSelector(config.Regions{})
+	defaultBidder.bidRequest = nil
+	bidder.requestBid(context.Background(), &openrtb.BidRequest{Imp: []openrtb.Imp{{ID: "imp1"}}}, "test", 1.0, nil, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	assert.NotNil(t, defaultBidder.bidRequest, "The default endpoint should be used without a region")
+}
+
+func TestNewRegionalBidders(t *testing.T) {
+	cfg := &config.Configuration{Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderGeneric): {
+			Endpoint:  "http://{{.Host}}/default",
+			Endpoints: map[string]string{"eu": "http://{{.Host}}/eu"},
+		},
+		"whitelabel": {
+			AliasOf:   string(openrtb_ext.BidderGeneric),
+			Endpoint:  "http://{{.Host}}/default",
+			Endpoints: map[string]string{"apac": "http://{{.Host}}/apac"},
+		},
+	}}
+	info := adapters.BidderInfo{
+		Status:       adapters.StatusActive,
+		Capabilities: &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{MediaTypes: []openrtb_ext.BidType{openrtb_ext.BidTypeBanner}}},
+	}
+	request := &openrtb.BidRequest{
+		Site: &openrtb.Site{},
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}, Ext: json.RawMessage(`{"bidder":{"host":"bidder.com"}}`)}},
+	}
+
+	testCases := []struct {
+		description string
+		name        openrtb_ext.BidderName
+		region      string
+		expectedURI string
+	}{
+		{description: "A core bidder should use its regional endpoint", name: openrtb_ext.BidderGeneric, region: "eu", expectedURI: "http://bidder.com/eu"},
+		{description: "An alias should run its core bidder's code with its regional endpoint", name: "whitelabel", region: "apac", expectedURI: "http://bidder.com/apac"},
+	}
+
+	for _, test := range testCases {
+		bidders := newRegionalBidders(&http.Client{}, cfg, test.name, info)
+		if !assert.Len(t, bidders, 1, test.description) {
+			continue
+		}
+		reqData, errs := bidders[test.region].MakeRequests(request, &adapters.ExtraRequestInfo{})
+		assert.Empty(t, errs, test.description)
+		if assert.Len(t, reqData, 1, test.description) {
+			assert.Equal(t, test.expectedURI, reqData[0].Uri, test.description)
+		}
+	}
+
+	assert.Nil(t, newRegionalBidders(&http.Client{}, cfg, openrtb_ext.BidderAppnexus, info), "Bidders without regional endpoints should have no regional Bidders")
+}
@@ -2,8 +2,10 @@ package exchange
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/macros"
 	"github.com/prebid/prebid-server/openrtb_ext"
 )
 
@@ -17,15 +19,47 @@ const MaxKeyLength = 20
 // All functions on this struct are all nil-safe.
 // If the value is nil, then no targeting data will be tracked.
 type targetData struct {
-	priceGranularity  openrtb_ext.PriceGranularity
-	includeWinners    bool
-	includeBidderKeys bool
-	includeCacheBids  bool
-	includeCacheVast  bool
-	includeFormat     bool
+	priceGranularity openrtb_ext.PriceGranularity
+	// mediaTypePriceGranularity overrides priceGranularity for the media types it sets
+	mediaTypePriceGranularity openrtb_ext.MediaTypePriceGranularity
+	includeWinners            bool
+	includeBidderKeys         bool
+	includeCacheBids          bool
+	includeCacheVast          bool
+	includeFormat             bool
 	// cacheHost and cachePath exist to supply cache host and path as targeting parameters
 	cacheHost string
 	cachePath string
+	// prefix replaces "hb" at the start of the keys. Empty means "hb".
+	prefix string
+	// includeKeys limits the standard keys to the ones it contains, if it isn't empty
+	includeKeys map[string]bool
+	// maxKeyLength truncates the keys which are longer. Zero means MaxKeyLength.
+	maxKeyLength int
+	customKeys   []customTargetingKey
+}
+
+// customTargetingKey is a targeting key whose value is resolved from the bid
+type customTargetingKey struct {
+	key   openrtb_ext.TargetingKey
+	value macros.TargetingKeyValue
+}
+
+// priceGranularityFor returns the price granularity used to round the bids of a media type
+func (targData *targetData) priceGranularityFor(bidType openrtb_ext.BidType) openrtb_ext.PriceGranularity {
+	var granularity *openrtb_ext.PriceGranularity
+	switch bidType {
+	case openrtb_ext.BidTypeBanner:
+		granularity = targData.mediaTypePriceGranularity.Banner
+	case openrtb_ext.BidTypeVideo:
+		granularity = targData.mediaTypePriceGranularity.Video
+	case openrtb_ext.BidTypeNative:
+		granularity = targData.mediaTypePriceGranularity.Native
+	}
+	if granularity != nil {
+		return *granularity
+	}
+	return targData.priceGranularity
 }
 
 // setTargeting writes all the targeting params into the bids.
@@ -68,6 +102,9 @@ func (targData *targetData) setTargeting(auc *auction, isApp bool, categoryMappi
 			if deal := topBidPerBidder.bid.DealID; len(deal) > 0 {
 				targData.addKeys(targets, openrtb_ext.HbDealIDConstantKey, deal, bidderName, isOverallWinner)
 			}
+			if dealTier, ok := auc.dealTiers[topBidPerBidder]; ok {
+				targData.addKeys(targets, openrtb_ext.HbDealTierKey, dealTier, bidderName, isOverallWinner)
+			}
 
 			if isApp {
 				targData.addKeys(targets, openrtb_ext.HbEnvKey, openrtb_ext.HbEnvKeyApp, bidderName, isOverallWinner)
@@ -75,6 +112,9 @@ func (targData *targetData) setTargeting(auc *auction, isApp bool, categoryMappi
 			if len(categoryMapping) > 0 {
 				targData.addKeys(targets, openrtb_ext.HbCategoryDurationKey, categoryMapping[topBidPerBidder.bid.ID], bidderName, isOverallWinner)
 			}
+			if len(targData.customKeys) > 0 {
+				targData.addCustomKeys(targets, makeTargetingKeyParams(topBidPerBidder, bidderName, auc.roundedPrices[topBidPerBidder]), bidderName, isOverallWinner)
+			}
 
 			topBidPerBidder.bidTargets = targets
 		}
@@ -82,11 +122,54 @@ func (targData *targetData) setTargeting(auc *auction, isApp bool, categoryMappi
 }
 
 func (targData *targetData) addKeys(keys map[string]string, key openrtb_ext.TargetingKey, value string, bidderName openrtb_ext.BidderName, overallWinner bool) {
+	if len(targData.includeKeys) > 0 && !targData.includeKeys[string(key)] {
+		return
+	}
+	targData.setKeys(keys, key, value, bidderName, overallWinner)
+}
+
+// addCustomKeys adds the custom keys which resolve to a value. The includeKeys don't apply to them.
+func (targData *targetData) addCustomKeys(keys map[string]string, params *macros.TargetingKeyTemplateParams, bidderName openrtb_ext.BidderName, overallWinner bool) {
+	for _, customKey := range targData.customKeys {
+		if value := customKey.value.Resolve(params); value != "" {
+			targData.setKeys(keys, customKey.key, value, bidderName, overallWinner)
+		}
+	}
+}
+
+func (targData *targetData) setKeys(keys map[string]string, key openrtb_ext.TargetingKey, value string, bidderName openrtb_ext.BidderName, overallWinner bool) {
+	if targData.prefix != "" && strings.HasPrefix(string(key), "hb_") {
+		key = openrtb_ext.TargetingKey(targData.prefix + strings.TrimPrefix(string(key), "hb"))
+	}
+	maxKeyLength := targData.maxKeyLength
+	if maxKeyLength == 0 {
+		maxKeyLength = MaxKeyLength
+	}
 	if targData.includeBidderKeys {
-		keys[key.BidderKey(bidderName, MaxKeyLength)] = value
+		keys[key.BidderKey(bidderName, maxKeyLength)] = value
 	}
 	if targData.includeWinners && overallWinner {
-		keys[string(key)] = value
+		winnerKey := string(key)
+		if len(winnerKey) > maxKeyLength {
+			winnerKey = winnerKey[:maxKeyLength]
+		}
+		keys[winnerKey] = value
+	}
+}
+
+func makeTargetingKeyParams(bid *pbsOrtbBid, bidderName openrtb_ext.BidderName, roundedPrice string) *macros.TargetingKeyTemplateParams {
+	return &macros.TargetingKeyTemplateParams{
+		Bidder:  string(bidderName),
+		BidID:   bid.bid.ID,
+		ImpID:   bid.bid.ImpID,
+		Price:   roundedPrice,
+		ADomain: strings.Join(bid.bid.ADomain, ","),
+		CrID:    bid.bid.CrID,
+		CID:     bid.bid.CID,
+		DealID:  bid.bid.DealID,
+		Cat:     strings.Join(bid.bid.Cat, ","),
+		Size:    makeHbSize(bid.bid),
+		Format:  string(bid.bidType),
 	}
 }
 
@@ -12,6 +12,7 @@ import (
 	"github.com/prebid/prebid-server/currencies"
 
 	"github.com/prebid/prebid-server/gdpr"
+	"github.com/prebid/prebid-server/macros"
 
 	"github.com/prebid/prebid-server/pbsmetrics"
 	metricsConf "github.com/prebid/prebid-server/pbsmetrics/config"
@@ -425,7 +426,7 @@ func TestSetTargeting(t *testing.T) {
 	for _, test := range TargetingTests {
 		auc := &test.Auction
 		// Set rounded prices from the auction data
-		auc.setRoundedPrices(test.TargetData.priceGranularity)
+		auc.setRoundedPrices(&test.TargetData)
 		winningBids := make(map[string]*pbsOrtbBid)
 		// Set winning bids from the auction data
 		for imp, bidsByBidder := range auc.winningBidsByBidder {
@@ -456,3 +457,150 @@ func TestSetTargeting(t *testing.T) {
 	}
 
 }
+
+func TestPriceGranularityFor(t *testing.T) {
+	low := openrtb_ext.PriceGranularityFromString("low")
+	high := openrtb_ext.PriceGranularityFromString("high")
+	med := openrtb_ext.PriceGranularityFromString("med")
+
+	targData := &targetData{
+		priceGranularity: med,
+		mediaTypePriceGranularity: openrtb_ext.MediaTypePriceGranularity{
+			Banner: &high,
+			Video:  &low,
+		},
+	}
+
+	assert.Equal(t, high, targData.priceGranularityFor(openrtb_ext.BidTypeBanner), "banner")
+	assert.Equal(t, low, targData.priceGranularityFor(openrtb_ext.BidTypeVideo), "video")
+	assert.Equal(t, med, targData.priceGranularityFor(openrtb_ext.BidTypeNative), "native falls back on the pricegranularity")
+	assert.Equal(t, med, targData.priceGranularityFor(openrtb_ext.BidTypeAudio), "audio falls back on the pricegranularity")
+}
+
+func TestSetRoundedPricesPerMediaType(t *testing.T) {
+	bannerBid := &pbsOrtbBid{bid: &openrtb.Bid{ID: "banner", Price: 1.37}, bidType: openrtb_ext.BidTypeBanner}
+	videoBid := &pbsOrtbBid{bid: &openrtb.Bid{ID: "video", Price: 1.37}, bidType: openrtb_ext.BidTypeVideo}
+	auc := &auction{
+		winningBidsByBidder: map[string]map[openrtb_ext.BidderName]*pbsOrtbBid{
+			"imp1": {openrtb_ext.BidderAppnexus: bannerBid},
+			"imp2": {openrtb_ext.BidderAppnexus: videoBid},
+		},
+	}
+	videoGranularity := openrtb_ext.PriceGranularity{
+		Precision: 2,
+		Ranges:    []openrtb_ext.GranularityRange{{Min: 0, Max: 20, Increment: 0.5}},
+	}
+
+	auc.setRoundedPrices(&targetData{
+		priceGranularity:          openrtb_ext.PriceGranularityFromString("dense"),
+		mediaTypePriceGranularity: openrtb_ext.MediaTypePriceGranularity{Video: &videoGranularity},
+	})
+
+	assert.Equal(t, "1.37", auc.roundedPrices[bannerBid], "banner bids should use the pricegranularity")
+	assert.Equal(t, "1.00", auc.roundedPrices[videoBid], "video bids should use the video granularity")
+}
+
+func TestSetTargetingWithKeyOptions(t *testing.T) {
+	newBid := func() *pbsOrtbBid {
+		return &pbsOrtbBid{
+			bid: &openrtb.Bid{
+				ID:      "bid1",
+				ImpID:   "imp1",
+				Price:   1.37,
+				ADomain: []string{"advertiser.com", "brand.com"},
+				CrID:    "creative1",
+				W:       300,
+				H:       250,
+			},
+			bidType: openrtb_ext.BidTypeBanner,
+		}
+	}
+	adomainValue, _ := macros.ParseTargetingKeyValue("{{.ADomain}}")
+	dealValue, _ := macros.ParseTargetingKeyValue("{{.DealID}}")
+	adomainKey := customTargetingKey{key: "hb_adomain", value: adomainValue}
+	dealKey := customTargetingKey{key: "hb_deal_id", value: dealValue}
+
+	testCases := []struct {
+		description string
+		targData    targetData
+		expected    map[string]string
+	}{
+		{
+			description: "Prefix and allow-list",
+			targData: targetData{
+				includeWinners:    true,
+				includeBidderKeys: true,
+				prefix:            "xn",
+				includeKeys:       map[string]bool{"hb_pb": true, "hb_bidder": true},
+			},
+			expected: map[string]string{
+				"xn_pb":              "1.30",
+				"xn_pb_appnexus":     "1.30",
+				"xn_bidder":          "appnexus",
+				"xn_bidder_appnexus": "appnexus",
+			},
+		},
+		{
+			description: "Max key length",
+			targData: targetData{
+				includeBidderKeys: true,
+				maxKeyLength:      8,
+				includeKeys:       map[string]bool{"hb_pb": true, "hb_size": true},
+			},
+			expected: map[string]string{
+				"hb_pb_ap": "1.30",
+				"hb_size_": "300x250",
+			},
+		},
+		{
+			description: "Custom keys skip the allow-list and empty values",
+			targData: targetData{
+				includeWinners: true,
+				includeKeys:    map[string]bool{"hb_pb": true},
+				customKeys:     []customTargetingKey{adomainKey, dealKey},
+			},
+			expected: map[string]string{
+				"hb_pb":      "1.30",
+				"hb_adomain": "advertiser.com,brand.com",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		bid := newBid()
+		auc := &auction{
+			winningBids:         map[string]*pbsOrtbBid{"imp1": bid},
+			winningBidsByBidder: map[string]map[openrtb_ext.BidderName]*pbsOrtbBid{"imp1": {openrtb_ext.BidderAppnexus: bid}},
+			roundedPrices:       map[*pbsOrtbBid]string{bid: "1.30"},
+		}
+		test.targData.setTargeting(auc, false, nil)
+		assert.Equal(t, test.expected, bid.bidTargets, test.description)
+	}
+}
+
+func TestSetTargetingDealTier(t *testing.T) {
+	bid := &pbsOrtbBid{
+		bid:     &openrtb.Bid{ID: "bid1", ImpID: "imp1", Price: 1.37, DealID: "deal1"},
+		bidType: openrtb_ext.BidTypeBanner,
+	}
+	auc := &auction{
+		winningBids:         map[string]*pbsOrtbBid{"imp1": bid},
+		winningBidsByBidder: map[string]map[openrtb_ext.BidderName]*pbsOrtbBid{"imp1": {openrtb_ext.BidderRubicon: bid}},
+		roundedPrices:       map[*pbsOrtbBid]string{bid: "1.30"},
+		dealTiers:           map[*pbsOrtbBid]string{bid: "tier5"},
+	}
+	targData := &targetData{
+		includeWinners:    true,
+		includeBidderKeys: true,
+		includeKeys:       map[string]bool{"hb_deal": true, "hb_deal_tier": true},
+	}
+
+	targData.setTargeting(auc, false, nil)
+
+	assert.Equal(t, map[string]string{
+		"hb_deal":              "deal1",
+		"hb_deal_rubicon":      "deal1",
+		"hb_deal_tier":         "tier5",
+		"hb_deal_tier_rubicon": "tier5",
+	}, bid.bidTargets)
+}
@@ -0,0 +1,243 @@
+package exchange
+
+import (
+	"container/list"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// bidderThrottler decides which requests are sent to the bidders, following the host's throttling
+// in adapters.{bidder}.throttling and the account's bidder_throttling.
+type bidderThrottler struct {
+	hostThrottling map[openrtb_ext.BidderName]config.BidderThrottling
+	now            func() time.Time
+	random         func() float64
+
+	mutex        sync.Mutex
+	hostLimiters map[openrtb_ext.BidderName]*qpsLimiter
+	// accountLimiters are keyed by the account and bidder. Unknown publishers get the account defaults under
+	// their own IDs, so these are kept in an LRU list bounded by maxAccountLimiters.
+	accountLimiters   map[throttlingKey]*list.Element
+	accountLimiterLRU *list.List
+}
+
+// maxAccountLimiters bounds the accounts' limiters kept in memory. The least recently used ones are dropped
+// past it, which resets their caps.
+const maxAccountLimiters = 10000
+
+type throttlingKey struct {
+	account string
+	bidder  openrtb_ext.BidderName
+}
+
+type accountLimiter struct {
+	key     throttlingKey
+	limiter *qpsLimiter
+}
+
+func newBidderThrottler(adapters map[string]config.Adapter) *bidderThrottler {
+	hostThrottling := make(map[openrtb_ext.BidderName]config.BidderThrottling)
+	for _, bidder := range openrtb_ext.BidderList() {
+		if adapter, ok := adapters[strings.ToLower(string(bidder))]; ok {
+			hostThrottling[bidder] = adapter.Throttling
+		}
+	}
+	return &bidderThrottler{
+		hostThrottling:    hostThrottling,
+		now:               time.Now,
+		random:            rand.Float64,
+		hostLimiters:      make(map[openrtb_ext.BidderName]*qpsLimiter),
+		accountLimiters:   make(map[throttlingKey]*list.Element),
+		accountLimiterLRU: list.New(),
+	}
+}
+
+// throttle tells why the request shouldn't be sent to the bidder, or returns an empty reason if it should.
+// Sampling is checked first, so that the requests it drops don't count against the caps.
+// A nil bidderThrottler sends every request.
+func (throttler *bidderThrottler) throttle(bidder openrtb_ext.BidderName, request *openrtb.BidRequest, account *config.Account) pbsmetrics.ThrottleReason {
+	if throttler == nil {
+		return ""
+	}
+	hostThrottling := throttler.hostThrottling[bidder]
+	accountThrottling := account.BidderThrottling[string(bidder)]
+
+	if !throttler.sampled(hostThrottling.Sampling, request) || !throttler.sampled(accountThrottling.Sampling, request) {
+		return pbsmetrics.ThrottleSampling
+	}
+	if !throttler.allow(throttlingKey{account: account.ID, bidder: bidder}, accountThrottling.MaxQPS, hostThrottling.MaxQPS) {
+		return pbsmetrics.ThrottleQPS
+	}
+	return ""
+}
+
+// sampled picks the request according to the first sampling rule it matches
+func (throttler *bidderThrottler) sampled(rules []config.TrafficSampling, request *openrtb.BidRequest) bool {
+	for _, rule := range rules {
+		if samplingRuleMatches(rule, request) {
+			return throttler.random()*100 < rule.Percent
+		}
+	}
+	return true
+}
+
+func samplingRuleMatches(rule config.TrafficSampling, request *openrtb.BidRequest) bool {
+	if len(rule.Countries) > 0 {
+		if !containsIgnoreCase(rule.Countries, requestCountry(request)) {
+			return false
+		}
+	}
+	if len(rule.MediaTypes) > 0 {
+		for _, imp := range request.Imp {
+			for _, mediaType := range rule.MediaTypes {
+				if impHasMediaType(&imp, mediaType) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// requestCountry is the country of the device's location, or the user's home country if the device has none
+func requestCountry(request *openrtb.BidRequest) string {
+	if request.Device != nil && request.Device.Geo != nil && request.Device.Geo.Country != "" {
+		return request.Device.Geo.Country
+	}
+	if request.User != nil && request.User.Geo != nil {
+		return request.User.Geo.Country
+	}
+	return ""
+}
+
+func containsIgnoreCase(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func impHasMediaType(imp *openrtb.Imp, mediaType openrtb_ext.BidType) bool {
+	switch mediaType {
+	case openrtb_ext.BidTypeBanner:
+		return imp.Banner != nil
+	case openrtb_ext.BidTypeVideo:
+		return imp.Video != nil
+	case openrtb_ext.BidTypeAudio:
+		return imp.Audio != nil
+	case openrtb_ext.BidTypeNative:
+		return imp.Native != nil
+	}
+	return false
+}
+
+// allow takes a token from both the account's and the host's limiters, if they have caps. Nothing is taken
+// unless both have a token, so that a request rejected by one cap doesn't count against the other.
+func (throttler *bidderThrottler) allow(key throttlingKey, accountMaxQPS float64, hostMaxQPS float64) bool {
+	if accountMaxQPS <= 0 && hostMaxQPS <= 0 {
+		return true
+	}
+	throttler.mutex.Lock()
+	defer throttler.mutex.Unlock()
+
+	now := throttler.now()
+	var limiters []*qpsLimiter
+	if accountMaxQPS > 0 {
+		limiters = append(limiters, throttler.accountLimiter(key, accountMaxQPS, now))
+	}
+	if hostMaxQPS > 0 {
+		limiter, ok := throttler.hostLimiters[key.bidder]
+		if !ok || limiter.maxQPS != hostMaxQPS {
+			limiter = newQPSLimiter(hostMaxQPS, now)
+			throttler.hostLimiters[key.bidder] = limiter
+		}
+		limiters = append(limiters, limiter)
+	}
+
+	for _, limiter := range limiters {
+		if !limiter.available(now) {
+			return false
+		}
+	}
+	for _, limiter := range limiters {
+		limiter.tokens--
+	}
+	return true
+}
+
+// accountLimiter finds the limiter for the key and marks it as the most recently used one. The limiters which are
+// past maxAccountLimiters, or which have been idle long enough to refill, are dropped since a new limiter
+// behaves the same.
+func (throttler *bidderThrottler) accountLimiter(key throttlingKey, maxQPS float64, now time.Time) *qpsLimiter {
+	var limiter *qpsLimiter
+	if element, ok := throttler.accountLimiters[key]; ok {
+		limiter = element.Value.(*accountLimiter).limiter
+		throttler.accountLimiterLRU.MoveToFront(element)
+	}
+	if limiter == nil || limiter.maxQPS != maxQPS {
+		// Accounts can be updated while the server runs, so a new cap replaces the limiter
+		limiter = newQPSLimiter(maxQPS, now)
+		if element, ok := throttler.accountLimiters[key]; ok {
+			element.Value.(*accountLimiter).limiter = limiter
+		} else {
+			throttler.accountLimiters[key] = throttler.accountLimiterLRU.PushFront(&accountLimiter{key: key, limiter: limiter})
+		}
+	}
+
+	for back := throttler.accountLimiterLRU.Back(); back != nil && back.Value.(*accountLimiter).limiter != limiter; back = throttler.accountLimiterLRU.Back() {
+		oldest := back.Value.(*accountLimiter)
+		if throttler.accountLimiterLRU.Len() <= maxAccountLimiters && !oldest.limiter.refilled(now) {
+			break
+		}
+		throttler.accountLimiterLRU.Remove(back)
+		delete(throttler.accountLimiters, oldest.key)
+	}
+	return limiter
+}
+
+// qpsLimiter is a token bucket which refills at maxQPS tokens per second, and holds at most one second's worth
+// (or a single token, for caps under 1 QPS). It isn't safe for concurrent use.
+type qpsLimiter struct {
+	maxQPS     float64
+	capacity   float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newQPSLimiter(maxQPS float64, now time.Time) *qpsLimiter {
+	capacity := math.Max(maxQPS, 1)
+	return &qpsLimiter{
+		maxQPS:     maxQPS,
+		capacity:   capacity,
+		tokens:     capacity,
+		lastRefill: now,
+	}
+}
+
+// available refills the bucket, and tells if it holds a token
+func (limiter *qpsLimiter) available(now time.Time) bool {
+	if elapsed := now.Sub(limiter.lastRefill); elapsed > 0 {
+		limiter.tokens += elapsed.Seconds() * limiter.maxQPS
+		if limiter.tokens > limiter.capacity {
+			limiter.tokens = limiter.capacity
+		}
+		limiter.lastRefill = now
+	}
+	return limiter.tokens >= 1
+}
+
+// refilled tells if the bucket would be full at now
+func (limiter *qpsLimiter) refilled(now time.Time) bool {
+	return limiter.tokens+now.Sub(limiter.lastRefill).Seconds()*limiter.maxQPS >= limiter.capacity
+}
@@ -0,0 +1,165 @@
+package exchange
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestThrottler(adapters map[string]config.Adapter, now *time.Time, random *float64) *bidderThrottler {
+	throttler := newBidderThrottler(adapters)
+	throttler.now = func() time.Time { return *now }
+	throttler.random = func() float64 { return *random }
+	return throttler
+}
+
+func TestThrottleNil(t *testing.T) {
+	var throttler *bidderThrottler
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, &openrtb.BidRequest{}, &config.Account{}))
+}
+
+func TestThrottleHostQPS(t *testing.T) {
+	now := time.Now()
+	random := 0.0
+	throttler := newTestThrottler(map[string]config.Adapter{
+		"appnexus": {Throttling: config.BidderThrottling{MaxQPS: 2}},
+	}, &now, &random)
+	request := &openrtb.BidRequest{}
+	account := &config.Account{ID: "acct"}
+
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, account))
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, account))
+	assert.Equal(t, pbsmetrics.ThrottleQPS, throttler.throttle(openrtb_ext.BidderAppnexus, request, account))
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderRubicon, request, account), "Bidders without caps shouldn't be throttled")
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, account), "Half a second should refill one request")
+	assert.Equal(t, pbsmetrics.ThrottleQPS, throttler.throttle(openrtb_ext.BidderAppnexus, request, account))
+}
+
+func TestThrottleAccountQPS(t *testing.T) {
+	now := time.Now()
+	random := 0.0
+	throttler := newTestThrottler(map[string]config.Adapter{
+		"appnexus": {Throttling: config.BidderThrottling{MaxQPS: 10}},
+	}, &now, &random)
+	request := &openrtb.BidRequest{}
+	limited := &config.Account{
+		ID:               "limited",
+		BidderThrottling: map[string]config.BidderThrottling{"appnexus": {MaxQPS: 0.5}},
+	}
+	other := &config.Account{ID: "other"}
+
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, limited))
+	assert.Equal(t, pbsmetrics.ThrottleQPS, throttler.throttle(openrtb_ext.BidderAppnexus, request, limited))
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, other), "Accounts shouldn't share caps")
+
+	now = now.Add(time.Second)
+	assert.Equal(t, pbsmetrics.ThrottleQPS, throttler.throttle(openrtb_ext.BidderAppnexus, request, limited), "Caps under 1 QPS should take over a second to refill")
+	now = now.Add(time.Second)
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, limited))
+
+	limited.BidderThrottling["appnexus"] = config.BidderThrottling{MaxQPS: 5}
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, limited), "A new cap should replace the limiter")
+}
+
+func TestThrottleHostCapKeepsAccountTokens(t *testing.T) {
+	now := time.Now()
+	random := 0.0
+	throttler := newTestThrottler(map[string]config.Adapter{
+		"appnexus": {Throttling: config.BidderThrottling{MaxQPS: 1}},
+	}, &now, &random)
+	request := &openrtb.BidRequest{}
+	limited := &config.Account{
+		ID:               "limited",
+		BidderThrottling: map[string]config.BidderThrottling{"appnexus": {MaxQPS: 1}},
+	}
+	other := &config.Account{ID: "other"}
+
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, other))
+	assert.Equal(t, pbsmetrics.ThrottleQPS, throttler.throttle(openrtb_ext.BidderAppnexus, request, limited))
+
+	throttler.hostThrottling[openrtb_ext.BidderAppnexus] = config.BidderThrottling{}
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, limited), "Requests rejected by the host's cap shouldn't take the account's tokens")
+}
+
+func TestThrottleAccountLimitersBounded(t *testing.T) {
+	now := time.Now()
+	random := 0.0
+	throttler := newTestThrottler(nil, &now, &random)
+	request := &openrtb.BidRequest{}
+	throttling := map[string]config.BidderThrottling{"appnexus": {MaxQPS: 1}}
+
+	for i := 0; i < maxAccountLimiters+10; i++ {
+		account := &config.Account{ID: fmt.Sprintf("pub-%d", i), BidderThrottling: throttling}
+		assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, account))
+	}
+	assert.Len(t, throttler.accountLimiters, maxAccountLimiters)
+	assert.Equal(t, maxAccountLimiters, throttler.accountLimiterLRU.Len())
+
+	now = now.Add(time.Second)
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, request, &config.Account{ID: "new", BidderThrottling: throttling}))
+	assert.Len(t, throttler.accountLimiters, 1, "The limiters which refilled should be dropped")
+}
+
+func TestThrottleSampling(t *testing.T) {
+	now := time.Now()
+	random := 0.3
+	throttler := newTestThrottler(map[string]config.Adapter{
+		"appnexus": {Throttling: config.BidderThrottling{
+			MaxQPS: 1,
+			Sampling: []config.TrafficSampling{
+				{Countries: []string{"USA"}, MediaTypes: []openrtb_ext.BidType{openrtb_ext.BidTypeVideo}, Percent: 20},
+				{Countries: []string{"usa", "CAN"}, Percent: 50},
+			},
+		}},
+	}, &now, &random)
+	account := &config.Account{ID: "acct"}
+	usVideo := &openrtb.BidRequest{
+		Imp:    []openrtb.Imp{{Banner: &openrtb.Banner{}}, {Video: &openrtb.Video{}}},
+		Device: &openrtb.Device{Geo: &openrtb.Geo{Country: "USA"}},
+	}
+	usBanner := &openrtb.BidRequest{
+		Imp:    []openrtb.Imp{{Banner: &openrtb.Banner{}}},
+		Device: &openrtb.Device{Geo: &openrtb.Geo{Country: "USA"}},
+	}
+	noGeo := &openrtb.BidRequest{Imp: []openrtb.Imp{{Video: &openrtb.Video{}}}}
+	canUser := &openrtb.BidRequest{
+		Imp:  []openrtb.Imp{{Banner: &openrtb.Banner{}}},
+		User: &openrtb.User{Geo: &openrtb.Geo{Country: "CAN"}},
+	}
+
+	assert.Equal(t, pbsmetrics.ThrottleSampling, throttler.throttle(openrtb_ext.BidderAppnexus, usVideo, account), "The first matching rule should apply")
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, usBanner, account), "Countries should match regardless of case")
+	assert.Equal(t, pbsmetrics.ThrottleQPS, throttler.throttle(openrtb_ext.BidderAppnexus, noGeo, account), "Requests without a matching rule should be sent")
+
+	random = 0.9
+	now = now.Add(time.Second)
+	assert.Equal(t, pbsmetrics.ThrottleSampling, throttler.throttle(openrtb_ext.BidderAppnexus, usBanner, account))
+	assert.Equal(t, pbsmetrics.ThrottleSampling, throttler.throttle(openrtb_ext.BidderAppnexus, canUser, account), "The user's country should be used without a device one")
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, noGeo, account), "Sampled out requests shouldn't count against the cap")
+}
+
+func TestThrottleAccountSampling(t *testing.T) {
+	now := time.Now()
+	random := 0.5
+	throttler := newTestThrottler(nil, &now, &random)
+	account := &config.Account{
+		ID: "acct",
+		BidderThrottling: map[string]config.BidderThrottling{"appnexus": {
+			Sampling: []config.TrafficSampling{{MediaTypes: []openrtb_ext.BidType{openrtb_ext.BidTypeNative}, Percent: 10}},
+		}},
+	}
+	native := &openrtb.BidRequest{Imp: []openrtb.Imp{{Native: &openrtb.Native{}}}}
+	banner := &openrtb.BidRequest{Imp: []openrtb.Imp{{Banner: &openrtb.Banner{}}}}
+
+	assert.Equal(t, pbsmetrics.ThrottleSampling, throttler.throttle(openrtb_ext.BidderAppnexus, native, account))
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderAppnexus, banner, account))
+	assert.Empty(t, throttler.throttle(openrtb_ext.BidderRubicon, native, account))
+}
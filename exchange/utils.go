@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sync"
 
 	"github.com/prebid/go-gdpr/vendorconsent"
 
 	"github.com/buger/jsonparser"
+	"github.com/golang/glog"
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/gdpr"
+	"github.com/prebid/prebid-server/macros"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/prebid/prebid-server/privacy"
@@ -270,7 +274,7 @@ func extractBuyerUIDs(user *openrtb.User) (map[string]string, error) {
 	// as long as user.ext.prebid exists.
 	buyerUIDs := userExt.Prebid.BuyerUIDs
 	userExt.Prebid = nil
-	if userExt.Consent != "" || userExt.DigiTrust != nil {
+	if userExt.Consent != "" || userExt.DigiTrust != nil || len(userExt.Eids) > 0 {
 		if newUserExtBytes, err := json.Marshal(userExt); err != nil {
 			return nil, err
 		} else {
@@ -532,20 +536,121 @@ func getExtCacheInstructions(requestExt *openrtb_ext.ExtRequest) extCacheInstruc
 	return cacheInstructions
 }
 
-func getExtTargetData(requestExt *openrtb_ext.ExtRequest, cacheInstructions *extCacheInstructions) *targetData {
+func getExtTargetData(requestExt *openrtb_ext.ExtRequest, cacheInstructions *extCacheInstructions, account *config.Account) (*targetData, []error) {
 	var targData *targetData
+	var errs []error
 
 	if requestExt != nil && requestExt.Prebid.Targeting != nil {
 		targData = &targetData{
-			priceGranularity:  requestExt.Prebid.Targeting.PriceGranularity,
-			includeWinners:    requestExt.Prebid.Targeting.IncludeWinners,
-			includeBidderKeys: requestExt.Prebid.Targeting.IncludeBidderKeys,
-			includeCacheBids:  cacheInstructions.cacheBids,
-			includeCacheVast:  cacheInstructions.cacheVAST,
-			includeFormat:     requestExt.Prebid.Targeting.IncludeFormat,
+			priceGranularity:          requestExt.Prebid.Targeting.PriceGranularity,
+			mediaTypePriceGranularity: mergeMediaTypePriceGranularity(account.MediaTypePriceGranularity, requestExt.Prebid.Targeting.MediaTypePriceGranularity),
+			includeWinners:            requestExt.Prebid.Targeting.IncludeWinners,
+			includeBidderKeys:         requestExt.Prebid.Targeting.IncludeBidderKeys,
+			includeCacheBids:          cacheInstructions.cacheBids,
+			includeCacheVast:          cacheInstructions.cacheVAST,
+			includeFormat:             requestExt.Prebid.Targeting.IncludeFormat,
+		}
+		errs = setTargetingKeyOptions(targData, requestExt.Prebid.Targeting, &account.Targeting)
+	}
+	return targData, errs
+}
+
+// setTargetingKeyOptions sets how the targeting keys are named. The request's options win over the account's.
+// The request's custom keys with invalid values are skipped, and returned as errors.
+func setTargetingKeyOptions(targData *targetData, requestTargeting *openrtb_ext.ExtRequestTargeting, accountTargeting *config.AccountTargeting) []error {
+	targData.prefix = requestTargeting.Prefix
+	if targData.prefix == "" {
+		targData.prefix = accountTargeting.Prefix
+	}
+
+	includeKeys := requestTargeting.IncludeKeys
+	if len(includeKeys) == 0 {
+		includeKeys = accountTargeting.IncludeKeys
+	}
+	if len(includeKeys) > 0 {
+		targData.includeKeys = make(map[string]bool, len(includeKeys))
+		for _, key := range includeKeys {
+			targData.includeKeys[key] = true
+		}
+	}
+
+	targData.maxKeyLength = requestTargeting.MaxKeyLength
+	if targData.maxKeyLength == 0 {
+		targData.maxKeyLength = accountTargeting.AdServerMaxKeyLength[requestTargeting.AdServer]
+	}
+	if targData.maxKeyLength == 0 {
+		targData.maxKeyLength = accountTargeting.MaxKeyLength
+	}
+
+	customKeys := make([]openrtb_ext.ExtRequestTargetingCustomKey, 0, len(accountTargeting.CustomKeys))
+	for _, accountKey := range accountTargeting.CustomKeys {
+		overridden := false
+		for _, requestKey := range requestTargeting.CustomKeys {
+			if requestKey.Key == accountKey.Key {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			customKeys = append(customKeys, accountKey)
+		}
+	}
+	for _, customKey := range customKeys {
+		if value := parseAccountCustomKeyValue(customKey.Value); len(value) > 0 {
+			targData.customKeys = append(targData.customKeys, customTargetingKey{key: openrtb_ext.TargetingKey(customKey.Key), value: value})
+		}
+	}
+
+	var errs []error
+	for i, customKey := range requestTargeting.CustomKeys {
+		value, err := macros.ParseTargetingKeyValue(customKey.Value)
+		if err != nil {
+			errs = append(errs, &errortypes.BadInput{
+				Message: fmt.Sprintf("request.ext.prebid.targeting.customkeys[%d] was skipped: %v", i, err),
+			})
+			continue
+		}
+		targData.customKeys = append(targData.customKeys, customTargetingKey{key: openrtb_ext.TargetingKey(customKey.Key), value: value})
+	}
+	return errs
+}
+
+// accountCustomKeyValues caches the parsed values of the accounts' custom keys, since the accounts are
+// unmarshalled again for every request. The invalid values are kept as nil, so that they're only logged once.
+var accountCustomKeyValues sync.Map
+
+func parseAccountCustomKeyValue(value string) macros.TargetingKeyValue {
+	if parsed, ok := accountCustomKeyValues.Load(value); ok {
+		return parsed.(macros.TargetingKeyValue)
+	}
+	parsed, err := macros.ParseTargetingKeyValue(value)
+	if err != nil {
+		glog.Errorf("Skipping the account's custom targeting keys with the value %q: %v", value, err)
+		parsed = nil
+	}
+	accountCustomKeyValues.Store(value, parsed)
+	return parsed
+}
+
+// mergeMediaTypePriceGranularity takes each media type's granularity from the request, or from the account
+// if the request doesn't set one
+func mergeMediaTypePriceGranularity(account *openrtb_ext.MediaTypePriceGranularity, request *openrtb_ext.MediaTypePriceGranularity) openrtb_ext.MediaTypePriceGranularity {
+	var merged openrtb_ext.MediaTypePriceGranularity
+	if account != nil {
+		merged = *account
+	}
+	if request != nil {
+		if request.Banner != nil {
+			merged.Banner = request.Banner
+		}
+		if request.Video != nil {
+			merged.Video = request.Video
+		}
+		if request.Native != nil {
+			merged.Native = request.Native
 		}
 	}
-	return targData
+	return merged
 }
 
 func getDebugInfo(bidRequest *openrtb.BidRequest, requestExt *openrtb_ext.ExtRequest) bool {
@@ -559,3 +664,19 @@ func getExtBidAdjustmentFactors(requestExt *openrtb_ext.ExtRequest) map[string]f
 	}
 	return bidAdjustmentFactors
 }
+
+func getExtBidAdjustments(requestExt *openrtb_ext.ExtRequest, account *config.Account) *openrtb_ext.ExtRequestPrebidBidAdjustments {
+	var requestAdjustments *openrtb_ext.ExtRequestPrebidBidAdjustments
+	if requestExt != nil {
+		requestAdjustments = requestExt.Prebid.BidAdjustments
+	}
+	return mergeBidAdjustments(account.BidAdjustments, requestAdjustments)
+}
+
+// getAuctionCurrency returns the currency the auction runs in, which is the first one from request.cur
+func getAuctionCurrency(bidRequest *openrtb.BidRequest) string {
+	if len(bidRequest.Cur) > 0 {
+		return bidRequest.Cur[0]
+	}
+	return "USD"
+}
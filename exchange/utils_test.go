@@ -10,6 +10,7 @@ import (
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/macros"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/stretchr/testify/assert"
@@ -790,7 +791,8 @@ func TestGetExtTargetData(t *testing.T) {
 		},
 	}
 	for _, test := range testCases {
-		actualTargetData := getExtTargetData(test.in.requestExt, test.in.cacheInstructions)
+		actualTargetData, errs := getExtTargetData(test.in.requestExt, test.in.cacheInstructions, &config.Account{})
+		assert.Empty(t, errs, test.desc)
 
 		if test.out.nilTargetData {
 			assert.Nil(t, actualTargetData, "%s. Targeting data should be nil. \n", test.desc)
@@ -1040,6 +1042,18 @@ func TestCleanOpenRTBRequestsGDPR(t *testing.T) {
 }
 
 // newAdapterAliasBidRequest builds a BidRequest with aliases
+func TestCleanOpenRTBRequestsKeepsEids(t *testing.T) {
+	req := newBidRequest(t)
+	req.User.Ext = json.RawMessage(`{"eids":[{"source":"adserver.org","uids":[{"id":"123"}]}],"prebid":{"buyeruids":{"appnexus":"123"}}}`)
+
+	results, _, _, errs := cleanOpenRTBRequests(context.Background(), req, nil, &emptyUsersync{}, map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{}, pbsmetrics.Labels{}, &permissionsMock{personalInfoAllowed: true}, true, config.Privacy{})
+
+	assert.Empty(t, errs)
+	if assert.Contains(t, results, openrtb_ext.BidderAppnexus) {
+		assert.JSONEq(t, `{"eids":[{"source":"adserver.org","uids":[{"id":"123"}]}]}`, string(results[openrtb_ext.BidderAppnexus].User.Ext), "The eids survive the removal of the buyeruids")
+	}
+}
+
 func newAdapterAliasBidRequest(t *testing.T) *openrtb.BidRequest {
 	dnt := int8(1)
 	return &openrtb.BidRequest{
@@ -1241,3 +1255,117 @@ func TestBidderToPrebidChainsZeroLengthSChains(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, len(output), 0)
 }
+
+func TestMergeMediaTypePriceGranularity(t *testing.T) {
+	low := openrtb_ext.PriceGranularityFromString("low")
+	med := openrtb_ext.PriceGranularityFromString("med")
+	high := openrtb_ext.PriceGranularityFromString("high")
+
+	account := &openrtb_ext.MediaTypePriceGranularity{Banner: &low, Video: &med}
+	request := &openrtb_ext.MediaTypePriceGranularity{Video: &high}
+
+	testCases := []struct {
+		description string
+		account     *openrtb_ext.MediaTypePriceGranularity
+		request     *openrtb_ext.MediaTypePriceGranularity
+		expected    openrtb_ext.MediaTypePriceGranularity
+	}{
+		{"Neither is set", nil, nil, openrtb_ext.MediaTypePriceGranularity{}},
+		{"Only the account is set", account, nil, openrtb_ext.MediaTypePriceGranularity{Banner: &low, Video: &med}},
+		{"Only the request is set", nil, request, openrtb_ext.MediaTypePriceGranularity{Video: &high}},
+		{"The request wins for the media types it sets", account, request, openrtb_ext.MediaTypePriceGranularity{Banner: &low, Video: &high}},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, mergeMediaTypePriceGranularity(test.account, test.request), test.description)
+	}
+	assert.Equal(t, &med, account.Video, "The account's granularity shouldn't be modified")
+}
+
+func TestSetTargetingKeyOptions(t *testing.T) {
+	accountTargeting := &config.AccountTargeting{
+		Prefix:               "acct",
+		IncludeKeys:          []string{"hb_pb"},
+		MaxKeyLength:         30,
+		AdServerMaxKeyLength: map[string]int{"xandr": 50},
+		CustomKeys: []openrtb_ext.ExtRequestTargetingCustomKey{
+			{Key: "hb_crid", Value: "{{.CrID}}"},
+			{Key: "hb_adomain", Value: "account-{{.ADomain}}"},
+		},
+	}
+
+	testCases := []struct {
+		description          string
+		requestTargeting     *openrtb_ext.ExtRequestTargeting
+		expectedPrefix       string
+		expectedIncludeKeys  map[string]bool
+		expectedMaxKeyLength int
+		expectedCustomKeys   []string
+	}{
+		{
+			description:          "Account defaults",
+			requestTargeting:     &openrtb_ext.ExtRequestTargeting{},
+			expectedPrefix:       "acct",
+			expectedIncludeKeys:  map[string]bool{"hb_pb": true},
+			expectedMaxKeyLength: 30,
+			expectedCustomKeys:   []string{"hb_crid", "hb_adomain"},
+		},
+		{
+			description:          "Ad server max key length",
+			requestTargeting:     &openrtb_ext.ExtRequestTargeting{AdServer: "xandr"},
+			expectedPrefix:       "acct",
+			expectedIncludeKeys:  map[string]bool{"hb_pb": true},
+			expectedMaxKeyLength: 50,
+			expectedCustomKeys:   []string{"hb_crid", "hb_adomain"},
+		},
+		{
+			description: "The request wins",
+			requestTargeting: &openrtb_ext.ExtRequestTargeting{
+				Prefix:       "req",
+				IncludeKeys:  []string{"hb_bidder", "hb_size"},
+				AdServer:     "xandr",
+				MaxKeyLength: 40,
+				CustomKeys:   []openrtb_ext.ExtRequestTargetingCustomKey{{Key: "hb_adomain", Value: "{{.ADomain}}"}},
+			},
+			expectedPrefix:       "req",
+			expectedIncludeKeys:  map[string]bool{"hb_bidder": true, "hb_size": true},
+			expectedMaxKeyLength: 40,
+			expectedCustomKeys:   []string{"hb_crid", "hb_adomain"},
+		},
+	}
+
+	for _, test := range testCases {
+		targData := &targetData{}
+		errs := setTargetingKeyOptions(targData, test.requestTargeting, accountTargeting)
+
+		assert.Empty(t, errs, test.description)
+		assert.Equal(t, test.expectedPrefix, targData.prefix, test.description)
+		assert.Equal(t, test.expectedIncludeKeys, targData.includeKeys, test.description)
+		assert.Equal(t, test.expectedMaxKeyLength, targData.maxKeyLength, test.description)
+		customKeys := make([]string, 0, len(targData.customKeys))
+		for _, customKey := range targData.customKeys {
+			customKeys = append(customKeys, string(customKey.key))
+		}
+		assert.Equal(t, test.expectedCustomKeys, customKeys, test.description)
+	}
+
+	targData := &targetData{}
+	setTargetingKeyOptions(targData, &openrtb_ext.ExtRequestTargeting{CustomKeys: []openrtb_ext.ExtRequestTargetingCustomKey{{Key: "hb_adomain", Value: "{{.ADomain}}"}}}, accountTargeting)
+	if assert.Len(t, targData.customKeys, 2) {
+		params := &macros.TargetingKeyTemplateParams{ADomain: "a.com"}
+		assert.Equal(t, "a.com", targData.customKeys[1].value.Resolve(params), "The request's value should replace the account's")
+	}
+
+	targData = &targetData{}
+	errs := setTargetingKeyOptions(targData, &openrtb_ext.ExtRequestTargeting{CustomKeys: []openrtb_ext.ExtRequestTargetingCustomKey{
+		{Key: "hb_loop", Value: "{{range 1000000}}x{{end}}"},
+		{Key: "hb_size", Value: "{{.Size}}"},
+	}}, &config.AccountTargeting{CustomKeys: []openrtb_ext.ExtRequestTargetingCustomKey{{Key: "hb_bad", Value: "{{.Unknown}}"}}})
+	if assert.Len(t, errs, 1, "Only the request's invalid keys should be reported") {
+		assert.IsType(t, &errortypes.BadInput{}, errs[0])
+		assert.Contains(t, errs[0].Error(), "customkeys[0]")
+	}
+	if assert.Len(t, targData.customKeys, 1, "The invalid keys should be skipped") {
+		assert.Equal(t, openrtb_ext.TargetingKey("hb_size"), targData.customKeys[0].key)
+	}
+}
@@ -0,0 +1,145 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/util/task"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+const (
+	defaultWarmupConnections   = 1
+	defaultWarmupTimeoutMillis = 1000
+)
+
+// ConnectionWarmer is implemented by the exchanges which can open their bidders' connections before the first auction.
+type ConnectionWarmer interface {
+	// WarmUpConnections sends the first round of probes to the bidders which enable the warm-up, and waits for it.
+	// The later rounds run in the background until stop is called.
+	WarmUpConnections() (stop func())
+}
+
+// connectionWarmer opens a bidder's connections before its first auction, and keeps them open while it's idle.
+// It's a task.Runner, so that the probes can be sent again periodically.
+type connectionWarmer struct {
+	bidder      *bidderAdapter
+	urls        []string
+	connections int
+	timeout     time.Duration
+	// interval is how often the probes are sent again. They're only sent once if it's 0.
+	interval time.Duration
+}
+
+func newConnectionWarmer(bidder *bidderAdapter, adapterCfg config.Adapter) *connectionWarmer {
+	warmer := &connectionWarmer{
+		bidder:      bidder,
+		urls:        adapterCfg.WarmupURLs(),
+		connections: adapterCfg.Warmup.Connections,
+		timeout:     time.Duration(adapterCfg.Warmup.TimeoutMillis) * time.Millisecond,
+		interval:    time.Duration(adapterCfg.Warmup.IntervalSeconds) * time.Second,
+	}
+	if warmer.connections == 0 {
+		warmer.connections = defaultWarmupConnections
+	}
+	if warmer.timeout == 0 {
+		warmer.timeout = defaultWarmupTimeoutMillis * time.Millisecond
+	}
+	return warmer
+}
+
+// Run sends a round of probes to the bidder. The probes are sent in parallel, so that each of them gets a
+// connection of its own unless enough of them are idle.
+func (warmer *connectionWarmer) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), warmer.timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, url := range warmer.urls {
+		for i := 0; i < warmer.connections; i++ {
+			wg.Add(1)
+			go func(url string) {
+				defer wg.Done()
+				warmer.probe(ctx, url)
+			}(url)
+		}
+	}
+	wg.Wait()
+	return nil
+}
+
+// probe sends a HEAD request to the url. Its response doesn't matter, but its body is read so that the
+// connection goes back to the idle pool.
+func (warmer *connectionWarmer) probe(ctx context.Context, url string) {
+	httpReq, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		glog.Warningf("Failed to warm up the connections to %s: %v", warmer.bidder.BidderName, err)
+		return
+	}
+	// The probes go through the same client trace as the bids, so that their DNS and connection times are
+	// in the adapter connection metrics.
+	if !warmer.bidder.config.DisableConnMetrics {
+		ctx = warmer.bidder.addClientTrace(ctx)
+	}
+
+	httpResp, err := ctxhttp.Do(ctx, warmer.bidder.Client, httpReq)
+	if err != nil {
+		glog.V(2).Infof("Failed to warm up the connection to %s at %s: %v", warmer.bidder.BidderName, url, err)
+		return
+	}
+	io.Copy(ioutil.Discard, httpResp.Body)
+	httpResp.Body.Close()
+}
+
+// newConnectionWarmers makes the warmers of the bidders which enable the warm-up. It doesn't send any probes.
+func newConnectionWarmers(adapterMap map[openrtb_ext.BidderName]adaptedBidder, adapterCfgs map[string]config.Adapter) []*connectionWarmer {
+	var warmers []*connectionWarmer
+	for name, bidder := range adapterMap {
+		adapterCfg := adapterCfgs[strings.ToLower(string(name))]
+		if !adapterCfg.Warmup.Enabled {
+			continue
+		}
+		if validated, ok := bidder.(*validatedBidder); ok {
+			bidder = validated.bidder
+		}
+		if adapted, ok := bidder.(*bidderAdapter); ok {
+			warmers = append(warmers, newConnectionWarmer(adapted, adapterCfg))
+		}
+	}
+	return warmers
+}
+
+func (e *exchange) WarmUpConnections() func() {
+	return startConnectionWarmers(e.connectionWarmers)
+}
+
+// startConnectionWarmers sends the first round of probes of every warmer, and waits for it so that the bidders'
+// connections are open before the first auction. The later rounds run in the background until stop is called.
+func startConnectionWarmers(warmers []*connectionWarmer) (stop func()) {
+	tasks := make([]*task.TickerTask, 0, len(warmers))
+	var wg sync.WaitGroup
+	for _, warmer := range warmers {
+		warmerTask := task.NewTickerTask(warmer.interval, warmer)
+		tasks = append(tasks, warmerTask)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			warmerTask.Start()
+		}()
+	}
+	wg.Wait()
+
+	return func() {
+		for _, warmerTask := range tasks {
+			warmerTask.Stop()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package exchange
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	metricsConfig "github.com/prebid/prebid-server/pbsmetrics/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConnectionWarmer(t *testing.T) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" && r.URL.Path == "/" {
+			atomic.AddInt32(&probes, 1)
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	metricsMock := &pbsmetrics.MetricsEngineMock{}
+	metricsMock.On("RecordAdapterConnections", openrtb_ext.BidderAppnexus, mock.Anything, mock.Anything).Return()
+	metricsMock.On("RecordDNSTime", mock.Anything).Return()
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 5}}
+	bidder := adaptBidder(&goodSingleBidder{}, client, &config.Configuration{}, metricsMock, openrtb_ext.BidderAppnexus)
+
+	warmer := newConnectionWarmer(bidder, config.Adapter{
+		Endpoint: server.URL + "/openrtb2",
+		Warmup:   config.AdapterWarmup{Enabled: true, Connections: 3},
+	})
+	assert.Equal(t, time.Second, warmer.timeout, "The timeout should default to 1 second")
+	stop := startConnectionWarmers([]*connectionWarmer{warmer})
+	defer stop()
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&probes), "Each connection should get a probe to the root of the endpoint's host")
+	metricsMock.AssertNumberOfCalls(t, "RecordAdapterConnections", 3)
+	metricsMock.AssertCalled(t, "RecordAdapterConnections", openrtb_ext.BidderAppnexus, false, mock.Anything)
+
+	// The next round, like the auctions, should find the connections open
+	warmer.Run()
+	metricsMock.AssertCalled(t, "RecordAdapterConnections", openrtb_ext.BidderAppnexus, true, mock.Anything)
+}
+
+func TestConnectionWarmerDefaults(t *testing.T) {
+	warmer := newConnectionWarmer(&bidderAdapter{}, config.Adapter{
+		Endpoint:  "https://ib.adnxs.com/openrtb2",
+		Endpoints: map[string]string{"eu": "https://eu.adnxs.com/openrtb2"},
+		Warmup:    config.AdapterWarmup{Enabled: true, IntervalSeconds: 30, TimeoutMillis: 200},
+	})
+	assert.Equal(t, []string{"https://eu.adnxs.com/", "https://ib.adnxs.com/"}, warmer.urls, "The regional endpoints should be warmed up too")
+	assert.Equal(t, 1, warmer.connections)
+	assert.Equal(t, 200*time.Millisecond, warmer.timeout)
+	assert.Equal(t, 30*time.Second, warmer.interval)
+}
+
+func TestNewConnectionWarmers(t *testing.T) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+	}))
+	defer server.Close()
+
+	cfg := &config.Configuration{Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderAppnexus): {
+			Endpoint: server.URL,
+			Warmup:   config.AdapterWarmup{Enabled: true, IntervalSeconds: 3600},
+		},
+		string(openrtb_ext.BidderRubicon): {Endpoint: server.URL},
+	}}
+	infos := adapters.ParseBidderInfos(cfg.Adapters, "../static/bidder-info", []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderRubicon})
+	adapterMap := newAdapterMap(server.Client(), cfg, infos, &metricsConfig.DummyMetricsEngine{}, nil)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&probes), "Building the adapter map shouldn't send any probes")
+
+	warmers := newConnectionWarmers(adapterMap, cfg.Adapters)
+	if assert.Len(t, warmers, 1, "Only the bidders which enable the warm-up should get a warmer") {
+		assert.Equal(t, openrtb_ext.BidderAppnexus, warmers[0].bidder.BidderName)
+	}
+
+	e := &exchange{connectionWarmers: warmers}
+	stop := e.WarmUpConnections()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&probes))
+	stop()
+}
@@ -2,6 +2,8 @@ package macros
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"text/template"
 )
 
@@ -14,6 +16,10 @@ type EndpointTemplateParams struct {
 	AccountID   string
 }
 
+// RegionMacro is replaced in the bidders' endpoints by the name of a region. Unlike the EndpointTemplateParams,
+// it's resolved when PBS starts, since each region gets an endpoint of its own.
+const RegionMacro = "{{.Region}}"
+
 // UserSyncTemplateParams specifies params for an user sync URL template
 type UserSyncTemplateParams struct {
 	GDPR        string
@@ -21,6 +27,108 @@ type UserSyncTemplateParams struct {
 	USPrivacy   string
 }
 
+// TargetingKeyTemplateParams specifies params for a custom targeting key template
+type TargetingKeyTemplateParams struct {
+	Bidder  string
+	BidID   string
+	ImpID   string
+	Price   string
+	ADomain string
+	CrID    string
+	CID     string
+	DealID  string
+	Cat     string
+	Size    string
+	Format  string
+}
+
+// field returns the value of the TargetingKeyTemplateParams field with the name, and whether it exists
+func (params *TargetingKeyTemplateParams) field(name string) (string, bool) {
+	switch name {
+	case "Bidder":
+		return params.Bidder, true
+	case "BidID":
+		return params.BidID, true
+	case "ImpID":
+		return params.ImpID, true
+	case "Price":
+		return params.Price, true
+	case "ADomain":
+		return params.ADomain, true
+	case "CrID":
+		return params.CrID, true
+	case "CID":
+		return params.CID, true
+	case "DealID":
+		return params.DealID, true
+	case "Cat":
+		return params.Cat, true
+	case "Size":
+		return params.Size, true
+	case "Format":
+		return params.Format, true
+	}
+	return "", false
+}
+
+// TargetingKeyValue is a custom targeting key's value, split around its {{.Field}} placeholders. Unlike the
+// templates, it only substitutes the TargetingKeyTemplateParams fields, so that the values can come from the requests.
+type TargetingKeyValue []targetingKeyValuePart
+
+type targetingKeyValuePart struct {
+	text string
+	// field is the TargetingKeyTemplateParams field of a placeholder. It's empty for the text between them.
+	field string
+}
+
+// ParseTargetingKeyValue splits the value around its placeholders. It fails if a placeholder isn't closed, or
+// isn't a TargetingKeyTemplateParams field.
+func ParseTargetingKeyValue(value string) (TargetingKeyValue, error) {
+	var parsed TargetingKeyValue
+	for {
+		start := strings.Index(value, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(value[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder at %q", value[start:])
+		}
+		placeholder := value[start : start+end+2]
+		field := strings.TrimSpace(placeholder[2 : len(placeholder)-2])
+		if !strings.HasPrefix(field, ".") {
+			return nil, fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+		field = field[1:]
+		if _, ok := (&TargetingKeyTemplateParams{}).field(field); !ok {
+			return nil, fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+		if start > 0 {
+			parsed = append(parsed, targetingKeyValuePart{text: value[:start]})
+		}
+		parsed = append(parsed, targetingKeyValuePart{field: field})
+		value = value[start+end+2:]
+	}
+	if value != "" {
+		parsed = append(parsed, targetingKeyValuePart{text: value})
+	}
+	return parsed, nil
+}
+
+// Resolve replaces the placeholders with the params' fields
+func (value TargetingKeyValue) Resolve(params *TargetingKeyTemplateParams) string {
+	var resolved strings.Builder
+	for _, part := range value {
+		if part.field == "" {
+			resolved.WriteString(part.text)
+		} else {
+			fieldValue, _ := params.field(part.field)
+			resolved.WriteString(fieldValue)
+		}
+	}
+	return resolved.String()
+}
+
 // ResolveMacros resolves macros in the given template with the provided params
 func ResolveMacros(aTemplate template.Template, params interface{}) (string, error) {
 	strBuf := bytes.Buffer{}
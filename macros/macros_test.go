@@ -34,3 +34,30 @@ func TestResolveMacros(t *testing.T) {
 		}
 	}
 }
+
+func TestTargetingKeyValue(t *testing.T) {
+	params := &TargetingKeyTemplateParams{Bidder: "appnexus", ADomain: "a.com,b.com", Size: "300x250"}
+
+	testCases := []struct {
+		description string
+		value       string
+		resolved    string
+		hasError    bool
+	}{
+		{description: "Text only", value: "fixed", resolved: "fixed"},
+		{description: "Placeholders", value: "{{.Bidder}}_{{ .Size }}:{{.ADomain}}", resolved: "appnexus_300x250:a.com,b.com"},
+		{description: "Empty field", value: "{{.DealID}}", resolved: ""},
+		{description: "Unknown field", value: "{{.Unknown}}", hasError: true},
+		{description: "Template action", value: "{{range 1000000000}}x{{end}}", hasError: true},
+		{description: "Unclosed placeholder", value: "{{.Bidder", hasError: true},
+	}
+
+	for _, test := range testCases {
+		value, err := ParseTargetingKeyValue(test.value)
+		if test.hasError {
+			assert.Error(t, err, test.description)
+		} else if assert.NoError(t, err, test.description) {
+			assert.Equal(t, test.resolved, value.Resolve(params), test.description)
+		}
+	}
+}
@@ -13,10 +13,18 @@ type ExtBid struct {
 
 // ExtBidPrebid defines the contract for bidresponse.seatbid.bid[i].ext.prebid
 type ExtBidPrebid struct {
-	Cache     *ExtBidPrebidCache `json:"cache,omitempty"`
-	Targeting map[string]string  `json:"targeting,omitempty"`
-	Type      BidType            `json:"type"`
-	Video     *ExtBidPrebidVideo `json:"video,omitempty"`
+	Cache        *ExtBidPrebidCache  `json:"cache,omitempty"`
+	DealPriority int                 `json:"dealpriority,omitempty"`
+	Targeting    map[string]string   `json:"targeting,omitempty"`
+	Type         BidType             `json:"type"`
+	Video        *ExtBidPrebidVideo  `json:"video,omitempty"`
+	Events       *ExtBidPrebidEvents `json:"events,omitempty"`
+}
+
+// ExtBidPrebidEvents defines the contract for bidresponse.seatbid.bid[i].ext.prebid.events
+type ExtBidPrebidEvents struct {
+	// Billing is called by the client when the ad is billable, in place of the bid's burl
+	Billing string `json:"billing,omitempty"`
 }
 
 // ExtBidPrebidCache defines the contract for  bidresponse.seatbid.bid[i].ext.prebid.cache
@@ -99,6 +107,10 @@ const (
 	HbSizeConstantKey   TargetingKey = "hb_size"
 	HbDealIDConstantKey TargetingKey = "hb_deal"
 
+	// HbDealTierKey is the deal tier of banner and native deal bids, made of the bidder's dealTier prefix
+	// and the bid's deal priority. Video bids carry their tier in HbCategoryDurationKey instead.
+	HbDealTierKey TargetingKey = "hb_deal_tier"
+
 	// HbFormatKey is the format of the bid. For example, "video", "banner"
 	HbFormatKey TargetingKey = "hb_format"
 
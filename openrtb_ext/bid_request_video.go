@@ -162,6 +162,22 @@ type PodConfig struct {
 	//  Flag indicating exact ad duration requirement. Default is false.
 	RequireExactDuration bool `json:"requireexactduration,omitempty"`
 
+	// Attribute:
+	//   optimizepods
+	// Type:
+	//   boolean, optional
+	//  Flag indicating that each pod should only return the combination of bids which maximizes its total
+	//  price within adpoddurationsec, along with the time left unfilled. Default is false.
+	OptimizePods bool `json:"optimizepods,omitempty"`
+
+	// Attribute:
+	//   competitiveexclusion
+	// Type:
+	//   boolean, optional
+	//  Flag indicating that bids sharing a category or an advertiser domain must not be placed in the same
+	//  pod. Only used when optimizepods is true. Default is false.
+	CompetitiveExclusion bool `json:"competitiveexclusion,omitempty"`
+
 	// Attribute:
 	//   pods
 	// Type:
@@ -8,9 +8,10 @@ type BidResponseVideo struct {
 }
 
 type AdPod struct {
-	PodId     int64            `json:"podid"`
-	Targeting []VideoTargeting `json:"targeting"`
-	Errors    []string         `json:"errors"`
+	PodId               int64            `json:"podid"`
+	Targeting           []VideoTargeting `json:"targeting"`
+	Errors              []string         `json:"errors"`
+	UnfilledDurationSec int              `json:"unfilleddurationsec,omitempty"`
 }
 
 type VideoTargeting struct {
@@ -111,7 +111,8 @@ const (
 	BidderZeroClickFraud   BidderName = "zeroclickfraud"
 )
 
-// BidderMap stores all the valid OpenRTB 2.x Bidders in the project. This map *must not* be mutated.
+// BidderMap stores all the valid OpenRTB 2.x Bidders in the project. This map *must not* be mutated,
+// except by RegisterBidder while the server starts up.
 // The bidder name 'general' is not allowed since it has special meaning in message maps.
 var BidderMap = map[string]BidderName{
 	"33across":          Bidder33Across,
@@ -198,6 +199,60 @@ var BidderMap = map[string]BidderName{
 	"zeroclickfraud":    BidderZeroClickFraud,
 }
 
+// configBidderSchemas holds the params schemas of the bidders added through RegisterBidder.
+// An empty schema means the bidder uses static/bidder-params/{bidder}.json, or accepts any params if there isn't one.
+var configBidderSchemas = make(map[BidderName]string)
+
+// permissiveParamsSchema is used for the registered bidders which don't define their params.
+const permissiveParamsSchema = `{"$schema": "http://json-schema.org/draft-04/schema#", "type": "object"}`
+
+// RegisterBidder adds a bidder which is declared in static/bidder-info rather than in code.
+// It must be called before the BidderMap is read, so before the config defaults, metrics and
+// params validator are set up.
+func RegisterBidder(name string, paramsSchema string) (BidderName, error) {
+	if err := validateNewBidderName(name); err != nil {
+		return "", err
+	}
+	bidder := BidderName(name)
+	BidderMap[name] = bidder
+	configBidderSchemas[bidder] = paramsSchema
+	return bidder, nil
+}
+
+// hostAliases maps the aliases added through RegisterAlias to their core bidders.
+var hostAliases = make(map[BidderName]BidderName)
+
+// RegisterAlias adds a permanent alias of a core bidder, which is configured by the host rather than
+// by each request. The alias shares the params schema of its core bidder. Like RegisterBidder, it must
+// be called while the server starts up.
+func RegisterAlias(alias string, core BidderName) (BidderName, error) {
+	if _, ok := BidderMap[string(core)]; !ok {
+		return "", fmt.Errorf("The alias %s refers to an unknown bidder %s", alias, core)
+	}
+	if _, isAlias := hostAliases[core]; isAlias {
+		return "", fmt.Errorf("The alias %s refers to %s, which is an alias itself", alias, core)
+	}
+	if err := validateNewBidderName(alias); err != nil {
+		return "", err
+	}
+	bidder := BidderName(alias)
+	BidderMap[alias] = bidder
+	hostAliases[bidder] = core
+	return bidder, nil
+}
+
+func validateNewBidderName(name string) error {
+	if name == "" || strings.EqualFold(name, string(BidderNameGeneral)) || strings.EqualFold(name, string(BidderNameContext)) {
+		return fmt.Errorf("The bidder name %q is reserved", name)
+	}
+	for existing := range BidderMap {
+		if strings.EqualFold(existing, name) {
+			return fmt.Errorf("The bidder %s already exists", name)
+		}
+	}
+	return nil
+}
+
 // BidderList returns the values of the BidderMap
 func BidderList() []BidderName {
 	bidders := make([]BidderName, 0, len(BidderMap))
@@ -262,6 +317,29 @@ func NewBidderParamsValidator(schemaDirectory string) (BidderParamValidator, err
 		schemaContents[BidderName(bidderName)] = string(fileBytes)
 	}
 
+	for bidderName, schema := range configBidderSchemas {
+		if _, hasFile := schemas[bidderName]; hasFile && schema == "" {
+			continue
+		}
+		if schema == "" {
+			schema = permissiveParamsSchema
+		}
+		loadedSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the params schema of bidder %s: %v", bidderName, err)
+		}
+		schemas[bidderName] = loadedSchema
+		schemaContents[bidderName] = schema
+	}
+
+	for alias, core := range hostAliases {
+		if _, ok := schemas[core]; !ok {
+			return nil, fmt.Errorf("The core bidder %s of alias %s has no params schema", core, alias)
+		}
+		schemas[alias] = schemas[core]
+		schemaContents[alias] = schemaContents[core]
+	}
+
 	return &bidderParamValidator{
 		schemaContents: schemaContents,
 		parsedSchemas:  schemas,
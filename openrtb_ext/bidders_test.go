@@ -66,3 +66,55 @@ func TestBidderListDoesNotDefineContext(t *testing.T) {
 	bidders := BidderList()
 	assert.NotContains(t, bidders, BidderNameContext)
 }
+
+func TestRegisterBidder(t *testing.T) {
+	defer delete(BidderMap, "configBidder")
+	defer delete(BidderMap, "looseBidder")
+	defer delete(configBidderSchemas, "configBidder")
+	defer delete(configBidderSchemas, "looseBidder")
+
+	_, err := RegisterBidder("configBidder", `{"type": "object", "required": ["placementId"]}`)
+	assert.NoError(t, err)
+	_, err = RegisterBidder("looseBidder", "")
+	assert.NoError(t, err)
+
+	_, err = RegisterBidder("APPNEXUS", "")
+	assert.EqualError(t, err, "The bidder APPNEXUS already exists")
+	_, err = RegisterBidder("general", "")
+	assert.EqualError(t, err, `The bidder name "general" is reserved`)
+	_, err = RegisterBidder("context", "")
+	assert.EqualError(t, err, `The bidder name "context" is reserved`)
+
+	configValidator, err := NewBidderParamsValidator("../static/bidder-params")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, configValidator.Validate("configBidder", json.RawMessage(`{"placementId": "123"}`)))
+	assert.Error(t, configValidator.Validate("configBidder", json.RawMessage(`{}`)))
+	assert.NoError(t, configValidator.Validate("looseBidder", json.RawMessage(`{"anything": 1}`)))
+	assert.Equal(t, permissiveParamsSchema, configValidator.Schema("looseBidder"))
+}
+
+func TestRegisterAlias(t *testing.T) {
+	defer delete(BidderMap, "whitelabel")
+	defer delete(hostAliases, "whitelabel")
+
+	alias, err := RegisterAlias("whitelabel", BidderAppnexus)
+	assert.NoError(t, err)
+	assert.Equal(t, BidderName("whitelabel"), alias)
+
+	_, err = RegisterAlias("other", "unknown")
+	assert.EqualError(t, err, "The alias other refers to an unknown bidder unknown")
+	_, err = RegisterAlias("other", "whitelabel")
+	assert.EqualError(t, err, "The alias other refers to whitelabel, which is an alias itself")
+	_, err = RegisterAlias("Rubicon", BidderAppnexus)
+	assert.EqualError(t, err, "The bidder Rubicon already exists")
+
+	aliasValidator, err := NewBidderParamsValidator("../static/bidder-params")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, aliasValidator.Schema(BidderAppnexus), aliasValidator.Schema("whitelabel"))
+	assert.NoError(t, aliasValidator.Validate("whitelabel", json.RawMessage(`{"placementId": 123}`)))
+	assert.Error(t, aliasValidator.Validate("whitelabel", json.RawMessage(`{}`)))
+}
@@ -0,0 +1,12 @@
+package openrtb_ext
+
+// LossReason is the OpenRTB 2.5 loss reason code (section 5.25) sent to bidders through the ${AUCTION_LOSS} macro
+type LossReason int
+
+const (
+	LossReasonBidWon               LossReason = 0
+	LossReasonBelowAuctionFloor    LossReason = 100
+	LossReasonLostToHigherBid      LossReason = 102
+	LossReasonAdvertiserExclusions LossReason = 205
+	LossReasonCategoryExclusions   LossReason = 209
+)
@@ -0,0 +1,151 @@
+package openrtb_ext
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// PBS works with the Native 1.2 format, which puts the fields of imp.native.request and of the native markup at
+// the top level of the JSON. Native 1.1 wraps them in a "native" object, and only tracks impressions through the
+// imptrackers and jstracker of the response. The bidders which declare nativeVersion: 1.1 in their bidder-info
+// file get their native requests converted to 1.1, and their markup converted back to 1.2.
+
+// The versions of the Native specification which the bidders may declare
+const (
+	NativeVersion11 = "1.1"
+	NativeVersion12 = "1.2"
+)
+
+// nativeFields12 are the native request fields which were added by Native 1.2
+var nativeFields12 = []string{"eventtrackers", "privacy", "aurlsupport", "durlsupport"}
+
+// The values of the Native 1.2 eventtrackers which replace the imptrackers and jstracker
+const (
+	nativeEventImpression = 1
+	nativeMethodImage     = 1
+	nativeMethodJS        = 2
+)
+
+var jsTrackerScript = regexp.MustCompile(`^\s*<script[^>]*\ssrc=["']([^"']+)["'][^>]*>\s*</script>\s*`)
+
+type nativeEventTracker struct {
+	Event  int    `json:"event"`
+	Method int    `json:"method"`
+	URL    string `json:"url"`
+}
+
+// ConvertNativeRequestTo12 converts the value of an imp.native.request to the Native 1.2 format. Requests which
+// are already in the 1.2 format are returned as they are.
+func ConvertNativeRequestTo12(request string) (string, error) {
+	payload, wrapped, err := unwrapNative([]byte(request))
+	if err != nil || !wrapped {
+		return request, err
+	}
+	payload["ver"] = json.RawMessage(`"` + NativeVersion12 + `"`)
+	converted, err := marshalJSON(payload)
+	return string(converted), err
+}
+
+// ConvertNativeRequestTo11 converts the value of an imp.native.request to the Native 1.1 format. The fields which
+// 1.1 doesn't have are dropped, so the bidder tracks impressions through the imptrackers and jstracker.
+func ConvertNativeRequestTo11(request string) (string, error) {
+	payload, wrapped, err := unwrapNative([]byte(request))
+	if err != nil || wrapped {
+		return request, err
+	}
+	for _, field := range nativeFields12 {
+		delete(payload, field)
+	}
+	payload["ver"] = json.RawMessage(`"` + NativeVersion11 + `"`)
+	converted, err := marshalJSON(map[string]interface{}{"native": payload})
+	return string(converted), err
+}
+
+// ConvertNativeResponseTo12 converts native markup to the Native 1.2 format. The imptrackers are replaced by
+// eventtrackers, and so is the jstracker when it only loads scripts.
+func ConvertNativeResponseTo12(adm string) (string, error) {
+	markup, wrapped, err := unwrapNative([]byte(adm))
+	if err != nil {
+		return adm, err
+	}
+
+	var trackers []nativeEventTracker
+	if markup["eventtrackers"] != nil {
+		if err := json.Unmarshal(markup["eventtrackers"], &trackers); err != nil {
+			return adm, err
+		}
+	}
+	numTrackers := len(trackers)
+
+	if markup["imptrackers"] != nil {
+		var impTrackers []string
+		if err := json.Unmarshal(markup["imptrackers"], &impTrackers); err != nil {
+			return adm, err
+		}
+		for _, url := range impTrackers {
+			trackers = append(trackers, nativeEventTracker{Event: nativeEventImpression, Method: nativeMethodImage, URL: url})
+		}
+		delete(markup, "imptrackers")
+	}
+
+	if markup["jstracker"] != nil {
+		var jsTracker string
+		if err := json.Unmarshal(markup["jstracker"], &jsTracker); err != nil {
+			return adm, err
+		}
+		if scripts, ok := jsTrackerURLs(jsTracker); ok {
+			for _, url := range scripts {
+				trackers = append(trackers, nativeEventTracker{Event: nativeEventImpression, Method: nativeMethodJS, URL: url})
+			}
+			delete(markup, "jstracker")
+		}
+	}
+
+	if !wrapped && len(trackers) == numTrackers {
+		return adm, nil
+	}
+	if len(trackers) > numTrackers {
+		if markup["eventtrackers"], err = marshalJSON(trackers); err != nil {
+			return adm, err
+		}
+	}
+	if markup["ver"] != nil {
+		markup["ver"] = json.RawMessage(`"` + NativeVersion12 + `"`)
+	}
+	converted, err := marshalJSON(markup)
+	if err != nil {
+		return adm, err
+	}
+	return string(converted), nil
+}
+
+// unwrapNative returns the fields of a native request or markup, and whether they were wrapped in the "native"
+// object of Native 1.1.
+func unwrapNative(data []byte) (map[string]json.RawMessage, bool, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, false, err
+	}
+	if native, ok := payload["native"]; ok && len(payload) == 1 {
+		var unwrapped map[string]json.RawMessage
+		if err := json.Unmarshal(native, &unwrapped); err == nil && unwrapped != nil {
+			return unwrapped, true, nil
+		}
+	}
+	return payload, false, nil
+}
+
+// jsTrackerURLs returns the sources of the scripts in a jstracker, or false if it holds anything else.
+func jsTrackerURLs(jsTracker string) ([]string, bool) {
+	var urls []string
+	for strings.TrimSpace(jsTracker) != "" {
+		match := jsTrackerScript.FindStringSubmatchIndex(jsTracker)
+		if match == nil {
+			return nil, false
+		}
+		urls = append(urls, jsTracker[match[2]:match[3]])
+		jsTracker = jsTracker[match[1]:]
+	}
+	return urls, len(urls) > 0
+}
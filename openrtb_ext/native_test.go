@@ -0,0 +1,90 @@
+package openrtb_ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertNativeRequestTo12(t *testing.T) {
+	testCases := []struct {
+		description string
+		request     string
+		expected    string
+	}{
+		{
+			description: "1.2 requests are left alone",
+			request:     `{"ver":"1.2","assets":[{"id":1,"title":{"len":90}}],"eventtrackers":[{"event":1,"methods":[1]}]}`,
+			expected:    `{"ver":"1.2","assets":[{"id":1,"title":{"len":90}}],"eventtrackers":[{"event":1,"methods":[1]}]}`,
+		},
+		{
+			description: "1.1 requests are unwrapped",
+			request:     `{"native":{"ver":"1.1","assets":[{"id":1,"title":{"len":90}}]}}`,
+			expected:    `{"ver":"1.2","assets":[{"id":1,"title":{"len":90}}]}`,
+		},
+	}
+
+	for _, test := range testCases {
+		converted, err := ConvertNativeRequestTo12(test.request)
+		assert.NoError(t, err, test.description)
+		assert.JSONEq(t, test.expected, converted, test.description)
+	}
+}
+
+func TestConvertNativeRequestTo11(t *testing.T) {
+	testCases := []struct {
+		description string
+		request     string
+		expected    string
+	}{
+		{
+			description: "1.2 requests are wrapped, without the fields added by 1.2",
+			request:     `{"ver":"1.2","plcmtcnt":1,"assets":[{"id":1,"title":{"len":90}}],"eventtrackers":[{"event":1,"methods":[1]}],"privacy":1,"aurlsupport":1,"durlsupport":1}`,
+			expected:    `{"native":{"ver":"1.1","plcmtcnt":1,"assets":[{"id":1,"title":{"len":90}}]}}`,
+		},
+		{
+			description: "1.1 requests are left alone",
+			request:     `{"native":{"ver":"1.1","assets":[{"id":1,"title":{"len":90}}]}}`,
+			expected:    `{"native":{"ver":"1.1","assets":[{"id":1,"title":{"len":90}}]}}`,
+		},
+	}
+
+	for _, test := range testCases {
+		converted, err := ConvertNativeRequestTo11(test.request)
+		assert.NoError(t, err, test.description)
+		assert.JSONEq(t, test.expected, converted, test.description)
+	}
+}
+
+func TestConvertNativeResponseTo12(t *testing.T) {
+	testCases := []struct {
+		description string
+		adm         string
+		expected    string
+	}{
+		{
+			description: "1.2 markup is left alone",
+			adm:         `{"assets":[{"id":1,"title":{"text":"Buy"}}],"link":{"url":"http://click"},"eventtrackers":[{"event":1,"method":1,"url":"http://imp"}]}`,
+			expected:    `{"assets":[{"id":1,"title":{"text":"Buy"}}],"link":{"url":"http://click"},"eventtrackers":[{"event":1,"method":1,"url":"http://imp"}]}`,
+		},
+		{
+			description: "1.1 markup is unwrapped, and its trackers become eventtrackers",
+			adm:         `{"native":{"ver":"1.1","assets":[{"id":1,"title":{"text":"Buy"}}],"link":{"url":"http://click"},"imptrackers":["http://imp1","http://imp2?a=1&b=2"],"jstracker":"<script src=\"http://js1\"></script><script type=\"text/javascript\" src='http://js2'></script>"}}`,
+			expected:    `{"ver":"1.2","assets":[{"id":1,"title":{"text":"Buy"}}],"link":{"url":"http://click"},"eventtrackers":[{"event":1,"method":1,"url":"http://imp1"},{"event":1,"method":1,"url":"http://imp2?a=1&b=2"},{"event":1,"method":2,"url":"http://js1"},{"event":1,"method":2,"url":"http://js2"}]}`,
+		},
+		{
+			description: "Inline jstrackers are kept",
+			adm:         `{"assets":[],"link":{"url":"http://click"},"jstracker":"<script>track()</script>"}`,
+			expected:    `{"assets":[],"link":{"url":"http://click"},"jstracker":"<script>track()</script>"}`,
+		},
+	}
+
+	for _, test := range testCases {
+		converted, err := ConvertNativeResponseTo12(test.adm)
+		assert.NoError(t, err, test.description)
+		assert.JSONEq(t, test.expected, converted, test.description)
+	}
+
+	_, err := ConvertNativeResponseTo12("<div>not native</div>")
+	assert.Error(t, err, "Markup which isn't JSON can't be converted")
+}
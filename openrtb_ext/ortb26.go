@@ -0,0 +1,439 @@
+package openrtb_ext
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mxmCherry/openrtb"
+)
+
+// The openrtb structs follow OpenRTB 2.5, so PBS carries the OpenRTB 2.6 fields in the ext locations which were
+// used for them before 2.6. Requests which use the 2.6 locations are converted when they arrive. The adapters of
+// the bidders which declare openrtbVersion: 2.6 in their bidder-info file also get their request as a BidRequest26,
+// which has the 2.6 fields outside of the ext, and may parse their responses into a BidResponse26.
+
+// BidRequest26 is a bid request with the OpenRTB 2.6 fields outside of the ext. Its objects replace the ones of the
+// embedded BidRequest, which are left empty.
+type BidRequest26 struct {
+	openrtb.BidRequest
+	Imp    []Imp26   `json:"imp"`
+	Device *Device26 `json:"device,omitempty"`
+	User   *User26   `json:"user,omitempty"`
+	Source *Source26 `json:"source,omitempty"`
+	Regs   *Regs26   `json:"regs,omitempty"`
+}
+
+type Imp26 struct {
+	openrtb.Imp
+	Video *Video26 `json:"video,omitempty"`
+	Rwdd  int8     `json:"rwdd,omitempty"`
+}
+
+type Video26 struct {
+	openrtb.Video
+	PodID        string  `json:"podid,omitempty"`
+	PodSeq       int8    `json:"podseq,omitempty"`
+	SlotInPod    int8    `json:"slotinpod,omitempty"`
+	MinCPMPerSec float64 `json:"mincpmpersec,omitempty"`
+	MaxSeq       int64   `json:"maxseq,omitempty"`
+	PodDur       int64   `json:"poddur,omitempty"`
+	RqdDurs      []int64 `json:"rqddurs,omitempty"`
+}
+
+type Device26 struct {
+	openrtb.Device
+	SUA json.RawMessage `json:"sua,omitempty"`
+}
+
+type User26 struct {
+	openrtb.User
+	Consent string          `json:"consent,omitempty"`
+	EIDs    json.RawMessage `json:"eids,omitempty"`
+}
+
+type Source26 struct {
+	openrtb.Source
+	SChain json.RawMessage `json:"schain,omitempty"`
+}
+
+type Regs26 struct {
+	openrtb.Regs
+	GDPR      *int8  `json:"gdpr,omitempty"`
+	USPrivacy string `json:"us_privacy,omitempty"`
+}
+
+// BidResponse26 is a bid response whose bids may use the OpenRTB 2.6 fields
+type BidResponse26 struct {
+	openrtb.BidResponse
+	SeatBid []SeatBid26 `json:"seatbid,omitempty"`
+}
+
+type SeatBid26 struct {
+	openrtb.SeatBid
+	Bid []Bid26 `json:"bid"`
+}
+
+type Bid26 struct {
+	openrtb.Bid
+	MType int8  `json:"mtype,omitempty"`
+	Dur   int64 `json:"dur,omitempty"`
+}
+
+// markupTypes maps the values of the OpenRTB 2.6 bid.mtype to the media types of bid.ext.prebid.type
+var markupTypes = map[int8]BidType{
+	1: BidTypeBanner,
+	2: BidTypeVideo,
+	3: BidTypeAudio,
+	4: BidTypeNative,
+}
+
+// BidType returns the media type of the bid's mtype, or false if it has none.
+func (bid *Bid26) BidType() (BidType, bool) {
+	bidType, ok := markupTypes[bid.MType]
+	return bidType, ok
+}
+
+// BidVideo returns the bid.ext.prebid.video of the bid's dur, or nil if it has none.
+func (bid *Bid26) BidVideo() *ExtBidPrebidVideo {
+	if bid.Dur <= 0 {
+		return nil
+	}
+	return &ExtBidPrebidVideo{Duration: int(bid.Dur)}
+}
+
+// ortb26Field relates an OpenRTB 2.6 field of an object to its OpenRTB 2.5 location in the object's ext.
+type ortb26Field struct {
+	field string
+	ext   []string
+}
+
+var regsFields26 = []ortb26Field{
+	{field: "gdpr", ext: []string{"gdpr"}},
+	{field: "us_privacy", ext: []string{"us_privacy"}},
+}
+
+var userFields26 = []ortb26Field{
+	{field: "consent", ext: []string{"consent"}},
+	{field: "eids", ext: []string{"eids"}},
+}
+
+var sourceFields26 = []ortb26Field{
+	{field: "schain", ext: []string{"schain"}},
+}
+
+var deviceFields26 = []ortb26Field{
+	{field: "sua", ext: []string{"sua"}},
+}
+
+var impFields26 = []ortb26Field{
+	{field: "rwdd", ext: []string{PrebidExtKey, "is_rewarded_inventory"}},
+}
+
+var videoFields26 = []ortb26Field{
+	{field: "podid", ext: []string{"podid"}},
+	{field: "podseq", ext: []string{"podseq"}},
+	{field: "slotinpod", ext: []string{"slotinpod"}},
+	{field: "mincpmpersec", ext: []string{"mincpmpersec"}},
+	{field: "maxseq", ext: []string{"maxseq"}},
+	{field: "poddur", ext: []string{"poddur"}},
+	{field: "rqddurs", ext: []string{"rqddurs"}},
+}
+
+// requestObjects26 are the objects of the bid request which have OpenRTB 2.6 fields, apart from the imps
+var requestObjects26 = map[string][]ortb26Field{
+	"regs":   regsFields26,
+	"user":   userFields26,
+	"source": sourceFields26,
+	"device": deviceFields26,
+}
+
+// jsonObject is a JSON object whose members are left undecoded
+type jsonObject map[string]json.RawMessage
+
+// ConvertRequestTo25 moves the OpenRTB 2.6 fields of a bid request to their 2.5 ext locations. The 2.6 values
+// replace the ones found in the ext. Each object is decoded once, and only the ones which had 2.6 fields are
+// encoded again.
+func ConvertRequestTo25(request []byte) ([]byte, error) {
+	var object jsonObject
+	if err := json.Unmarshal(request, &object); err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for name, fields := range requestObjects26 {
+		moved, err := convertObjectTo25(object, name, fields)
+		if err != nil {
+			return nil, err
+		}
+		changed = changed || moved
+	}
+	moved, err := convertImpsTo25(object)
+	if err != nil {
+		return nil, err
+	}
+	if !changed && !moved {
+		return request, nil
+	}
+	return marshalJSON(object)
+}
+
+// convertObjectTo25 moves the 2.6 fields of the parent's object to its ext. It tells whether any were found.
+func convertObjectTo25(parent jsonObject, name string, fields []ortb26Field) (bool, error) {
+	raw, ok := parent[name]
+	if !ok || isNullJSON(raw) {
+		return false, nil
+	}
+	var object jsonObject
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return false, fmt.Errorf("%s: %v", name, err)
+	}
+	moved, err := moveToExt(object, fields)
+	if err != nil || !moved {
+		return false, err
+	}
+	if parent[name], err = marshalJSON(object); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func convertImpsTo25(request jsonObject) (bool, error) {
+	raw, ok := request["imp"]
+	if !ok || isNullJSON(raw) {
+		return false, nil
+	}
+	var imps []json.RawMessage
+	if err := json.Unmarshal(raw, &imps); err != nil {
+		return false, fmt.Errorf("imp: %v", err)
+	}
+
+	changed := false
+	for i := range imps {
+		var imp jsonObject
+		if err := json.Unmarshal(imps[i], &imp); err != nil {
+			return false, fmt.Errorf("imp[%d]: %v", i, err)
+		}
+		moved, err := moveToExt(imp, impFields26)
+		if err != nil {
+			return false, err
+		}
+		videoMoved, err := convertObjectTo25(imp, "video", videoFields26)
+		if err != nil {
+			return false, err
+		}
+		if !moved && !videoMoved {
+			continue
+		}
+		if imps[i], err = marshalJSON(imp); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	var err error
+	request["imp"], err = marshalJSON(imps)
+	return err == nil, err
+}
+
+// moveToExt moves the fields of the object to their location in its ext. It tells whether any were found.
+func moveToExt(object jsonObject, fields []ortb26Field) (bool, error) {
+	var ext jsonObject
+	for _, field := range fields {
+		value, ok := object[field.field]
+		if !ok || isNullJSON(value) {
+			continue
+		}
+		if ext == nil {
+			var err error
+			if ext, err = parseJSONObject(object["ext"]); err != nil {
+				return false, fmt.Errorf("ext: %v", err)
+			}
+		}
+		if err := setExtValue(ext, field.ext, value); err != nil {
+			return false, err
+		}
+		delete(object, field.field)
+	}
+	if ext == nil {
+		return false, nil
+	}
+
+	var err error
+	object["ext"], err = marshalJSON(ext)
+	return err == nil, err
+}
+
+func setExtValue(ext jsonObject, path []string, value json.RawMessage) error {
+	if len(path) == 1 {
+		ext[path[0]] = value
+		return nil
+	}
+	child, err := parseJSONObject(ext[path[0]])
+	if err != nil {
+		return fmt.Errorf("%s: %v", path[0], err)
+	}
+	if err := setExtValue(child, path[1:], value); err != nil {
+		return err
+	}
+	ext[path[0]], err = marshalJSON(child)
+	return err
+}
+
+// ConvertRequestTo26 makes a copy of the bid request with the fields which have an OpenRTB 2.6 location moved out
+// of the ext. The request isn't changed.
+func ConvertRequestTo26(request *openrtb.BidRequest) (*BidRequest26, error) {
+	converted := &BidRequest26{BidRequest: *request}
+	converted.BidRequest.Imp = nil
+	converted.BidRequest.Device = nil
+	converted.BidRequest.User = nil
+	converted.BidRequest.Source = nil
+	converted.BidRequest.Regs = nil
+	var err error
+
+	converted.Imp = make([]Imp26, len(request.Imp))
+	for i := range request.Imp {
+		imp := &converted.Imp[i]
+		imp.Imp = request.Imp[i]
+		if imp.Imp.Ext, err = takeFromExt(imp.Imp.Ext, impFields26, map[string]interface{}{"rwdd": &imp.Rwdd}); err != nil {
+			return nil, fmt.Errorf("imp[%d].ext: %v", i, err)
+		}
+		if imp.Imp.Video != nil {
+			imp.Video = &Video26{Video: *imp.Imp.Video}
+			imp.Imp.Video = nil
+			targets := map[string]interface{}{
+				"podid":        &imp.Video.PodID,
+				"podseq":       &imp.Video.PodSeq,
+				"slotinpod":    &imp.Video.SlotInPod,
+				"mincpmpersec": &imp.Video.MinCPMPerSec,
+				"maxseq":       &imp.Video.MaxSeq,
+				"poddur":       &imp.Video.PodDur,
+				"rqddurs":      &imp.Video.RqdDurs,
+			}
+			if imp.Video.Ext, err = takeFromExt(imp.Video.Ext, videoFields26, targets); err != nil {
+				return nil, fmt.Errorf("imp[%d].video.ext: %v", i, err)
+			}
+		}
+	}
+
+	if request.Device != nil {
+		converted.Device = &Device26{Device: *request.Device}
+		if converted.Device.Ext, err = takeFromExt(request.Device.Ext, deviceFields26, map[string]interface{}{"sua": &converted.Device.SUA}); err != nil {
+			return nil, fmt.Errorf("device.ext: %v", err)
+		}
+	}
+	if request.User != nil {
+		converted.User = &User26{User: *request.User}
+		targets := map[string]interface{}{"consent": &converted.User.Consent, "eids": &converted.User.EIDs}
+		if converted.User.Ext, err = takeFromExt(request.User.Ext, userFields26, targets); err != nil {
+			return nil, fmt.Errorf("user.ext: %v", err)
+		}
+	}
+	if request.Source != nil {
+		converted.Source = &Source26{Source: *request.Source}
+		if converted.Source.Ext, err = takeFromExt(request.Source.Ext, sourceFields26, map[string]interface{}{"schain": &converted.Source.SChain}); err != nil {
+			return nil, fmt.Errorf("source.ext: %v", err)
+		}
+	}
+	if request.Regs != nil {
+		converted.Regs = &Regs26{Regs: *request.Regs}
+		targets := map[string]interface{}{"gdpr": &converted.Regs.GDPR, "us_privacy": &converted.Regs.USPrivacy}
+		if converted.Regs.Ext, err = takeFromExt(request.Regs.Ext, regsFields26, targets); err != nil {
+			return nil, fmt.Errorf("regs.ext: %v", err)
+		}
+	}
+	return converted, nil
+}
+
+// takeFromExt decodes the values of the fields found in the ext into their targets, and returns the ext without
+// them. The ext is returned as-is if it has none of the fields, and nil if nothing else is left.
+func takeFromExt(ext json.RawMessage, fields []ortb26Field, targets map[string]interface{}) (json.RawMessage, error) {
+	if len(ext) == 0 || isNullJSON(ext) {
+		return ext, nil
+	}
+	object, err := parseJSONObject(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := false
+	for _, field := range fields {
+		value, err := takeExtValue(object, field.ext)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		if err := json.Unmarshal(value, targets[field.field]); err != nil {
+			return nil, fmt.Errorf("%s: %v", field.field, err)
+		}
+		moved = true
+	}
+	if !moved {
+		return ext, nil
+	}
+	if len(object) == 0 {
+		return nil, nil
+	}
+	return marshalJSON(object)
+}
+
+// takeExtValue removes the value at path from the ext, along with the objects which it leaves empty. It returns
+// nil if there's no value.
+func takeExtValue(ext jsonObject, path []string) (json.RawMessage, error) {
+	raw, ok := ext[path[0]]
+	if !ok {
+		return nil, nil
+	}
+	if len(path) == 1 {
+		delete(ext, path[0])
+		if isNullJSON(raw) {
+			return nil, nil
+		}
+		return raw, nil
+	}
+
+	child, err := parseJSONObject(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path[0], err)
+	}
+	value, err := takeExtValue(child, path[1:])
+	if err != nil || value == nil {
+		return nil, err
+	}
+	if len(child) == 0 {
+		delete(ext, path[0])
+	} else if ext[path[0]], err = marshalJSON(child); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func parseJSONObject(data json.RawMessage) (jsonObject, error) {
+	object := make(jsonObject)
+	if len(data) == 0 || isNullJSON(data) {
+		return object, nil
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func isNullJSON(data json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func marshalJSON(value interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	enc := json.NewEncoder(buffer)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,105 @@
+package openrtb_ext
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertRequestTo25(t *testing.T) {
+	testCases := []struct {
+		description string
+		request     string
+		expected    string
+	}{
+		{
+			description: "2.5 requests are left alone",
+			request:     `{"id":"req","regs":{"ext":{"gdpr":1}},"imp":[{"id":"imp1"}]}`,
+			expected:    `{"id":"req","regs":{"ext":{"gdpr":1}},"imp":[{"id":"imp1"}]}`,
+		},
+		{
+			description: "Request fields move to their ext",
+			request:     `{"id":"req","regs":{"gdpr":1,"us_privacy":"1YNN","ext":{"gdpr":0}},"user":{"consent":"BOONm","eids":[{"source":"adserver.org","uids":[{"id":"123"}]}]},"source":{"schain":{"complete":1,"nodes":[],"ver":"1.0"}},"device":{"sua":{"mobile":1}}}`,
+			expected:    `{"id":"req","regs":{"ext":{"gdpr":1,"us_privacy":"1YNN"}},"user":{"ext":{"consent":"BOONm","eids":[{"source":"adserver.org","uids":[{"id":"123"}]}]}},"source":{"ext":{"schain":{"complete":1,"nodes":[],"ver":"1.0"}}},"device":{"ext":{"sua":{"mobile":1}}}}`,
+		},
+		{
+			description: "Imp fields move to their ext",
+			request:     `{"id":"req","imp":[{"id":"imp1","rwdd":1,"ext":{"appnexus":{"placementId":1}}},{"id":"imp2","video":{"mimes":["video/mp4"],"podid":"pod1","poddur":60,"rqddurs":[15,30]}}]}`,
+			expected:    `{"id":"req","imp":[{"ext":{"appnexus":{"placementId":1},"prebid":{"is_rewarded_inventory":1}},"id":"imp1"},{"id":"imp2","video":{"ext":{"podid":"pod1","poddur":60,"rqddurs":[15,30]},"mimes":["video/mp4"]}}]}`,
+		},
+	}
+
+	for _, test := range testCases {
+		converted, err := ConvertRequestTo25([]byte(test.request))
+		assert.NoError(t, err, test.description)
+		assert.JSONEq(t, test.expected, string(converted), test.description)
+	}
+}
+
+func TestConvertRequestTo25Errors(t *testing.T) {
+	for _, request := range []string{`[]`, `{"regs":[]}`, `{"imp":[1]}`, `{"user":{"consent":"BOONm","ext":1}}`} {
+		_, err := ConvertRequestTo25([]byte(request))
+		assert.Error(t, err, request)
+	}
+}
+
+func TestConvertRequestTo25KeepsBytes(t *testing.T) {
+	request := []byte(`{"id":"req",  "imp":[{"id":"imp1","video":{"mimes":["video/mp4"]}}], "user":{"ext":{"consent":"BOONm"}}}`)
+
+	converted, err := ConvertRequestTo25(request)
+	assert.NoError(t, err)
+	assert.Equal(t, string(request), string(converted), "2.5 requests aren't encoded again")
+}
+
+func TestConvertRequestTo26(t *testing.T) {
+	request := &openrtb.BidRequest{
+		ID: "req",
+		Imp: []openrtb.Imp{
+			{ID: "imp1", Ext: json.RawMessage(`{"bidder":{},"prebid":{"is_rewarded_inventory":1}}`), Video: &openrtb.Video{MIMEs: []string{"video/mp4"}, Ext: json.RawMessage(`{"podseq":1,"rqddurs":[15,30]}`)}},
+			{ID: "imp2", Ext: json.RawMessage(`{"bidder":{}}`)},
+		},
+		User: &openrtb.User{BuyerUID: "abc", Ext: json.RawMessage(`{"consent":"BOONm","digitrust":{"id":"x"}}`)},
+		Regs: &openrtb.Regs{Ext: json.RawMessage(`{"gdpr":1}`)},
+	}
+	expected := `{"id":"req","regs":{"gdpr":1},"user":{"buyeruid":"abc","consent":"BOONm","ext":{"digitrust":{"id":"x"}}},"imp":[{"id":"imp1","rwdd":1,"ext":{"bidder":{}},"video":{"mimes":["video/mp4"],"podseq":1,"rqddurs":[15,30]}},{"id":"imp2","ext":{"bidder":{}}}]}`
+
+	converted, err := ConvertRequestTo26(request)
+	if assert.NoError(t, err) {
+		convertedJSON, err := json.Marshal(converted)
+		assert.NoError(t, err)
+		assert.JSONEq(t, expected, string(convertedJSON))
+	}
+	assert.JSONEq(t, `{"consent":"BOONm","digitrust":{"id":"x"}}`, string(request.User.Ext), "The request shouldn't be changed")
+	assert.JSONEq(t, `{"podseq":1,"rqddurs":[15,30]}`, string(request.Imp[0].Video.Ext), "The request shouldn't be changed")
+}
+
+func TestConvertRequestTo26Errors(t *testing.T) {
+	request := &openrtb.BidRequest{Regs: &openrtb.Regs{Ext: json.RawMessage(`{"gdpr":"yes"}`)}}
+
+	_, err := ConvertRequestTo26(request)
+	assert.EqualError(t, err, "regs.ext: gdpr: json: cannot unmarshal string into Go value of type int8")
+}
+
+func TestBidResponse26(t *testing.T) {
+	var response BidResponse26
+	err := json.Unmarshal([]byte(`{"id":"resp","seatbid":[{"seat":"seat1","bid":[{"id":"bid1","impid":"imp1","price":1,"mtype":2,"dur":30},{"id":"bid2","impid":"imp2","price":1}]}]}`), &response)
+	if !assert.NoError(t, err) || !assert.Len(t, response.SeatBid, 1) || !assert.Len(t, response.SeatBid[0].Bid, 2) {
+		return
+	}
+	assert.Equal(t, "resp", response.ID)
+	assert.Equal(t, "seat1", response.SeatBid[0].Seat)
+
+	bid := response.SeatBid[0].Bid[0]
+	assert.Equal(t, "bid1", bid.ID)
+	bidType, ok := bid.BidType()
+	assert.True(t, ok)
+	assert.Equal(t, BidTypeVideo, bidType)
+	assert.Equal(t, &ExtBidPrebidVideo{Duration: 30}, bid.BidVideo())
+
+	bid = response.SeatBid[0].Bid[1]
+	_, ok = bid.BidType()
+	assert.False(t, ok, "Bids without an mtype have no type")
+	assert.Nil(t, bid.BidVideo(), "Bids without a dur have no video")
+}
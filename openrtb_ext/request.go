@@ -3,6 +3,9 @@ package openrtb_ext
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+
+	"golang.org/x/text/currency"
 )
 
 // FirstPartyDataContextExtKey defines the field name within bidrequest.ext reserved
@@ -16,14 +19,17 @@ type ExtRequest struct {
 
 // ExtRequestPrebid defines the contract for bidrequest.ext.prebid
 type ExtRequestPrebid struct {
-	Aliases              map[string]string         `json:"aliases,omitempty"`
-	BidAdjustmentFactors map[string]float64        `json:"bidadjustmentfactors,omitempty"`
-	Cache                *ExtRequestPrebidCache    `json:"cache,omitempty"`
-	SChains              []*ExtRequestPrebidSChain `json:"schains,omitempty"`
-	StoredRequest        *ExtStoredRequest         `json:"storedrequest,omitempty"`
-	Targeting            *ExtRequestTargeting      `json:"targeting,omitempty"`
-	SupportDeals         bool                      `json:"supportdeals,omitempty"`
-	Debug                bool                      `json:"debug,omitempty"`
+	Aliases              map[string]string                     `json:"aliases,omitempty"`
+	BidAdjustmentFactors map[string]float64                    `json:"bidadjustmentfactors,omitempty"`
+	BidAdjustments       *ExtRequestPrebidBidAdjustments       `json:"bidadjustments,omitempty"`
+	Cache                *ExtRequestPrebidCache                `json:"cache,omitempty"`
+	CompetitiveExclusion *ExtRequestPrebidCompetitiveExclusion `json:"competitiveexclusion,omitempty"`
+	Currency             *ExtRequestCurrency                   `json:"currency,omitempty"`
+	SChains              []*ExtRequestPrebidSChain             `json:"schains,omitempty"`
+	StoredRequest        *ExtStoredRequest                     `json:"storedrequest,omitempty"`
+	Targeting            *ExtRequestTargeting                  `json:"targeting,omitempty"`
+	SupportDeals         bool                                  `json:"supportdeals,omitempty"`
+	Debug                bool                                  `json:"debug,omitempty"`
 
 	// NoSale specifies bidders with whom the publisher has a legal relationship where the
 	// passing of personally identifiable information doesn't constitute a sale per CCPA law.
@@ -31,6 +37,111 @@ type ExtRequestPrebid struct {
 	NoSale []string `json:"nosale,omitempty"`
 }
 
+// ExtRequestCurrency defines the contract for bidrequest.ext.prebid.currency
+type ExtRequestCurrency struct {
+	// ConversionRates are consulted before the host's rates, in the same format as the host's currency file
+	ConversionRates map[string]map[string]float64 `json:"rates"`
+	// UsePBSRates falls back on the host's rates when ConversionRates can't convert. Defaults to true.
+	UsePBSRates *bool `json:"usepbsrates"`
+}
+
+// ExtRequestPrebidBidAdjustments defines the contract for bidrequest.ext.prebid.bidadjustments
+type ExtRequestPrebidBidAdjustments struct {
+	// MediaType maps a media type, a bidder and a deal ID to the adjustments for the matching bids.
+	// Any of the three keys may be "*" to match everything. Open market bids only match "*" deals.
+	MediaType map[string]map[string]map[string][]ExtRequestPrebidBidAdjustment `json:"mediatype,omitempty"`
+}
+
+// ExtRequestPrebidBidAdjustment is a single price adjustment. Adjustments are applied in the order they're listed.
+type ExtRequestPrebidBidAdjustment struct {
+	// AdjType is one of "multiplier", "cpm" or "static"
+	AdjType string `json:"adjtype"`
+	// Value multiplies the price, is subtracted from it, or replaces it, depending on AdjType
+	Value float64 `json:"value"`
+	// Currency of the value for "cpm" and "static" adjustments. Defaults to USD.
+	Currency string `json:"currency,omitempty"`
+}
+
+const (
+	BidAdjustmentWildcard       = "*"
+	BidAdjustmentTypeMultiplier = "multiplier"
+	BidAdjustmentTypeCPM        = "cpm"
+	BidAdjustmentTypeStatic     = "static"
+)
+
+// Validate checks the media types, bidders and adjustments. The field is the path of the adjustments used in
+// the errors, e.g. "request.ext.prebid.bidadjustments". Bidders must be core bidders, aliases or "*".
+func (adjustments *ExtRequestPrebidBidAdjustments) Validate(field string, aliases map[string]string) error {
+	if adjustments == nil {
+		return nil
+	}
+	for mediaType, bidders := range adjustments.MediaType {
+		switch mediaType {
+		case string(BidTypeBanner), string(BidTypeVideo), string(BidTypeAudio), string(BidTypeNative), BidAdjustmentWildcard:
+		default:
+			return fmt.Errorf("%s.mediatype.%s is not a known media type", field, mediaType)
+		}
+		for bidder, deals := range bidders {
+			if _, isBidder := BidderMap[bidder]; !isBidder && bidder != BidAdjustmentWildcard {
+				if _, isAlias := aliases[bidder]; !isAlias {
+					return fmt.Errorf("%s.mediatype.%s.%s is not a known bidder or alias", field, mediaType, bidder)
+				}
+			}
+			for dealID, dealAdjustments := range deals {
+				for i, adjustment := range dealAdjustments {
+					path := fmt.Sprintf("%s.mediatype.%s.%s.%s[%d]", field, mediaType, bidder, dealID, i)
+					if err := adjustment.validate(path); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (adjustment ExtRequestPrebidBidAdjustment) validate(path string) error {
+	switch adjustment.AdjType {
+	case BidAdjustmentTypeMultiplier, BidAdjustmentTypeStatic:
+		if adjustment.Value <= 0 {
+			return fmt.Errorf("%s.value must be a positive number for adjtype %s. Got %f", path, adjustment.AdjType, adjustment.Value)
+		}
+	case BidAdjustmentTypeCPM:
+		if adjustment.Value < 0 {
+			return fmt.Errorf("%s.value must be >= 0 for adjtype %s. Got %f", path, adjustment.AdjType, adjustment.Value)
+		}
+	default:
+		return fmt.Errorf("%s.adjtype must be one of \"%s\", \"%s\" or \"%s\". Got \"%s\"", path, BidAdjustmentTypeMultiplier, BidAdjustmentTypeCPM, BidAdjustmentTypeStatic, adjustment.AdjType)
+	}
+	if adjustment.Currency != "" {
+		if _, err := currency.ParseISO(adjustment.Currency); err != nil {
+			return fmt.Errorf("%s.currency is not a valid ISO 4217 currency code. Got \"%s\"", path, adjustment.Currency)
+		}
+	}
+	return nil
+}
+
+// ExtRequestPrebidCompetitiveExclusion defines the contract for bidrequest.ext.prebid.competitiveexclusion
+type ExtRequestPrebidCompetitiveExclusion struct {
+	// Fields lists the bid attributes which no two winning bids on the page may share.
+	// Defaults to both "adomain" and "cat" when empty.
+	Fields []string `json:"fields,omitempty"`
+	// TieBreak decides which imp keeps its bid when winners conflict. Defaults to "price".
+	TieBreak string `json:"tiebreak,omitempty"`
+}
+
+const (
+	// CompetitiveExclusionFieldADomain excludes winning bids with a common bid.adomain entry.
+	CompetitiveExclusionFieldADomain = "adomain"
+	// CompetitiveExclusionFieldCat excludes winning bids with a common bid.cat entry.
+	CompetitiveExclusionFieldCat = "cat"
+
+	// CompetitiveExclusionTieBreakPrice lets the highest bid win a conflict, and the earlier imp win on equal prices.
+	CompetitiveExclusionTieBreakPrice = "price"
+	// CompetitiveExclusionTieBreakImpOrder lets the imp which comes first in the request win a conflict.
+	CompetitiveExclusionTieBreakImpOrder = "imporder"
+)
+
 // ExtRequestPrebid defines the contract for bidrequest.ext.prebid.schains
 type ExtRequestPrebidSChain struct {
 	Bidders []string                     `json:"bidders,omitempty"`
@@ -95,12 +206,40 @@ type ExtRequestPrebidCacheVAST struct {
 
 // ExtRequestTargeting defines the contract for bidrequest.ext.prebid.targeting
 type ExtRequestTargeting struct {
-	PriceGranularity     PriceGranularity         `json:"pricegranularity"`
-	IncludeWinners       bool                     `json:"includewinners"`
-	IncludeBidderKeys    bool                     `json:"includebidderkeys"`
-	IncludeBrandCategory *ExtIncludeBrandCategory `json:"includebrandcategory"`
-	IncludeFormat        bool                     `json:"includeformat"`
-	DurationRangeSec     []int                    `json:"durationrangesec"`
+	PriceGranularity          PriceGranularity           `json:"pricegranularity"`
+	MediaTypePriceGranularity *MediaTypePriceGranularity `json:"mediatypepricegranularity,omitempty"`
+	IncludeWinners            bool                       `json:"includewinners"`
+	IncludeBidderKeys         bool                       `json:"includebidderkeys"`
+	IncludeBrandCategory      *ExtIncludeBrandCategory   `json:"includebrandcategory"`
+	IncludeFormat             bool                       `json:"includeformat"`
+	DurationRangeSec          []int                      `json:"durationrangesec"`
+	// Prefix replaces "hb" at the start of the targeting keys
+	Prefix string `json:"prefix,omitempty"`
+	// IncludeKeys limits the standard targeting keys to the ones listed, using their unprefixed names (e.g. "hb_pb")
+	IncludeKeys []string `json:"includekeys,omitempty"`
+	// AdServer picks the account's max key length for that ad server
+	AdServer string `json:"adserver,omitempty"`
+	// MaxKeyLength truncates the targeting keys which are longer. It wins over the account's settings.
+	MaxKeyLength int `json:"maxkeylength,omitempty"`
+	// CustomKeys are targeting keys whose values are built from the bid
+	CustomKeys []ExtRequestTargetingCustomKey `json:"customkeys,omitempty"`
+}
+
+// ExtRequestTargetingCustomKey defines the contract for bidrequest.ext.prebid.targeting.customkeys[i]
+type ExtRequestTargetingCustomKey struct {
+	Key string `mapstructure:"key" json:"key"`
+	// Value is resolved by replacing its placeholders, e.g. "{{.ADomain}}", with the bid's fields. See
+	// macros.TargetingKeyTemplateParams for the fields available. Values with other placeholders are skipped.
+	// The key is left out if the value resolves to an empty string.
+	Value string `mapstructure:"value" json:"value"`
+}
+
+// MediaTypePriceGranularity defines the contract for bidrequest.ext.prebid.targeting.mediatypepricegranularity.
+// Bids of a media type with a granularity here are rounded with it instead of the pricegranularity.
+type MediaTypePriceGranularity struct {
+	Banner *PriceGranularity `mapstructure:"banner" json:"banner,omitempty"`
+	Video  *PriceGranularity `mapstructure:"video" json:"video,omitempty"`
+	Native *PriceGranularity `mapstructure:"native" json:"native,omitempty"`
 }
 
 type ExtIncludeBrandCategory struct {
@@ -9,14 +9,30 @@ type ExtBidResponse struct {
 	Debug *ExtResponseDebug `json:"debug,omitempty"`
 	// Errors defines the contract for bidresponse.ext.errors
 	Errors map[BidderName][]ExtBidderError `json:"errors,omitempty"`
+	// Warnings defines the contract for bidresponse.ext.warnings. They report the bids which failed the bid
+	// validations, whether they were dropped or kept, which don't fail the bidder.
+	Warnings map[BidderName][]ExtBidderError `json:"warnings,omitempty"`
 	// ResponseTimeMillis defines the contract for bidresponse.ext.responsetimemillis
 	ResponseTimeMillis map[BidderName]int `json:"responsetimemillis,omitempty"`
 	// RequestTimeoutMillis returns the timeout used in the auction.
 	// This is useful if the timeout is saved in the Stored Request on the server.
 	// Clients can run one auction, and then use this to set better connection timeouts on future auction requests.
+	// It stays the auction's timeout when the host gives bidders their own timeouts, since that's what the
+	// clients wait for. The bidders' timeouts are in tmaxrequestbybidder.
 	RequestTimeoutMillis int64 `json:"tmaxrequest,omitempty"`
+	// BidderTimeoutMillis defines the contract for bidresponse.ext.tmaxrequestbybidder. It's the tmax each bidder
+	// was sent, which can be less than tmaxrequest when the host gives bidders their own timeouts.
+	BidderTimeoutMillis map[BidderName]int64 `json:"tmaxrequestbybidder,omitempty"`
 	// ResponseUserSync defines the contract for bidresponse.ext.usersync
 	Usersync map[BidderName]*ExtResponseSyncData `json:"usersync,omitempty"`
+	// Prebid defines the contract for bidresponse.ext.prebid
+	Prebid *ExtResponsePrebid `json:"prebid,omitempty"`
+}
+
+// ExtResponsePrebid defines the contract for bidresponse.ext.prebid
+type ExtResponsePrebid struct {
+	// Currency is the currency the auction ran in. Every bid price in the response is in this currency.
+	Currency string `json:"currency,omitempty"`
 }
 
 // ExtResponseDebug defines the contract for bidresponse.ext.debug
@@ -25,6 +41,9 @@ type ExtResponseDebug struct {
 	HttpCalls map[BidderName][]*ExtHttpCall `json:"httpcalls,omitempty"`
 	// Request after resolution of stored requests and debug overrides
 	ResolvedRequest *openrtb.BidRequest `json:"resolvedrequest,omitempty"`
+	// Throttled defines the contract for bidresponse.ext.debug.throttled. It lists the bidders which weren't
+	// called because of the host's or account's throttling, with the reason ("qps" or "sampling").
+	Throttled map[BidderName]string `json:"throttled,omitempty"`
 }
 
 // ExtResponseSyncData defines the contract for bidresponse.ext.usersync.{bidder}
@@ -126,6 +126,34 @@ func (me *MultiMetricsEngine) RecordAdapterConnections(bidderName openrtb_ext.Bi
 	}
 }
 
+// RecordAdapterCircuitBreaker across all engines
+func (me *MultiMetricsEngine) RecordAdapterCircuitBreaker(bidderName openrtb_ext.BidderName, open bool) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterCircuitBreaker(bidderName, open)
+	}
+}
+
+// RecordAdapterThrottled across all engines
+func (me *MultiMetricsEngine) RecordAdapterThrottled(bidderName openrtb_ext.BidderName, reason pbsmetrics.ThrottleReason) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterThrottled(bidderName, reason)
+	}
+}
+
+// RecordAdapterRequestSize across all engines
+func (me *MultiMetricsEngine) RecordAdapterRequestSize(bidderName openrtb_ext.BidderName, bytes int) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterRequestSize(bidderName, bytes)
+	}
+}
+
+// RecordAdapterResponseSize across all engines
+func (me *MultiMetricsEngine) RecordAdapterResponseSize(bidderName openrtb_ext.BidderName, bytes int) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterResponseSize(bidderName, bytes)
+	}
+}
+
 // Times the DNS resolution process
 func (me *MultiMetricsEngine) RecordDNSTime(dnsLookupTime time.Duration) {
 	for _, thisME := range *me {
@@ -140,6 +168,34 @@ func (me *MultiMetricsEngine) RecordAdapterBidReceived(labels pbsmetrics.Adapter
 	}
 }
 
+// RecordAdapterBlockedBid across all engines
+func (me *MultiMetricsEngine) RecordAdapterBlockedBid(labels pbsmetrics.AdapterLabels, enforced bool) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterBlockedBid(labels, enforced)
+	}
+}
+
+// RecordAdapterBadCreativeSize across all engines
+func (me *MultiMetricsEngine) RecordAdapterBadCreativeSize(labels pbsmetrics.AdapterLabels, enforced bool) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterBadCreativeSize(labels, enforced)
+	}
+}
+
+// RecordAdapterDealViolation across all engines
+func (me *MultiMetricsEngine) RecordAdapterDealViolation(labels pbsmetrics.AdapterLabels, enforced bool) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterDealViolation(labels, enforced)
+	}
+}
+
+// RecordAdapterInsecureMarkup across all engines
+func (me *MultiMetricsEngine) RecordAdapterInsecureMarkup(labels pbsmetrics.AdapterLabels, enforced bool) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterInsecureMarkup(labels, enforced)
+	}
+}
+
 // RecordAdapterPrice across all engines
 func (me *MultiMetricsEngine) RecordAdapterPrice(labels pbsmetrics.AdapterLabels, cpm float64) {
 	for _, thisME := range *me {
@@ -256,6 +312,22 @@ func (me *DummyMetricsEngine) RecordAdapterRequest(labels pbsmetrics.AdapterLabe
 func (me *DummyMetricsEngine) RecordAdapterConnections(bidderName openrtb_ext.BidderName, connWasReused bool, connWaitTime time.Duration) {
 }
 
+// RecordAdapterCircuitBreaker as a noop
+func (me *DummyMetricsEngine) RecordAdapterCircuitBreaker(bidderName openrtb_ext.BidderName, open bool) {
+}
+
+// RecordAdapterThrottled as a noop
+func (me *DummyMetricsEngine) RecordAdapterThrottled(bidderName openrtb_ext.BidderName, reason pbsmetrics.ThrottleReason) {
+}
+
+// RecordAdapterRequestSize as a noop
+func (me *DummyMetricsEngine) RecordAdapterRequestSize(bidderName openrtb_ext.BidderName, bytes int) {
+}
+
+// RecordAdapterResponseSize as a noop
+func (me *DummyMetricsEngine) RecordAdapterResponseSize(bidderName openrtb_ext.BidderName, bytes int) {
+}
+
 // RecordDNSTime as a noop
 func (me *DummyMetricsEngine) RecordDNSTime(dnsLookupTime time.Duration) {
 }
@@ -264,6 +336,22 @@ func (me *DummyMetricsEngine) RecordDNSTime(dnsLookupTime time.Duration) {
 func (me *DummyMetricsEngine) RecordAdapterBidReceived(labels pbsmetrics.AdapterLabels, bidType openrtb_ext.BidType, hasAdm bool) {
 }
 
+// RecordAdapterBlockedBid as a noop
+func (me *DummyMetricsEngine) RecordAdapterBlockedBid(labels pbsmetrics.AdapterLabels, enforced bool) {
+}
+
+// RecordAdapterBadCreativeSize as a noop
+func (me *DummyMetricsEngine) RecordAdapterBadCreativeSize(labels pbsmetrics.AdapterLabels, enforced bool) {
+}
+
+// RecordAdapterDealViolation as a noop
+func (me *DummyMetricsEngine) RecordAdapterDealViolation(labels pbsmetrics.AdapterLabels, enforced bool) {
+}
+
+// RecordAdapterInsecureMarkup as a noop
+func (me *DummyMetricsEngine) RecordAdapterInsecureMarkup(labels pbsmetrics.AdapterLabels, enforced bool) {
+}
+
 // RecordAdapterPrice as a noop
 func (me *DummyMetricsEngine) RecordAdapterPrice(labels pbsmetrics.AdapterLabels, cpm float64) {
 }
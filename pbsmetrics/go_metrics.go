@@ -81,10 +81,22 @@ type AdapterMetrics struct {
 	PriceHistogram    metrics.Histogram
 	BidsReceivedMeter metrics.Meter
 	PanicMeter        metrics.Meter
+	BlockedBidsMeter  metrics.Meter
+	BlockedBidsWarned metrics.Meter
+	BadSizeMeter      metrics.Meter
+	BadSizeWarned     metrics.Meter
+	InsecureMeter     metrics.Meter
+	InsecureWarned    metrics.Meter
+	DealMeter         metrics.Meter
+	DealWarned        metrics.Meter
 	MarkupMetrics     map[openrtb_ext.BidType]*MarkupDeliveryMetrics
 	ConnCreated       metrics.Counter
 	ConnReused        metrics.Counter
 	ConnWaitTime      metrics.Timer
+	CircuitsOpen      metrics.Counter
+	ThrottledMeters   map[ThrottleReason]metrics.Meter
+	RequestSize       metrics.Histogram
+	ResponseSize      metrics.Histogram
 }
 
 type MarkupDeliveryMetrics struct {
@@ -272,7 +284,19 @@ func makeBlankAdapterMetrics(disabledMetrics config.DisabledMetrics) *AdapterMet
 		PriceHistogram:    &metrics.NilHistogram{},
 		BidsReceivedMeter: blankMeter,
 		PanicMeter:        blankMeter,
+		BlockedBidsMeter:  blankMeter,
+		BlockedBidsWarned: blankMeter,
+		BadSizeMeter:      blankMeter,
+		BadSizeWarned:     blankMeter,
+		InsecureMeter:     blankMeter,
+		InsecureWarned:    blankMeter,
+		DealMeter:         blankMeter,
+		DealWarned:        blankMeter,
 		MarkupMetrics:     makeBlankBidMarkupMetrics(),
+		CircuitsOpen:      metrics.NilCounter{},
+		ThrottledMeters:   make(map[ThrottleReason]metrics.Meter),
+		RequestSize:       &metrics.NilHistogram{},
+		ResponseSize:      &metrics.NilHistogram{},
 	}
 	if !disabledMetrics.AdapterConnectionMetrics {
 		newAdapter.ConnCreated = metrics.NilCounter{}
@@ -282,6 +306,9 @@ func makeBlankAdapterMetrics(disabledMetrics config.DisabledMetrics) *AdapterMet
 	for _, err := range AdapterErrors() {
 		newAdapter.ErrorMeters[err] = blankMeter
 	}
+	for _, reason := range ThrottleReasons() {
+		newAdapter.ThrottledMeters[reason] = blankMeter
+	}
 	return newAdapter
 }
 
@@ -316,13 +343,27 @@ func registerAdapterMetrics(registry metrics.Registry, adapterOrAccount string,
 	am.ConnCreated = metrics.GetOrRegisterCounter(fmt.Sprintf("%[1]s.%[2]s.connections_created", adapterOrAccount, exchange), registry)
 	am.ConnReused = metrics.GetOrRegisterCounter(fmt.Sprintf("%[1]s.%[2]s.connections_reused", adapterOrAccount, exchange), registry)
 	am.ConnWaitTime = metrics.GetOrRegisterTimer(fmt.Sprintf("%[1]s.%[2]s.connection_wait_time", adapterOrAccount, exchange), registry)
+	am.CircuitsOpen = metrics.GetOrRegisterCounter(fmt.Sprintf("%[1]s.%[2]s.circuit_breakers_open", adapterOrAccount, exchange), registry)
+	am.RequestSize = metrics.GetOrRegisterHistogram(fmt.Sprintf("%[1]s.%[2]s.request_size_bytes", adapterOrAccount, exchange), registry, metrics.NewExpDecaySample(1028, 0.015))
+	am.ResponseSize = metrics.GetOrRegisterHistogram(fmt.Sprintf("%[1]s.%[2]s.response_size_bytes", adapterOrAccount, exchange), registry, metrics.NewExpDecaySample(1028, 0.015))
 	for err := range am.ErrorMeters {
 		am.ErrorMeters[err] = metrics.GetOrRegisterMeter(fmt.Sprintf("%s.%s.requests.%s", adapterOrAccount, exchange, err), registry)
 	}
+	for reason := range am.ThrottledMeters {
+		am.ThrottledMeters[reason] = metrics.GetOrRegisterMeter(fmt.Sprintf("%s.%s.throttled.%s", adapterOrAccount, exchange, reason), registry)
+	}
 	if adapterOrAccount != "adapter" {
 		am.BidsReceivedMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.bids_received", adapterOrAccount, exchange), registry)
 	}
 	am.PanicMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.requests.panic", adapterOrAccount, exchange), registry)
+	am.BlockedBidsMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.blocked_bids.rejected", adapterOrAccount, exchange), registry)
+	am.BlockedBidsWarned = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.blocked_bids.warned", adapterOrAccount, exchange), registry)
+	am.BadSizeMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.bad_creative_size.rejected", adapterOrAccount, exchange), registry)
+	am.BadSizeWarned = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.bad_creative_size.warned", adapterOrAccount, exchange), registry)
+	am.InsecureMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.insecure_markup.rejected", adapterOrAccount, exchange), registry)
+	am.InsecureWarned = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.insecure_markup.warned", adapterOrAccount, exchange), registry)
+	am.DealMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.deal_violations.rejected", adapterOrAccount, exchange), registry)
+	am.DealWarned = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.deal_violations.warned", adapterOrAccount, exchange), registry)
 }
 
 func makeDeliveryMetrics(registry metrics.Registry, prefix string, bidType openrtb_ext.BidType) *MarkupDeliveryMetrics {
@@ -454,6 +495,62 @@ func (me *Metrics) RecordAdapterPanic(labels AdapterLabels) {
 	am.PanicMeter.Mark(1)
 }
 
+// RecordAdapterBlockedBid implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterBlockedBid(labels AdapterLabels, enforced bool) {
+	am, ok := me.AdapterMetrics[labels.Adapter]
+	if !ok {
+		glog.Errorf("Trying to run adapter metrics on %s: adapter metrics not found", string(labels.Adapter))
+		return
+	}
+	if enforced {
+		am.BlockedBidsMeter.Mark(1)
+	} else {
+		am.BlockedBidsWarned.Mark(1)
+	}
+}
+
+// RecordAdapterBadCreativeSize implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterBadCreativeSize(labels AdapterLabels, enforced bool) {
+	am, ok := me.AdapterMetrics[labels.Adapter]
+	if !ok {
+		glog.Errorf("Trying to run adapter metrics on %s: adapter metrics not found", string(labels.Adapter))
+		return
+	}
+	if enforced {
+		am.BadSizeMeter.Mark(1)
+	} else {
+		am.BadSizeWarned.Mark(1)
+	}
+}
+
+// RecordAdapterInsecureMarkup implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterInsecureMarkup(labels AdapterLabels, enforced bool) {
+	am, ok := me.AdapterMetrics[labels.Adapter]
+	if !ok {
+		glog.Errorf("Trying to run adapter metrics on %s: adapter metrics not found", string(labels.Adapter))
+		return
+	}
+	if enforced {
+		am.InsecureMeter.Mark(1)
+	} else {
+		am.InsecureWarned.Mark(1)
+	}
+}
+
+// RecordAdapterDealViolation implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterDealViolation(labels AdapterLabels, enforced bool) {
+	am, ok := me.AdapterMetrics[labels.Adapter]
+	if !ok {
+		glog.Errorf("Trying to run adapter metrics on %s: adapter metrics not found", string(labels.Adapter))
+		return
+	}
+	if enforced {
+		am.DealMeter.Mark(1)
+	} else {
+		am.DealWarned.Mark(1)
+	}
+}
+
 // RecordAdapterRequest implements a part of the MetricsEngine interface
 func (me *Metrics) RecordAdapterRequest(labels AdapterLabels) {
 	am, ok := me.AdapterMetrics[labels.Adapter]
@@ -510,6 +607,53 @@ func (me *Metrics) RecordAdapterConnections(adapterName openrtb_ext.BidderName,
 	am.ConnWaitTime.Update(connWaitTime)
 }
 
+// RecordAdapterThrottled implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterThrottled(adapterName openrtb_ext.BidderName, reason ThrottleReason) {
+	am, ok := me.AdapterMetrics[adapterName]
+	if !ok {
+		glog.Errorf("Trying to log adapter throttling metrics for %s: adapter not found", string(adapterName))
+		return
+	}
+	if meter, ok := am.ThrottledMeters[reason]; ok {
+		meter.Mark(1)
+	}
+}
+
+// RecordAdapterRequestSize implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterRequestSize(adapterName openrtb_ext.BidderName, bytes int) {
+	am, ok := me.AdapterMetrics[adapterName]
+	if !ok {
+		glog.Errorf("Trying to log adapter request size metrics for %s: adapter not found", string(adapterName))
+		return
+	}
+	am.RequestSize.Update(int64(bytes))
+}
+
+// RecordAdapterResponseSize implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterResponseSize(adapterName openrtb_ext.BidderName, bytes int) {
+	am, ok := me.AdapterMetrics[adapterName]
+	if !ok {
+		glog.Errorf("Trying to log adapter response size metrics for %s: adapter not found", string(adapterName))
+		return
+	}
+	am.ResponseSize.Update(int64(bytes))
+}
+
+// RecordAdapterCircuitBreaker implements a part of the MetricsEngine interface. The counter holds
+// the number of the adapter's circuit breakers which are currently open.
+func (me *Metrics) RecordAdapterCircuitBreaker(adapterName openrtb_ext.BidderName, open bool) {
+	am, ok := me.AdapterMetrics[adapterName]
+	if !ok {
+		glog.Errorf("Trying to log adapter circuit breaker metrics for %s: adapter not found", string(adapterName))
+		return
+	}
+	if open {
+		am.CircuitsOpen.Inc(1)
+	} else {
+		am.CircuitsOpen.Dec(1)
+	}
+}
+
 func (me *Metrics) RecordDNSTime(dnsLookupTime time.Duration) {
 	me.DNSLookupTimer.Update(dnsLookupTime)
 }
@@ -211,6 +211,42 @@ func TestRecordDNSTime(t *testing.T) {
 	}
 }
 
+func TestRecordAdapterCircuitBreaker(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}, config.DisabledMetrics{AccountAdapterDetails: true})
+
+	m.RecordAdapterCircuitBreaker(openrtb_ext.BidderAppnexus, true)
+	m.RecordAdapterCircuitBreaker(openrtb_ext.BidderAppnexus, true)
+	m.RecordAdapterCircuitBreaker(openrtb_ext.BidderAppnexus, false)
+
+	assert.Equal(t, int64(1), m.AdapterMetrics[openrtb_ext.BidderAppnexus].CircuitsOpen.Count(), "One circuit breaker should be open")
+}
+
+func TestRecordAdapterThrottled(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}, config.DisabledMetrics{AccountAdapterDetails: true})
+
+	m.RecordAdapterThrottled(openrtb_ext.BidderAppnexus, ThrottleSampling)
+
+	am := m.AdapterMetrics[openrtb_ext.BidderAppnexus]
+	assert.Equal(t, int64(1), am.ThrottledMeters[ThrottleSampling].Count())
+	assert.Equal(t, int64(0), am.ThrottledMeters[ThrottleQPS].Count())
+}
+
+func TestRecordAdapterPayloadSizes(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}, config.DisabledMetrics{AccountAdapterDetails: true})
+
+	m.RecordAdapterRequestSize(openrtb_ext.BidderAppnexus, 1500)
+	m.RecordAdapterResponseSize(openrtb_ext.BidderAppnexus, 300)
+	m.RecordAdapterResponseSize(openrtb_ext.BidderAppnexus, 700)
+
+	am := m.AdapterMetrics[openrtb_ext.BidderAppnexus]
+	assert.Equal(t, int64(1500), am.RequestSize.Sum())
+	assert.Equal(t, int64(2), am.ResponseSize.Count())
+	assert.Equal(t, int64(1000), am.ResponseSize.Sum())
+}
+
 func TestRecordAdapterConnections(t *testing.T) {
 	var fakeBidder openrtb_ext.BidderName = "fooAdvertising"
 
@@ -77,6 +77,9 @@ type AdapterBid string
 // AdapterError : Errors which may have occurred during the adapter's execution
 type AdapterError string
 
+// ThrottleReason : Why a request wasn't sent to an adapter
+type ThrottleReason string
+
 // CacheResult : Cache hit/miss
 type CacheResult string
 
@@ -215,6 +218,20 @@ func AdapterErrors() []AdapterError {
 	}
 }
 
+const (
+	// ThrottleQPS means the adapter's requests per second cap was reached
+	ThrottleQPS ThrottleReason = "qps"
+	// ThrottleSampling means the request wasn't picked by the adapter's traffic sampling
+	ThrottleSampling ThrottleReason = "sampling"
+)
+
+func ThrottleReasons() []ThrottleReason {
+	return []ThrottleReason{
+		ThrottleQPS,
+		ThrottleSampling,
+	}
+}
+
 const (
 	// CacheHit represents a cache hit i.e the key was found in cache
 	CacheHit CacheResult = "hit"
@@ -302,12 +319,27 @@ type MetricsEngine interface {
 	RecordRequestTime(labels Labels, length time.Duration) // ignores adapter. only statusOk and statusErr fom status
 	RecordAdapterRequest(labels AdapterLabels)
 	RecordAdapterConnections(adapterName openrtb_ext.BidderName, connWasReused bool, connWaitTime time.Duration)
+	// This records a circuit breaker of the adapter opening, or closing again once its endpoint recovered.
+	RecordAdapterCircuitBreaker(adapterName openrtb_ext.BidderName, open bool)
+	// This records a request which wasn't sent to the adapter because of the host's or account's throttling.
+	RecordAdapterThrottled(adapterName openrtb_ext.BidderName, reason ThrottleReason)
+	// This records the size in bytes of a request body sent to the adapter, after any compression.
+	RecordAdapterRequestSize(adapterName openrtb_ext.BidderName, bytes int)
+	// This records the size in bytes of a response body received from the adapter.
+	RecordAdapterResponseSize(adapterName openrtb_ext.BidderName, bytes int)
 	RecordDNSTime(dnsLookupTime time.Duration)
 	RecordAdapterPanic(labels AdapterLabels)
 	// This records whether or not a bid of a particular type uses `adm` or `nurl`.
 	// Since the legacy endpoints don't have a bid type, it can only count bids from OpenRTB and AMP.
 	RecordAdapterBidReceived(labels AdapterLabels, bidType openrtb_ext.BidType, hasAdm bool)
 	RecordAdapterPrice(labels AdapterLabels, cpm float64)
+	// This records bids which violated the request's bcat, badv or battr, and whether they were rejected or only reported.
+	RecordAdapterBlockedBid(labels AdapterLabels, enforced bool)
+	// These record banner bids which didn't match the imp's sizes, and bids with insecure markup for secure imps.
+	RecordAdapterBadCreativeSize(labels AdapterLabels, enforced bool)
+	RecordAdapterInsecureMarkup(labels AdapterLabels, enforced bool)
+	// This records bids on private auction imps which weren't for one of the imp's deals.
+	RecordAdapterDealViolation(labels AdapterLabels, enforced bool)
 	RecordAdapterTime(labels AdapterLabels, length time.Duration)
 	RecordCookieSync()
 	RecordAdapterCookieSync(adapter openrtb_ext.BidderName, gdprBlocked bool)
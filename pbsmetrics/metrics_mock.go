@@ -57,6 +57,26 @@ func (me *MetricsEngineMock) RecordAdapterConnections(bidderName openrtb_ext.Bid
 	me.Called(bidderName, connWasReused, connWaitTime)
 }
 
+// RecordAdapterCircuitBreaker mock
+func (me *MetricsEngineMock) RecordAdapterCircuitBreaker(bidderName openrtb_ext.BidderName, open bool) {
+	me.Called(bidderName, open)
+}
+
+// RecordAdapterThrottled mock
+func (me *MetricsEngineMock) RecordAdapterThrottled(bidderName openrtb_ext.BidderName, reason ThrottleReason) {
+	me.Called(bidderName, reason)
+}
+
+// RecordAdapterRequestSize mock
+func (me *MetricsEngineMock) RecordAdapterRequestSize(bidderName openrtb_ext.BidderName, bytes int) {
+	me.Called(bidderName, bytes)
+}
+
+// RecordAdapterResponseSize mock
+func (me *MetricsEngineMock) RecordAdapterResponseSize(bidderName openrtb_ext.BidderName, bytes int) {
+	me.Called(bidderName, bytes)
+}
+
 // RecordDNSTime mock
 func (me *MetricsEngineMock) RecordDNSTime(dnsLookupTime time.Duration) {
 	me.Called(dnsLookupTime)
@@ -67,6 +87,26 @@ func (me *MetricsEngineMock) RecordAdapterBidReceived(labels AdapterLabels, bidT
 	me.Called(labels, bidType, hasAdm)
 }
 
+// RecordAdapterBlockedBid mock
+func (me *MetricsEngineMock) RecordAdapterBlockedBid(labels AdapterLabels, enforced bool) {
+	me.Called(labels, enforced)
+}
+
+// RecordAdapterBadCreativeSize mock
+func (me *MetricsEngineMock) RecordAdapterBadCreativeSize(labels AdapterLabels, enforced bool) {
+	me.Called(labels, enforced)
+}
+
+// RecordAdapterInsecureMarkup mock
+func (me *MetricsEngineMock) RecordAdapterInsecureMarkup(labels AdapterLabels, enforced bool) {
+	me.Called(labels, enforced)
+}
+
+// RecordAdapterDealViolation mock
+func (me *MetricsEngineMock) RecordAdapterDealViolation(labels AdapterLabels, enforced bool) {
+	me.Called(labels, enforced)
+}
+
 // RecordAdapterPrice mock
 func (me *MetricsEngineMock) RecordAdapterPrice(labels AdapterLabels, cpm float64) {
 	me.Called(labels, cpm)
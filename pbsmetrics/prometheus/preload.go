@@ -61,6 +61,21 @@ func preloadLabelValues(m *Metrics) {
 		markupDeliveryLabel: bidTypeValues,
 	})
 
+	preloadLabelValuesForCounter(m.adapterBlockedBids, map[string][]string{
+		adapterLabel:  adapterValues,
+		enforcedLabel: boolValues,
+	})
+
+	preloadLabelValuesForCounter(m.adapterBadCreativeSize, map[string][]string{
+		adapterLabel:  adapterValues,
+		enforcedLabel: boolValues,
+	})
+
+	preloadLabelValuesForCounter(m.adapterInsecureMarkup, map[string][]string{
+		adapterLabel:  adapterValues,
+		enforcedLabel: boolValues,
+	})
+
 	preloadLabelValuesForCounter(m.adapterCookieSync, map[string][]string{
 		adapterLabel:        adapterValues,
 		privacyBlockedLabel: boolValues,
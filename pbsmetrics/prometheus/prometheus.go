@@ -37,6 +37,10 @@ type Metrics struct {
 
 	// Adapter Metrics
 	adapterBids               *prometheus.CounterVec
+	adapterBlockedBids        *prometheus.CounterVec
+	adapterBadCreativeSize    *prometheus.CounterVec
+	adapterInsecureMarkup     *prometheus.CounterVec
+	adapterDealViolations     *prometheus.CounterVec
 	adapterCookieSync         *prometheus.CounterVec
 	adapterErrors             *prometheus.CounterVec
 	adapterPanics             *prometheus.CounterVec
@@ -47,6 +51,10 @@ type Metrics struct {
 	adapterReusedConnections  *prometheus.CounterVec
 	adapterCreatedConnections *prometheus.CounterVec
 	adapterConnectionWaitTime *prometheus.HistogramVec
+	adapterCircuitsOpen       *prometheus.GaugeVec
+	adapterThrottled          *prometheus.CounterVec
+	adapterRequestSize        *prometheus.HistogramVec
+	adapterResponseSize       *prometheus.HistogramVec
 
 	// Account Metrics
 	accountRequests *prometheus.CounterVec
@@ -63,6 +71,8 @@ const (
 	cacheResultLabel     = "cache_result"
 	connectionErrorLabel = "connection_error"
 	cookieLabel          = "cookie"
+	enforcedLabel        = "enforced"
+	throttleReasonLabel  = "throttle_reason"
 	hasBidsLabel         = "has_bids"
 	isAudioLabel         = "audio"
 	isBannerLabel        = "banner"
@@ -108,6 +118,7 @@ func NewMetrics(cfg config.PrometheusMetrics, disabledMetrics config.DisabledMet
 	cacheWriteTimeBuckets := []float64{0.001, 0.002, 0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 1}
 	priceBuckets := []float64{250, 500, 750, 1000, 1500, 2000, 2500, 3000, 3500, 4000}
 	queuedRequestTimeBuckets := []float64{0, 1, 5, 30, 60, 120, 180, 240, 300}
+	payloadSizeBuckets := []float64{256, 512, 1024, 2048, 4096, 8192, 16384, 32768, 65536, 131072}
 
 	metrics := Metrics{}
 	metrics.Registry = prometheus.NewRegistry()
@@ -206,6 +217,26 @@ func NewMetrics(cfg config.PrometheusMetrics, disabledMetrics config.DisabledMet
 		"Count of bids labeled by adapter and markup delivery type (adm or nurl).",
 		[]string{adapterLabel, markupDeliveryLabel})
 
+	metrics.adapterBlockedBids = newCounter(cfg, metrics.Registry,
+		"adapter_blocked_bids",
+		"Count of bids which violated the request's bcat, badv or battr labeled by adapter and if the bid was rejected.",
+		[]string{adapterLabel, enforcedLabel})
+
+	metrics.adapterBadCreativeSize = newCounter(cfg, metrics.Registry,
+		"adapter_bad_creative_size",
+		"Count of banner bids which didn't match the imp's sizes labeled by adapter and if the bid was rejected.",
+		[]string{adapterLabel, enforcedLabel})
+
+	metrics.adapterInsecureMarkup = newCounter(cfg, metrics.Registry,
+		"adapter_insecure_markup",
+		"Count of bids on secure imps with insecure markup labeled by adapter and if the bid was rejected.",
+		[]string{adapterLabel, enforcedLabel})
+
+	metrics.adapterDealViolations = newCounter(cfg, metrics.Registry,
+		"adapter_deal_violations",
+		"Count of bids on private auction imps which weren't for one of the imp's deals labeled by adapter and if the bid was rejected.",
+		[]string{adapterLabel, enforcedLabel})
+
 	metrics.adapterCookieSync = newCounter(cfg, metrics.Registry,
 		"adapter_cookie_sync",
 		"Count of cookie sync requests received labeled by adapter and if the sync was blocked due to privacy regulation (GDPR, CCPA, etc...).",
@@ -250,6 +281,28 @@ func NewMetrics(cfg config.PrometheusMetrics, disabledMetrics config.DisabledMet
 			standardTimeBuckets)
 	}
 
+	metrics.adapterCircuitsOpen = newGaugeVec(cfg, metrics.Registry,
+		"adapter_circuit_breakers_open",
+		"Number of circuit breakers currently open labeled by adapter. Requests to an endpoint aren't sent while its circuit breaker is open.",
+		[]string{adapterLabel})
+
+	metrics.adapterThrottled = newCounter(cfg, metrics.Registry,
+		"adapter_throttled",
+		"Count of requests which weren't sent to the adapter because of throttling labeled by adapter and reason.",
+		[]string{adapterLabel, throttleReasonLabel})
+
+	metrics.adapterRequestSize = newHistogramVec(cfg, metrics.Registry,
+		"adapter_request_size_bytes",
+		"Size in bytes of the request bodies sent to the adapter, after any compression, labeled by adapter.",
+		[]string{adapterLabel},
+		payloadSizeBuckets)
+
+	metrics.adapterResponseSize = newHistogramVec(cfg, metrics.Registry,
+		"adapter_response_size_bytes",
+		"Size in bytes of the response bodies received from the adapter labeled by adapter.",
+		[]string{adapterLabel},
+		payloadSizeBuckets)
+
 	metrics.adapterRequestsTimer = newHistogramVec(cfg, metrics.Registry,
 		"adapter_request_time_seconds",
 		"Seconds to resolve each successful request labeled by adapter.",
@@ -301,6 +354,18 @@ func newCounterWithoutLabels(cfg config.PrometheusMetrics, registry *prometheus.
 	return counter
 }
 
+func newGaugeVec(cfg config.PrometheusMetrics, registry *prometheus.Registry, name, help string, labels []string) *prometheus.GaugeVec {
+	opts := prometheus.GaugeOpts{
+		Namespace: cfg.Namespace,
+		Subsystem: cfg.Subsystem,
+		Name:      name,
+		Help:      help,
+	}
+	gauge := prometheus.NewGaugeVec(opts, labels)
+	registry.MustRegister(gauge)
+	return gauge
+}
+
 func newHistogramVec(cfg config.PrometheusMetrics, registry *prometheus.Registry, name, help string, labels []string, buckets []float64) *prometheus.HistogramVec {
 	opts := prometheus.HistogramOpts{
 		Namespace: cfg.Namespace,
@@ -424,6 +489,36 @@ func (m *Metrics) RecordAdapterConnections(adapterName openrtb_ext.BidderName, c
 	}).Observe(connWaitTime.Seconds())
 }
 
+func (m *Metrics) RecordAdapterCircuitBreaker(adapterName openrtb_ext.BidderName, open bool) {
+	gauge := m.adapterCircuitsOpen.With(prometheus.Labels{
+		adapterLabel: string(adapterName),
+	})
+	if open {
+		gauge.Inc()
+	} else {
+		gauge.Dec()
+	}
+}
+
+func (m *Metrics) RecordAdapterThrottled(adapterName openrtb_ext.BidderName, reason pbsmetrics.ThrottleReason) {
+	m.adapterThrottled.With(prometheus.Labels{
+		adapterLabel:        string(adapterName),
+		throttleReasonLabel: string(reason),
+	}).Inc()
+}
+
+func (m *Metrics) RecordAdapterRequestSize(adapterName openrtb_ext.BidderName, bytes int) {
+	m.adapterRequestSize.With(prometheus.Labels{
+		adapterLabel: string(adapterName),
+	}).Observe(float64(bytes))
+}
+
+func (m *Metrics) RecordAdapterResponseSize(adapterName openrtb_ext.BidderName, bytes int) {
+	m.adapterResponseSize.With(prometheus.Labels{
+		adapterLabel: string(adapterName),
+	}).Observe(float64(bytes))
+}
+
 func (m *Metrics) RecordDNSTime(dnsLookupTime time.Duration) {
 	m.dnsLookupTimer.Observe(dnsLookupTime.Seconds())
 }
@@ -434,6 +529,34 @@ func (m *Metrics) RecordAdapterPanic(labels pbsmetrics.AdapterLabels) {
 	}).Inc()
 }
 
+func (m *Metrics) RecordAdapterBlockedBid(labels pbsmetrics.AdapterLabels, enforced bool) {
+	m.adapterBlockedBids.With(prometheus.Labels{
+		adapterLabel:  string(labels.Adapter),
+		enforcedLabel: strconv.FormatBool(enforced),
+	}).Inc()
+}
+
+func (m *Metrics) RecordAdapterBadCreativeSize(labels pbsmetrics.AdapterLabels, enforced bool) {
+	m.adapterBadCreativeSize.With(prometheus.Labels{
+		adapterLabel:  string(labels.Adapter),
+		enforcedLabel: strconv.FormatBool(enforced),
+	}).Inc()
+}
+
+func (m *Metrics) RecordAdapterInsecureMarkup(labels pbsmetrics.AdapterLabels, enforced bool) {
+	m.adapterInsecureMarkup.With(prometheus.Labels{
+		adapterLabel:  string(labels.Adapter),
+		enforcedLabel: strconv.FormatBool(enforced),
+	}).Inc()
+}
+
+func (m *Metrics) RecordAdapterDealViolation(labels pbsmetrics.AdapterLabels, enforced bool) {
+	m.adapterDealViolations.With(prometheus.Labels{
+		adapterLabel:  string(labels.Adapter),
+		enforcedLabel: strconv.FormatBool(enforced),
+	}).Inc()
+}
+
 func (m *Metrics) RecordAdapterBidReceived(labels pbsmetrics.AdapterLabels, bidType openrtb_ext.BidType, hasAdm bool) {
 	markupDelivery := markupDeliveryNurl
 	if hasAdm {
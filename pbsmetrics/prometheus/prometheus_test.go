@@ -62,7 +62,10 @@ func TestMetricCountGatekeeping(t *testing.T) {
 	// Verify Per-Adapter Cardinality
 	// - This assertion provides a warning for newly added adapter metrics. Threre are 40+ adapters which makes the
 	//   cost of new per-adapter metrics rather expensive. Thought should be given when adding new per-adapter metrics.
-	assert.True(t, perAdapterCardinalityCount <= 27, "Per-Adapter Cardinality count equals %d \n", perAdapterCardinalityCount)
+	// - The bid validations add 6 series to the 25 before them: adapter_blocked_bids, adapter_bad_creative_size and
+	//   adapter_insecure_markup, preloaded for both values of the enforced label. The warn mode is how hosts try out
+	//   the validations before enforcing them, so both values need to start at zero for the rate() of either to work.
+	assert.True(t, perAdapterCardinalityCount <= 31, "Per-Adapter Cardinality count equals %d \n", perAdapterCardinalityCount)
 }
 
 func TestConnectionMetrics(t *testing.T) {
@@ -803,6 +806,120 @@ func TestAdapterPanicMetric(t *testing.T) {
 		})
 }
 
+func TestAdapterBlockedBidMetric(t *testing.T) {
+	m := createMetricsForTesting()
+	adapterName := "anyName"
+	labels := pbsmetrics.AdapterLabels{
+		Adapter: openrtb_ext.BidderName(adapterName),
+	}
+
+	m.RecordAdapterBlockedBid(labels, true)
+	m.RecordAdapterBlockedBid(labels, true)
+	m.RecordAdapterBlockedBid(labels, false)
+
+	assertCounterVecValue(t, "", "adapterBlockedBids:enforced", m.adapterBlockedBids,
+		float64(2),
+		prometheus.Labels{
+			adapterLabel:  adapterName,
+			enforcedLabel: "true",
+		})
+	assertCounterVecValue(t, "", "adapterBlockedBids:warned", m.adapterBlockedBids,
+		float64(1),
+		prometheus.Labels{
+			adapterLabel:  adapterName,
+			enforcedLabel: "false",
+		})
+}
+
+func TestAdapterBadCreativeSizeMetric(t *testing.T) {
+	m := createMetricsForTesting()
+	adapterName := "anyName"
+
+	m.RecordAdapterBadCreativeSize(pbsmetrics.AdapterLabels{
+		Adapter: openrtb_ext.BidderName(adapterName),
+	}, false)
+
+	assertCounterVecValue(t, "", "adapterBadCreativeSize", m.adapterBadCreativeSize,
+		float64(1),
+		prometheus.Labels{
+			adapterLabel:  adapterName,
+			enforcedLabel: "false",
+		})
+}
+
+func TestAdapterInsecureMarkupMetric(t *testing.T) {
+	m := createMetricsForTesting()
+	adapterName := "anyName"
+
+	m.RecordAdapterInsecureMarkup(pbsmetrics.AdapterLabels{
+		Adapter: openrtb_ext.BidderName(adapterName),
+	}, true)
+
+	assertCounterVecValue(t, "", "adapterInsecureMarkup", m.adapterInsecureMarkup,
+		float64(1),
+		prometheus.Labels{
+			adapterLabel:  adapterName,
+			enforcedLabel: "true",
+		})
+}
+
+func TestAdapterDealViolationMetric(t *testing.T) {
+	m := createMetricsForTesting()
+	adapterName := "anyName"
+
+	m.RecordAdapterDealViolation(pbsmetrics.AdapterLabels{
+		Adapter: openrtb_ext.BidderName(adapterName),
+	}, false)
+
+	assertCounterVecValue(t, "", "adapterDealViolations", m.adapterDealViolations,
+		float64(1),
+		prometheus.Labels{
+			adapterLabel:  adapterName,
+			enforcedLabel: "false",
+		})
+}
+
+func TestAdapterCircuitBreakerMetric(t *testing.T) {
+	m := createMetricsForTesting()
+	adapterName := openrtb_ext.BidderName("anyName")
+
+	m.RecordAdapterCircuitBreaker(adapterName, true)
+	m.RecordAdapterCircuitBreaker(adapterName, true)
+	m.RecordAdapterCircuitBreaker(adapterName, false)
+
+	gauge := dto.Metric{}
+	m.adapterCircuitsOpen.With(prometheus.Labels{adapterLabel: string(adapterName)}).Write(&gauge)
+	assert.Equal(t, float64(1), gauge.GetGauge().GetValue())
+}
+
+func TestAdapterThrottledMetric(t *testing.T) {
+	m := createMetricsForTesting()
+	adapterName := "anyName"
+
+	m.RecordAdapterThrottled(openrtb_ext.BidderName(adapterName), pbsmetrics.ThrottleQPS)
+
+	assertCounterVecValue(t, "", "adapterThrottled", m.adapterThrottled,
+		float64(1),
+		prometheus.Labels{
+			adapterLabel:        adapterName,
+			throttleReasonLabel: string(pbsmetrics.ThrottleQPS),
+		})
+}
+
+func TestAdapterPayloadSizeMetrics(t *testing.T) {
+	m := createMetricsForTesting()
+	adapterName := "anyName"
+
+	m.RecordAdapterRequestSize(openrtb_ext.BidderName(adapterName), 1500)
+	m.RecordAdapterResponseSize(openrtb_ext.BidderName(adapterName), 300)
+	m.RecordAdapterResponseSize(openrtb_ext.BidderName(adapterName), 700)
+
+	requestSizes := getHistogramFromHistogramVec(m.adapterRequestSize, adapterLabel, adapterName)
+	assertHistogram(t, "adapterRequestSize", requestSizes, uint64(1), float64(1500))
+	responseSizes := getHistogramFromHistogramVec(m.adapterResponseSize, adapterLabel, adapterName)
+	assertHistogram(t, "adapterResponseSize", responseSizes, uint64(2), float64(1000))
+}
+
 func TestStoredReqCacheResultMetric(t *testing.T) {
 	m := createMetricsForTesting()
 
@@ -81,6 +81,15 @@ func NewJsonDirectoryServer(schemaDirectory string, validator openrtb_ext.Bidder
 		data[bidder] = json.RawMessage(validator.Schema(bidderName))
 	}
 
+	// Add in the bidders which have no schema file, because they're declared in static/bidder-info or are the host's aliases
+	for bidder, bidderName := range openrtb_ext.BidderMap {
+		if _, ok := data[bidder]; !ok {
+			if schema := validator.Schema(bidderName); schema != "" {
+				data[bidder] = json.RawMessage(schema)
+			}
+		}
+	}
+
 	// Add in any default aliases
 	for aliasName, bidderName := range aliases {
 		bidderData, ok := data[bidderName]
@@ -205,6 +214,15 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 		},
 	}
 
+	// Bidders declared only in static/bidder-info, and the host's aliases, must be registered before anything reads the BidderMap
+	p, _ := filepath.Abs(infoDirectory)
+	if _, err := adapters.RegisterConfigBidders(p); err != nil {
+		glog.Fatalf("Failed to register the bidders declared in %s. %v", infoDirectory, err)
+	}
+	if _, err := adapters.RegisterHostAliases(cfg.Adapters); err != nil {
+		glog.Fatalf("Failed to register the bidder aliases of the app config. %v", err)
+	}
+
 	// Hack because of how legacy handles districtm
 	legacyBidderList := openrtb_ext.BidderList()
 	legacyBidderList = append(legacyBidderList, openrtb_ext.BidderName("districtm"))
@@ -226,7 +244,6 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 		glog.Fatalf("Failed to create the bidder params validator. %v", err)
 	}
 
-	p, _ := filepath.Abs(infoDirectory)
 	bidderInfos := adapters.ParseBidderInfos(cfg.Adapters, p, openrtb_ext.BidderList())
 
 	disabledBidders := map[string]string{
@@ -237,11 +254,20 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 	defaultAliases, defReqJSON := readDefaultRequest(cfg.DefReqConfig)
 
 	syncers := usersyncers.NewSyncerMap(cfg)
+	usersyncers.InsertConfigBidderSyncers(cfg, syncers, bidderInfos)
+	usersyncers.InsertAliasSyncers(cfg, syncers)
 	gdprPerms := gdpr.NewPermissions(context.Background(), cfg.GDPR, adapters.GDPRAwareSyncerIDs(syncers), generalHttpClient)
 
 	exchanges = newExchangeMap(cfg)
 	cacheClient := pbc.NewClient(cacheHttpClient, &cfg.CacheURL, &cfg.ExtCacheURL, r.MetricsEngine)
 	theExchange := exchange.NewExchange(generalHttpClient, cacheClient, cfg, r.MetricsEngine, bidderInfos, gdprPerms, rateConvertor)
+	if warmer, ok := theExchange.(exchange.ConnectionWarmer); ok {
+		stopWarmers := warmer.WarmUpConnections()
+		r.Shutdown = func() {
+			shutdown()
+			stopWarmers()
+		}
+	}
 
 	openrtbEndpoint, err := openrtb2.NewEndpoint(theExchange, paramsValidator, fetcher, accounts, categoriesFetcher, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap)
 
@@ -260,20 +286,30 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 		glog.Fatalf("Failed to create the video endpoint handler. %v", err)
 	}
 
+	vastEndpoint, err := openrtb2.NewVastEndpoint(theExchange, paramsValidator, fetcher, videoFetcher, accounts, categoriesFetcher, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap, cacheClient)
+	if err != nil {
+		glog.Fatalf("Failed to create the vast endpoint handler. %v", err)
+	}
+
 	requestTimeoutHeaders := config.RequestTimeoutHeaders{}
 	if cfg.RequestTimeoutHeaders != requestTimeoutHeaders {
 		videoEndpoint = aspects.QueuedRequestTimeout(videoEndpoint, cfg.RequestTimeoutHeaders, r.MetricsEngine, pbsmetrics.ReqTypeVideo)
+		vastEndpoint = aspects.QueuedRequestTimeout(vastEndpoint, cfg.RequestTimeoutHeaders, r.MetricsEngine, pbsmetrics.ReqTypeVideo)
 	}
 
 	r.POST("/auction", endpoints.Auction(cfg, syncers, gdprPerms, r.MetricsEngine, dataCache, exchanges))
 	r.POST("/openrtb2/auction", openrtbEndpoint)
 	r.POST("/openrtb2/video", videoEndpoint)
+	r.POST("/openrtb2/vast", vastEndpoint)
 	r.GET("/openrtb2/amp", ampEndpoint)
 	r.GET("/info/bidders", infoEndpoints.NewBiddersEndpoint(defaultAliases))
 	r.GET("/info/bidders/:bidderName", infoEndpoints.NewBidderDetailsEndpoint(bidderInfos, defaultAliases))
 	r.GET("/bidders/params", NewJsonDirectoryServer(schemaDirectory, paramsValidator, defaultAliases))
 	r.POST("/cookie_sync", endpoints.NewCookieSyncEndpoint(syncers, cfg, gdprPerms, r.MetricsEngine, pbsAnalytics))
 	r.GET("/status", endpoints.NewStatusEndpoint(cfg.StatusResponse))
+	if billingNotifier, ok := theExchange.(exchange.BillingNotifier); ok {
+		r.GET("/event", endpoints.NewEventEndpoint(billingNotifier))
+	}
 	r.GET("/", serveIndex)
 	r.ServeFiles("/static/*filepath", http.Dir("static"))
 
@@ -38,6 +38,19 @@ func AppendPEMFileToRootCAPool(certPool *x509.CertPool, pemFileName string) (*x5
 	return certPool, nil
 }
 
+// NewRootCAPool returns a new `x509.CertPool` holding the hardcoded certificates, along with those of the
+// `.pem` files. Unlike the pool of GetRootCAPool, it isn't shared.
+func NewRootCAPool(pemFileNames ...string) (*x509.CertPool, error) {
+	certPool := x509.NewCertPool()
+	certPool.AppendCertsFromPEM(pemCerts)
+	for _, pemFileName := range pemFileNames {
+		if _, err := AppendPEMFileToRootCAPool(certPool, pemFileName); err != nil {
+			return certPool, err
+		}
+	}
+	return certPool, nil
+}
+
 var pemCerts = []byte(`
 -----BEGIN CERTIFICATE-----
 MIIH0zCCBbugAwIBAgIIXsO3pkN/pOAwDQYJKoZIhvcNAQEFBQAwQjESMBAGA1UE
@@ -52,3 +52,19 @@ func TestAppendPEMFileToRootCAPoolFail(t *testing.T) {
 	// Assert AppendPEMFileToRootCAPool correctly throws an error when trying to load an nonexisting file
 	assert.Errorf(t, err, "AppendPEMFileToRootCAPool should throw an error by while loading fake file %s \n", fakeCertificatesFile)
 }
+
+func TestNewRootCAPool(t *testing.T) {
+	certPool, err := NewRootCAPool()
+	assert.NoError(t, err)
+	hardCodedSubNum := len(certPool.Subjects())
+	assert.True(t, hardCodedSubNum > 0)
+	sharedSubNum := len(GetRootCAPool().Subjects())
+
+	certPool, err = NewRootCAPool("mockcertificates/mock-certs.pem")
+	assert.NoError(t, err)
+	assert.Len(t, certPool.Subjects(), hardCodedSubNum+1, "The pool should hold the hardcoded certificates and the file's")
+	assert.Len(t, GetRootCAPool().Subjects(), sharedSubNum, "The shared pool should be left alone")
+
+	_, err = NewRootCAPool("mockcertificates/NO-FILE.pem")
+	assert.Error(t, err)
+}
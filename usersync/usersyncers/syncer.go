@@ -5,6 +5,7 @@ import (
 	"text/template"
 
 	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/adapters"
 	ttx "github.com/prebid/prebid-server/adapters/33across"
 	"github.com/prebid/prebid-server/adapters/adform"
 	"github.com/prebid/prebid-server/adapters/adkernel"
@@ -74,6 +75,7 @@ import (
 	"github.com/prebid/prebid-server/adapters/zeroclickfraud"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/privacy"
 	"github.com/prebid/prebid-server/usersync"
 )
 
@@ -154,6 +156,50 @@ func NewSyncerMap(cfg *config.Configuration) map[openrtb_ext.BidderName]usersync
 	return syncers
 }
 
+// InsertConfigBidderSyncers adds the syncers of the bidders declared in static/bidder-info, for those which
+// have a usersync section.
+func InsertConfigBidderSyncers(cfg *config.Configuration, syncers map[openrtb_ext.BidderName]usersync.Usersyncer, infos adapters.BidderInfos) {
+	for bidderName, info := range infos {
+		if info.OpenRTB == nil || info.OpenRTB.Usersync == nil {
+			continue
+		}
+		bidder := openrtb_ext.BidderName(bidderName)
+		syncers[bidder] = adapters.NewConfigBidderSyncer(bidder, info.OpenRTB, cfg.ExternalURL, cfg.Adapters[strings.ToLower(bidderName)].UserSyncURL)
+	}
+}
+
+// InsertAliasSyncers adds the syncers of the host's aliases. An alias with its own usersync_url syncs under its own
+// name, the same way as its core bidder. The other aliases share the cookie of their core bidder.
+func InsertAliasSyncers(cfg *config.Configuration, syncers map[openrtb_ext.BidderName]usersync.Usersyncer) {
+	for aliasName, adapter := range cfg.Adapters {
+		if adapter.AliasOf == "" {
+			continue
+		}
+		coreSyncer, hasCoreSyncer := syncers[openrtb_ext.BidderName(adapter.AliasOf)]
+		vendorID := adapter.GVLVendorID
+		if vendorID == 0 && hasCoreSyncer {
+			vendorID = coreSyncer.GDPRVendorID()
+		}
+
+		alias := openrtb_ext.BidderName(aliasName)
+		if adapter.UserSyncURL == "" {
+			if hasCoreSyncer {
+				syncers[alias] = adapters.NewAliasSyncer(coreSyncer, vendorID)
+			}
+			continue
+		}
+
+		syncType := adapters.SyncTypeRedirect
+		if hasCoreSyncer {
+			if coreInfo, err := coreSyncer.GetUsersyncInfo(privacy.Policies{}); err == nil {
+				syncType = adapters.SyncType(coreInfo.Type)
+			}
+		}
+		urlTemplate := template.Must(template.New(aliasName + "_usersync_url").Parse(adapter.UserSyncURL))
+		syncers[alias] = adapters.NewSyncer(aliasName, vendorID, urlTemplate, syncType)
+	}
+}
+
 func insertIntoMap(cfg *config.Configuration, syncers map[openrtb_ext.BidderName]usersync.Usersyncer, bidder openrtb_ext.BidderName, syncerFactory func(*template.Template) usersync.Usersyncer) {
 	lowercased := strings.ToLower(string(bidder))
 	urlString := cfg.Adapters[lowercased].UserSyncURL
@@ -3,9 +3,14 @@ package usersyncers
 import (
 	"strings"
 	"testing"
+	"text/template"
 
+	"github.com/prebid/prebid-server/adapters"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/privacy"
+	"github.com/prebid/prebid-server/privacy/gdpr"
+	"github.com/prebid/prebid-server/usersync"
 )
 
 func TestNewSyncerMap(t *testing.T) {
@@ -137,6 +142,75 @@ func TestVendorIDUniqueness(t *testing.T) {
 	}
 }
 
+func TestInsertConfigBidderSyncers(t *testing.T) {
+	cfg := &config.Configuration{ExternalURL: "http://pbs.example.com"}
+	infos := adapters.BidderInfos{
+		"syncpartner": adapters.BidderInfo{OpenRTB: &adapters.OpenRTBInfo{
+			GVLVendorID: 1234,
+			Usersync: &adapters.UsersyncInfo{
+				URL:  "https://sync.example.com/?redir={{.RedirectURL}}",
+				Type: adapters.SyncTypeRedirect,
+			},
+		}},
+		"nosyncpartner": adapters.BidderInfo{OpenRTB: &adapters.OpenRTBInfo{}},
+		"appnexus":      adapters.BidderInfo{},
+	}
+	syncers := make(map[openrtb_ext.BidderName]usersync.Usersyncer)
+	InsertConfigBidderSyncers(cfg, syncers, infos)
+
+	if len(syncers) != 1 {
+		t.Fatalf("Expected one syncer, got %d", len(syncers))
+	}
+	syncer, ok := syncers["syncpartner"]
+	if !ok {
+		t.Fatalf("No syncer was made for syncpartner")
+	}
+	assertStringsMatch(t, "syncpartner", syncer.FamilyName())
+	if syncer.GDPRVendorID() != 1234 {
+		t.Errorf("Expected vendor ID 1234, got %d", syncer.GDPRVendorID())
+	}
+}
+
+func TestInsertAliasSyncers(t *testing.T) {
+	cfg := &config.Configuration{Adapters: map[string]config.Adapter{
+		"appnexus":    {},
+		"sharedlabel": {AliasOf: "appnexus"},
+		"ownlabel":    {AliasOf: "appnexus", UserSyncURL: "https://sync.ownlabel.com/?gdpr={{.GDPR}}", GVLVendorID: 1234},
+		"nosynclabel": {AliasOf: "adgeneration"},
+	}}
+	coreSyncer := adapters.NewSyncer("adnxs", 32, template.Must(template.New("sync").Parse("https://adnxs.com/sync")), adapters.SyncTypeIframe)
+	syncers := map[openrtb_ext.BidderName]usersync.Usersyncer{openrtb_ext.BidderAppnexus: coreSyncer}
+	InsertAliasSyncers(cfg, syncers)
+
+	if _, ok := syncers["nosynclabel"]; ok {
+		t.Errorf("No syncer should be made for an alias without a usersync_url, whose core bidder has none")
+	}
+
+	shared := syncers["sharedlabel"]
+	if shared == nil {
+		t.Fatalf("No syncer was made for sharedlabel")
+	}
+	assertStringsMatch(t, "adnxs", shared.FamilyName())
+	if shared.GDPRVendorID() != 32 {
+		t.Errorf("Expected the core bidder's vendor ID 32, got %d", shared.GDPRVendorID())
+	}
+
+	own := syncers["ownlabel"]
+	if own == nil {
+		t.Fatalf("No syncer was made for ownlabel")
+	}
+	assertStringsMatch(t, "ownlabel", own.FamilyName())
+	if own.GDPRVendorID() != 1234 {
+		t.Errorf("Expected vendor ID 1234, got %d", own.GDPRVendorID())
+	}
+	syncInfo, err := own.GetUsersyncInfo(privacy.Policies{GDPR: gdpr.Policy{Signal: "1"}})
+	if err != nil {
+		t.Fatalf("Failed to get the usersync info of ownlabel: %v", err)
+	}
+	assertStringsMatch(t, "https://sync.ownlabel.com/?gdpr=1", syncInfo.URL)
+	assertStringsMatch(t, "iframe", syncInfo.Type)
+}
+
 func assertStringsMatch(t *testing.T, expected string, actual string) {
 	t.Helper()
 	if expected != actual {